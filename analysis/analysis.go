@@ -0,0 +1,333 @@
+// Package analysis computes static properties of a workflow before it is
+// deployed: its topological levels, maximum parallelism, critical path,
+// fan-in/fan-out hot spots and estimated makespan.
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gbasilveira/dag-engine/dagengine"
+	"github.com/gbasilveira/dag-engine/orchestrator"
+)
+
+// DefaultHotSpotDegree is the fan-in or fan-out from which a node is
+// reported as a hot spot when Options.HotSpotDegree is 0.
+const DefaultHotSpotDegree = 3
+
+// Options tunes an analysis.
+type Options struct {
+	// Durations are known node durations, e.g. measured in earlier
+	// executions. They take precedence over the declared
+	// NodeDefinition.EstimatedDuration.
+	Durations map[string]time.Duration
+	// DefaultDuration is assumed for nodes with neither a known nor a
+	// declared duration.
+	DefaultDuration time.Duration
+	// HotSpotDegree is the fan-in or fan-out from which a node is reported
+	// as a hot spot (default: DefaultHotSpotDegree).
+	HotSpotDegree int
+}
+
+// Report is the result of analysing a workflow.
+type Report struct {
+	WorkflowID string
+	Version    string
+	Nodes      int
+	Edges      int
+
+	// Levels groups nodes by topological level: roots are in level 0 and
+	// every other node is one level below its deepest dependency.
+	Levels [][]string
+	// Depth is the number of levels, i.e. the longest chain of nodes.
+	Depth int
+	// MaxParallelism is the size of the widest level: the number of nodes
+	// that can run at once when the concurrency is not limited.
+	MaxParallelism int
+
+	// Durations are the node durations the estimates are based on.
+	Durations map[string]time.Duration
+	// Unestimated lists the nodes whose duration is DefaultDuration.
+	Unestimated []string
+
+	// CriticalPath is the chain of dependent nodes with the longest total
+	// duration, which bounds the makespan from below.
+	CriticalPath         []string
+	CriticalPathDuration time.Duration
+	// Makespan is the estimated duration of a run, scheduling nodes like
+	// the engine does: by priority, within MaxConcurrency and the resource
+	// pools of the workflow. Map nodes count as a single task.
+	Makespan time.Duration
+
+	FanIn  []HotSpot // Nodes with the most dependencies
+	FanOut []HotSpot // Nodes with the most dependents
+}
+
+// HotSpot is a node with a high fan-in or fan-out.
+type HotSpot struct {
+	NodeID string
+	Degree int
+}
+
+// graph is the dependency graph of a workflow definition.
+type graph struct {
+	def      *orchestrator.WorkflowDefinition
+	ids      []string // Definition order
+	index    map[string]int
+	deps     map[string][]string
+	children map[string][]string
+	order    []string // Topological order, stable with respect to ids
+}
+
+// Analyze computes the report of a workflow definition. It fails if the
+// dependency graph is not a valid DAG.
+func Analyze(def *orchestrator.WorkflowDefinition, opts Options) (*Report, error) {
+	g, err := newGraph(def)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		WorkflowID: def.WorkflowID,
+		Version:    def.Version,
+		Nodes:      len(g.ids),
+		Durations:  make(map[string]time.Duration, len(g.ids)),
+	}
+	for _, node := range def.Nodes {
+		report.Edges += len(g.deps[node.NodeID])
+		switch duration, known := opts.Durations[node.NodeID]; {
+		case known:
+			report.Durations[node.NodeID] = duration
+		case node.EstimatedDuration > 0:
+			report.Durations[node.NodeID] = node.EstimatedDuration
+		default:
+			report.Durations[node.NodeID] = opts.DefaultDuration
+			report.Unestimated = append(report.Unestimated, node.NodeID)
+		}
+	}
+
+	report.Levels = g.levels()
+	report.Depth = len(report.Levels)
+	for _, level := range report.Levels {
+		if len(level) > report.MaxParallelism {
+			report.MaxParallelism = len(level)
+		}
+	}
+
+	report.CriticalPath, report.CriticalPathDuration = g.criticalPath(report.Durations)
+	if report.Makespan, err = g.simulate(report.Durations); err != nil {
+		return nil, err
+	}
+
+	threshold := opts.HotSpotDegree
+	if threshold <= 0 {
+		threshold = DefaultHotSpotDegree
+	}
+	report.FanIn = g.hotSpots(g.deps, threshold)
+	report.FanOut = g.hotSpots(g.children, threshold)
+
+	return report, nil
+}
+
+// newGraph validates the dependencies of def and sorts its nodes topologically.
+func newGraph(def *orchestrator.WorkflowDefinition) (*graph, error) {
+	g := &graph{
+		def:      def,
+		index:    make(map[string]int, len(def.Nodes)),
+		deps:     make(map[string][]string, len(def.Nodes)),
+		children: make(map[string][]string, len(def.Nodes)),
+	}
+	for i, node := range def.Nodes {
+		if _, exists := g.index[node.NodeID]; exists {
+			return nil, fmt.Errorf("duplicate node ID: %s", node.NodeID)
+		}
+		g.ids = append(g.ids, node.NodeID)
+		g.index[node.NodeID] = i
+		g.deps[node.NodeID] = node.Dependencies
+	}
+	if err := dagengine.ValidateGraph(g.ids, g.deps); err != nil {
+		return nil, err
+	}
+
+	pending := make(map[string]int, len(g.ids))
+	for _, id := range g.ids {
+		pending[id] = len(g.deps[id])
+		for _, dep := range g.deps[id] {
+			g.children[dep] = append(g.children[dep], id)
+		}
+	}
+
+	// Kahn's algorithm, visiting ready nodes in definition order
+	var queue []string
+	for _, id := range g.ids {
+		if pending[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		g.order = append(g.order, id)
+		for _, child := range g.children[id] {
+			pending[child]--
+			if pending[child] == 0 {
+				queue = append(queue, child)
+			}
+		}
+	}
+	return g, nil
+}
+
+// levels groups the nodes by topological level, in definition order.
+func (g *graph) levels() [][]string {
+	level := make(map[string]int, len(g.ids))
+	depth := 0
+	for _, id := range g.order {
+		for _, dep := range g.deps[id] {
+			if level[dep]+1 > level[id] {
+				level[id] = level[dep] + 1
+			}
+		}
+		if level[id]+1 > depth {
+			depth = level[id] + 1
+		}
+	}
+
+	levels := make([][]string, depth)
+	for _, id := range g.ids {
+		levels[level[id]] = append(levels[level[id]], id)
+	}
+	return levels
+}
+
+// criticalPath returns the chain of nodes with the longest total duration.
+// Ties go to the node defined or listed as a dependency first.
+func (g *graph) criticalPath(durations map[string]time.Duration) ([]string, time.Duration) {
+	finish := make(map[string]time.Duration, len(g.ids))
+	via := make(map[string]string, len(g.ids))
+	for _, id := range g.order {
+		for _, dep := range g.deps[id] {
+			if via[id] == "" || finish[dep] > finish[via[id]] {
+				via[id] = dep
+			}
+		}
+		finish[id] = finish[via[id]] + durations[id]
+	}
+
+	var last string
+	for _, id := range g.ids {
+		if last == "" || finish[id] > finish[last] {
+			last = id
+		}
+	}
+	if last == "" {
+		return nil, 0
+	}
+
+	var path []string
+	for id := last; id != ""; id = via[id] {
+		path = append([]string{id}, path...)
+	}
+	return path, finish[last]
+}
+
+// simulate estimates the makespan of a run by replaying the engine's
+// scheduling: ready nodes start by descending priority, then in the order
+// they became ready, as long as MaxConcurrency and their pool allow.
+func (g *graph) simulate(durations map[string]time.Duration) (time.Duration, error) {
+	nodes := make(map[string]orchestrator.NodeDefinition, len(g.ids))
+	for _, node := range g.def.Nodes {
+		slots := poolSlots(node)
+		if slots > 0 && slots > g.def.Pools[node.Pool] {
+			return 0, fmt.Errorf("node %s claims %d slots of pool %s, which only has %d", node.NodeID, slots, node.Pool, g.def.Pools[node.Pool])
+		}
+		nodes[node.NodeID] = node
+	}
+
+	type task struct {
+		id  string
+		end time.Duration
+	}
+	var (
+		now       time.Duration
+		ready     []string // Sorted by priority, then readiness
+		running   []task
+		poolUsage = make(map[string]int)
+		pending   = make(map[string]int, len(g.ids))
+	)
+	enqueue := func(id string) {
+		i := sort.Search(len(ready), func(i int) bool {
+			return nodes[ready[i]].Priority < nodes[id].Priority
+		})
+		ready = append(ready, "")
+		copy(ready[i+1:], ready[i:])
+		ready[i] = id
+	}
+	for _, id := range g.ids {
+		pending[id] = len(g.deps[id])
+		if pending[id] == 0 {
+			enqueue(id)
+		}
+	}
+
+	for len(ready) > 0 || len(running) > 0 {
+		remaining := ready[:0]
+		for _, id := range ready {
+			node := nodes[id]
+			if g.def.MaxConcurrency > 0 && len(running) >= g.def.MaxConcurrency {
+				remaining = append(remaining, id)
+				continue
+			}
+			if slots := poolSlots(node); slots > 0 {
+				if poolUsage[node.Pool]+slots > g.def.Pools[node.Pool] {
+					remaining = append(remaining, id)
+					continue
+				}
+				poolUsage[node.Pool] += slots
+			}
+			running = append(running, task{id: id, end: now + durations[id]})
+		}
+		ready = remaining
+
+		// Advance to the next completion and release its capacity
+		sort.SliceStable(running, func(i, j int) bool { return running[i].end < running[j].end })
+		done := running[0]
+		running = running[1:]
+		now = done.end
+		if slots := poolSlots(nodes[done.id]); slots > 0 {
+			poolUsage[nodes[done.id].Pool] -= slots
+		}
+		for _, child := range g.children[done.id] {
+			pending[child]--
+			if pending[child] == 0 {
+				enqueue(child)
+			}
+		}
+	}
+	return now, nil
+}
+
+// poolSlots returns how many slots of its pool a node claims.
+func poolSlots(node orchestrator.NodeDefinition) int {
+	if node.Pool == "" {
+		return 0
+	}
+	if node.PoolSlots <= 0 {
+		return 1
+	}
+	return node.PoolSlots
+}
+
+// hotSpots returns the nodes with at least threshold neighbours in edges,
+// by descending degree and then definition order.
+func (g *graph) hotSpots(edges map[string][]string, threshold int) []HotSpot {
+	var spots []HotSpot
+	for _, id := range g.ids {
+		if degree := len(edges[id]); degree >= threshold {
+			spots = append(spots, HotSpot{NodeID: id, Degree: degree})
+		}
+	}
+	sort.SliceStable(spots, func(i, j int) bool { return spots[i].Degree > spots[j].Degree })
+	return spots
+}
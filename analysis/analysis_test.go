@@ -0,0 +1,132 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gbasilveira/dag-engine/orchestrator"
+)
+
+// newETL returns extract -> (t1, t2, t3) -> load -> report, where report has
+// no declared duration.
+func newETL() *orchestrator.WorkflowDefinition {
+	return &orchestrator.WorkflowDefinition{
+		WorkflowID: "etl",
+		Version:    "1.0.0",
+		Nodes: []orchestrator.NodeDefinition{
+			{NodeID: "extract", EstimatedDuration: 10 * time.Second},
+			{NodeID: "t1", Dependencies: []string{"extract"}, EstimatedDuration: 30 * time.Second},
+			{NodeID: "t2", Dependencies: []string{"extract"}, EstimatedDuration: 5 * time.Second},
+			{NodeID: "t3", Dependencies: []string{"extract"}, EstimatedDuration: 5 * time.Second},
+			{NodeID: "load", Dependencies: []string{"t1", "t2", "t3"}, EstimatedDuration: 20 * time.Second},
+			{NodeID: "report", Dependencies: []string{"load"}},
+		},
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	report, err := Analyze(newETL(), Options{DefaultDuration: time.Second})
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	wantLevels := [][]string{{"extract"}, {"t1", "t2", "t3"}, {"load"}, {"report"}}
+	if !reflect.DeepEqual(report.Levels, wantLevels) {
+		t.Errorf("Expected levels %v, got %v", wantLevels, report.Levels)
+	}
+	if report.Depth != 4 || report.MaxParallelism != 3 || report.Nodes != 6 || report.Edges != 7 {
+		t.Errorf("Expected depth 4, parallelism 3, 6 nodes and 7 edges, got %d, %d, %d and %d",
+			report.Depth, report.MaxParallelism, report.Nodes, report.Edges)
+	}
+
+	wantPath := []string{"extract", "t1", "load", "report"}
+	if !reflect.DeepEqual(report.CriticalPath, wantPath) || report.CriticalPathDuration != 61*time.Second {
+		t.Errorf("Expected critical path %v of 61s, got %v of %v", wantPath, report.CriticalPath, report.CriticalPathDuration)
+	}
+	if report.Makespan != 61*time.Second {
+		t.Errorf("Expected a makespan of 61s without limits, got %v", report.Makespan)
+	}
+	if !reflect.DeepEqual(report.Unestimated, []string{"report"}) {
+		t.Errorf("Expected report to be unestimated, got %v", report.Unestimated)
+	}
+
+	if !reflect.DeepEqual(report.FanIn, []HotSpot{{NodeID: "load", Degree: 3}}) {
+		t.Errorf("Expected load to be the fan-in hot spot, got %v", report.FanIn)
+	}
+	if !reflect.DeepEqual(report.FanOut, []HotSpot{{NodeID: "extract", Degree: 3}}) {
+		t.Errorf("Expected extract to be the fan-out hot spot, got %v", report.FanOut)
+	}
+}
+
+func TestAnalyzeMakespan(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(def *orchestrator.WorkflowDefinition)
+		opts   Options
+		want   time.Duration
+		path   []string
+	}{
+		{
+			name:   "serial",
+			modify: func(def *orchestrator.WorkflowDefinition) { def.MaxConcurrency = 1 },
+			want:   71 * time.Second,
+			path:   []string{"extract", "t1", "load", "report"},
+		},
+		{
+			name: "shared pool",
+			modify: func(def *orchestrator.WorkflowDefinition) {
+				def.Pools = map[string]int{"db": 1}
+				for i := 1; i <= 3; i++ {
+					def.Nodes[i].Pool = "db"
+				}
+			},
+			want: 71 * time.Second,
+			path: []string{"extract", "t1", "load", "report"},
+		},
+		{
+			name:   "historical durations",
+			modify: func(def *orchestrator.WorkflowDefinition) {},
+			opts:   Options{Durations: map[string]time.Duration{"t1": time.Second, "report": 3 * time.Second}},
+			want:   38 * time.Second,
+			path:   []string{"extract", "t2", "load", "report"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := newETL()
+			tt.modify(def)
+			if tt.opts.DefaultDuration == 0 {
+				tt.opts.DefaultDuration = time.Second
+			}
+
+			report, err := Analyze(def, tt.opts)
+			if err != nil {
+				t.Fatalf("Analyze failed: %v", err)
+			}
+			if report.Makespan != tt.want {
+				t.Errorf("Expected a makespan of %v, got %v", tt.want, report.Makespan)
+			}
+			if !reflect.DeepEqual(report.CriticalPath, tt.path) {
+				t.Errorf("Expected critical path %v, got %v", tt.path, report.CriticalPath)
+			}
+		})
+	}
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	cyclic := newETL()
+	cyclic.Nodes[0].Dependencies = []string{"report"}
+	if _, err := Analyze(cyclic, Options{}); err == nil {
+		t.Error("Expected an error for a cyclic workflow")
+	}
+
+	oversized := newETL()
+	oversized.Pools = map[string]int{"db": 1}
+	oversized.Nodes[4].Pool = "db"
+	oversized.Nodes[4].PoolSlots = 2
+	if _, err := Analyze(oversized, Options{}); err == nil {
+		t.Error("Expected an error for a node that claims more slots than its pool has")
+	}
+}
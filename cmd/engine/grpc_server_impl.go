@@ -41,7 +41,16 @@ func (s *engineGRPCServer) ExecuteWorkflow(ctx context.Context, req *proto.Workf
 	eng := dagengine.NewDAGEngine()
 	
 	// Execute the workflow
-	err := s.engineService.ExecuteWorkflow(ctx, req.WorkflowId, req.WorkflowVersion, req.ExecutionId, eng)
+	inputs := make(map[string]interface{}, len(req.Inputs))
+	for k, v := range req.Inputs {
+		inputs[k] = v
+	}
+	var err error
+	if req.RerunFrom != "" {
+		err = s.engineService.RerunWorkflow(ctx, req.WorkflowId, req.WorkflowVersion, req.ExecutionId, req.RerunFrom, eng)
+	} else {
+		err = s.engineService.ExecuteWorkflow(ctx, req.WorkflowId, req.WorkflowVersion, req.ExecutionId, eng, inputs)
+	}
 	if err != nil {
 		return &proto.WorkflowResponse{
 			ExecutionId:  req.ExecutionId,
@@ -65,7 +74,11 @@ func (s *engineGRPCServer) ExecuteSubWorkflow(ctx context.Context, req *proto.Su
 	// Similar to ExecuteWorkflow but for sub-workflows
 	eng := dagengine.NewDAGEngine()
 	
-	err := s.engineService.ExecuteWorkflow(ctx, req.SubWorkflowId, req.SubWorkflowVersion, req.ExecutionId, eng)
+	inputs := make(map[string]interface{}, len(req.Inputs))
+	for k, v := range req.Inputs {
+		inputs[k] = v
+	}
+	err := s.engineService.ExecuteWorkflow(ctx, req.SubWorkflowId, req.SubWorkflowVersion, req.ExecutionId, eng, inputs)
 	if err != nil {
 		return &proto.SubWorkflowResponse{
 			ExecutionId:  req.ExecutionId,
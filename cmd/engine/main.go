@@ -12,6 +12,7 @@ import (
 	
 	"google.golang.org/grpc"
 	
+	"github.com/gbasilveira/dag-engine/dagengine"
 	"github.com/gbasilveira/dag-engine/orchestrator/engine"
 	
 	// Uncomment after running ./generate-proto.sh:
@@ -23,6 +24,8 @@ var (
 	port        = flag.Int("port", 50051, "gRPC server port")
 	capacity    = flag.Int("capacity", 10, "Maximum concurrent workflows")
 	address     = flag.String("address", "0.0.0.0", "Server address")
+	stateDir    = flag.String("state-dir", "", "Directory for execution checkpoints (default: $STATE_DIR; empty disables checkpointing)")
+	cacheDir    = flag.String("cache-dir", "", "Directory for cached node results (default: $CACHE_DIR; empty disables caching)")
 )
 
 func main() {
@@ -48,6 +51,32 @@ func main() {
 	// Create engine service
 	engineService := engine.NewEngineService(*engineID, *capacity)
 	
+	// Persist checkpoints so that executions survive a restart of the engine
+	if *stateDir == "" {
+		*stateDir = os.Getenv("STATE_DIR")
+	}
+	if *stateDir != "" {
+		store, err := dagengine.NewFileStateStore(*stateDir)
+		if err != nil {
+			log.Fatalf("Failed to open state directory: %v", err)
+		}
+		engineService.SetStateStore(store)
+		log.Printf("Checkpoints: %s", *stateDir)
+	}
+	
+	// Share the results of cached nodes between executions
+	if *cacheDir == "" {
+		*cacheDir = os.Getenv("CACHE_DIR")
+	}
+	if *cacheDir != "" {
+		cache, err := dagengine.NewFileResultCache(*cacheDir)
+		if err != nil {
+			log.Fatalf("Failed to open cache directory: %v", err)
+		}
+		engineService.SetResultCache(cache)
+		log.Printf("Result cache: %s", *cacheDir)
+	}
+	
 	// Create gRPC server
 	lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", *address, *port))
 	if err != nil {
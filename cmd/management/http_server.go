@@ -2,11 +2,16 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/gbasilveira/dag-engine/analysis"
+	"github.com/gbasilveira/dag-engine/dagengine"
 	"github.com/gbasilveira/dag-engine/orchestrator"
 	"github.com/gbasilveira/dag-engine/spec"
 )
@@ -42,10 +47,15 @@ func NewHTTPServer(port int, orchestratorAddr string) (*HTTPServer, error) {
 func (s *HTTPServer) setupRoutes() {
 	// Workflow CRUD operations
 	s.mux.HandleFunc("POST /api/v1/workflows", s.handleCreateWorkflow)
+	s.mux.HandleFunc("POST /api/v1/workflows/plan", s.handlePlanWorkflow)
 	s.mux.HandleFunc("PUT /api/v1/workflows/{id}", s.handleUpdateWorkflow)
 	s.mux.HandleFunc("DELETE /api/v1/workflows/{id}", s.handleDeleteWorkflow)
 	s.mux.HandleFunc("GET /api/v1/workflows", s.handleListWorkflows)
 	s.mux.HandleFunc("GET /api/v1/workflows/{id}", s.handleGetWorkflow)
+	s.mux.HandleFunc("GET /api/v1/workflows/{id}/graph", s.handleGetWorkflowGraph)
+
+	// Execution operations
+	s.mux.HandleFunc("POST /api/v1/executions/{id}/rerun", s.handleRerunExecution)
 
 	// Health check
 	s.mux.HandleFunc("GET /health", s.handleHealth)
@@ -106,6 +116,81 @@ func (s *HTTPServer) handleCreateWorkflow(w http.ResponseWriter, r *http.Request
 	})
 }
 
+// handlePlanWorkflow handles POST /api/v1/workflows/plan
+// It validates a workflow without registering it and reports its levels,
+// critical path, hot spots and estimated makespan.
+func (s *HTTPServer) handlePlanWorkflow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	opts := analysis.Options{}
+	if value := r.URL.Query().Get("default_duration_seconds"); value != "" {
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil || seconds < 0 {
+			respondError(w, http.StatusBadRequest, "Invalid default_duration_seconds",
+				fmt.Errorf("expected a non-negative number of seconds: %s", value))
+			return
+		}
+		opts.DefaultDuration = time.Duration(seconds * float64(time.Second))
+	}
+
+	// Parse and validate YAML from request body
+	yamlSpec, err := ParseYAML(r.Body)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Failed to parse YAML", err)
+		return
+	}
+
+	def, err := ConvertYAMLToWorkflowDefinition(yamlSpec)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Failed to convert workflow definition", err)
+		return
+	}
+
+	report, err := analysis.Analyze(def, opts)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Failed to analyze workflow", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, planResponse(report))
+}
+
+// planResponse converts an analysis report to its JSON form, with durations in seconds.
+func planResponse(report *analysis.Report) map[string]interface{} {
+	durations := make(map[string]float64, len(report.Durations))
+	for nodeID, duration := range report.Durations {
+		durations[nodeID] = duration.Seconds()
+	}
+	hotSpots := func(spots []analysis.HotSpot) []map[string]interface{} {
+		result := make([]map[string]interface{}, 0, len(spots))
+		for _, spot := range spots {
+			result = append(result, map[string]interface{}{"node_id": spot.NodeID, "degree": spot.Degree})
+		}
+		return result
+	}
+
+	return map[string]interface{}{
+		"success":                        true,
+		"workflow_id":                    report.WorkflowID,
+		"version":                        report.Version,
+		"nodes":                          report.Nodes,
+		"edges":                          report.Edges,
+		"levels":                         report.Levels,
+		"depth":                          report.Depth,
+		"max_parallelism":                report.MaxParallelism,
+		"durations_seconds":              durations,
+		"unestimated":                    report.Unestimated,
+		"critical_path":                  report.CriticalPath,
+		"critical_path_duration_seconds": report.CriticalPathDuration.Seconds(),
+		"makespan_seconds":               report.Makespan.Seconds(),
+		"fan_in":                         hotSpots(report.FanIn),
+		"fan_out":                        hotSpots(report.FanOut),
+	}
+}
+
 // handleUpdateWorkflow handles PUT /api/v1/workflows/{id}
 func (s *HTTPServer) handleUpdateWorkflow(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
@@ -218,6 +303,118 @@ func (s *HTTPServer) handleGetWorkflow(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, workflow)
 }
 
+// graphContentTypes are the response content types of the graph formats
+var graphContentTypes = map[string]string{
+	dagengine.FormatDOT:     "text/vnd.graphviz; charset=utf-8",
+	dagengine.FormatMermaid: "text/plain; charset=utf-8",
+	dagengine.FormatJSON:    "application/json",
+}
+
+// handleGetWorkflowGraph handles GET /api/v1/workflows/{id}/graph?format=dot|mermaid|json
+func (s *HTTPServer) handleGetWorkflowGraph(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	workflowID := r.PathValue("id")
+	version := r.URL.Query().Get("version")
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = dagengine.FormatJSON
+	}
+	contentType, ok := graphContentTypes[format]
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Unsupported graph format",
+			fmt.Errorf("format must be one of dot, mermaid, json: %s", format))
+		return
+	}
+
+	// Colour nodes by their status in an execution of the workflow
+	var statuses map[string]string
+	if executionID := r.URL.Query().Get("execution"); executionID != "" {
+		execution, err := s.client.GetExecution(r.Context(), executionID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to get execution", err)
+			return
+		}
+		if execution == nil {
+			http.Error(w, "Execution not found", http.StatusNotFound)
+			return
+		}
+		if execution.WorkflowID != workflowID {
+			respondError(w, http.StatusBadRequest, "Workflow ID mismatch",
+				fmt.Errorf("execution %s belongs to workflow %s", executionID, execution.WorkflowID))
+			return
+		}
+		if version == "" {
+			version = execution.Version
+		}
+		statuses = execution.NodeStatuses
+	}
+
+	workflow, err := s.client.GetWorkflow(r.Context(), workflowID, version)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get workflow", err)
+		return
+	}
+
+	if workflow == nil {
+		http.Error(w, "Workflow not found", http.StatusNotFound)
+		return
+	}
+
+	graph := workflow.Graph()
+	if statuses != nil {
+		graph.SetStatuses(statuses)
+	}
+	data, err := graph.Export(format)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to export graph", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// handleRerunExecution handles POST /api/v1/executions/{id}/rerun?from=node
+func (s *HTTPServer) handleRerunExecution(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	executionID := r.PathValue("id")
+	fromNode := r.URL.Query().Get("from")
+	if fromNode == "" {
+		respondError(w, http.StatusBadRequest, "Missing node to rerun from",
+			fmt.Errorf("the 'from' query parameter is required"))
+		return
+	}
+
+	err := s.client.RerunExecution(r.Context(), executionID, fromNode)
+	switch {
+	case errors.Is(err, orchestrator.ErrExecutionNotFound):
+		respondError(w, http.StatusNotFound, "Execution not found", err)
+		return
+	case errors.Is(err, orchestrator.ErrNodeNotFound):
+		respondError(w, http.StatusBadRequest, "Unknown node to rerun from", err)
+		return
+	case err != nil:
+		respondError(w, http.StatusInternalServerError, "Failed to rerun execution", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success":      true,
+		"execution_id": executionID,
+		"from":         fromNode,
+		"message":      "Execution rerun successfully",
+	})
+}
+
 // handleHealth handles GET /health
 func (s *HTTPServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{
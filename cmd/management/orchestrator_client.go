@@ -87,3 +87,17 @@ func (c *OrchestratorClient) GetWorkflow(ctx context.Context, workflowID, versio
 	return nil, fmt.Errorf("gRPC client not yet implemented - need to generate proto files first")
 }
 
+// RerunExecution re-executes a node and its descendants in a finished execution
+func (c *OrchestratorClient) RerunExecution(ctx context.Context, executionID, fromNode string) error {
+	_ = ctx
+	_ = executionID
+	_ = fromNode
+	return fmt.Errorf("gRPC client not yet implemented - need to generate proto files first")
+}
+
+// GetExecution retrieves an execution and the status of its nodes
+func (c *OrchestratorClient) GetExecution(ctx context.Context, executionID string) (*orchestrator.ExecutionInfo, error) {
+	_ = ctx
+	_ = executionID
+	return nil, fmt.Errorf("gRPC client not yet implemented - need to generate proto files first")
+}
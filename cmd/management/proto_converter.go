@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/gbasilveira/dag-engine/dagengine"
 	"github.com/gbasilveira/dag-engine/orchestrator"
 	"github.com/gbasilveira/dag-engine/spec"
 )
@@ -22,6 +24,16 @@ func ConvertYAMLToWorkflowDefinition(yamlSpec *spec.WorkflowSpec) (*orchestrator
 			ExecutorType: yamlNode.Executor.Type,
 			ExecutorCode: yamlNode.Executor.Code,
 			ExecutorConfig: yamlNode.Executor.Config,
+			Retry:        convertRetrySpec(yamlNode.Retry, yamlNode.Metadata),
+			Timeout:      convertNodeTimeout(yamlNode.TimeoutSec, yamlNode.Metadata),
+			Condition:    yamlNode.Condition,
+			TriggerRule:  yamlNode.TriggerRule,
+			Map:          convertMapSpec(yamlNode.Map),
+			Priority:     yamlNode.Priority,
+			Pool:         yamlNode.Pool,
+			PoolSlots:    yamlNode.PoolSlots,
+			Cache:        convertCacheSpec(yamlNode.Cache),
+			EstimatedDuration: time.Duration(yamlNode.EstimatedDurationSec * float64(time.Second)),
 			Metadata:     yamlNode.Metadata,
 		}
 
@@ -48,6 +60,7 @@ func ConvertYAMLToWorkflowDefinition(yamlSpec *spec.WorkflowSpec) (*orchestrator
 				"port":   yamlSpec.Spec.Triggers.HTTP.Port,
 				"path":   yamlSpec.Spec.Triggers.HTTP.Path,
 				"method": yamlSpec.Spec.Triggers.HTTP.Method,
+				"timeout_seconds": yamlSpec.Spec.Triggers.HTTP.TimeoutSec,
 			}
 		}
 		if yamlSpec.Spec.Triggers.Cron != nil {
@@ -111,13 +124,77 @@ func ConvertYAMLToWorkflowDefinition(yamlSpec *spec.WorkflowSpec) (*orchestrator
 	}
 
 	def := &orchestrator.WorkflowDefinition{
-		WorkflowID: yamlSpec.Metadata.ID,
-		Version:    yamlSpec.Metadata.Version,
-		Name:      yamlSpec.Metadata.Name,
-		Nodes:     nodes,
-		Metadata:  metadata,
+		WorkflowID:    yamlSpec.Metadata.ID,
+		Version:       yamlSpec.Metadata.Version,
+		Name:          yamlSpec.Metadata.Name,
+		Nodes:         nodes,
+		FailurePolicy: yamlSpec.Spec.FailurePolicy,
+		Timeout:       time.Duration(yamlSpec.Spec.TimeoutSec) * time.Second, // 0: orchestrator.DefaultWorkflowTimeout
+		MaxConcurrency: yamlSpec.Spec.MaxConcurrency,
+		Pools:         yamlSpec.Spec.Pools,
+		Metadata:      metadata,
 	}
 
 	return def, nil
 }
 
+
+// convertRetrySpec converts a node's retry spec to an engine retry policy.
+// Nodes without a retry block fall back to the legacy metadata.retries count.
+func convertRetrySpec(retry *spec.RetrySpec, metadata map[string]interface{}) *dagengine.RetryPolicy {
+	if retry == nil {
+		retries, ok := metadata["retries"].(int)
+		if !ok || retries <= 0 {
+			return nil
+		}
+		return &dagengine.RetryPolicy{
+			MaxAttempts:  retries + 1,
+			Backoff:      dagengine.BackoffExponential,
+			InitialDelay: time.Second,
+		}
+	}
+
+	return &dagengine.RetryPolicy{
+		MaxAttempts:  retry.MaxAttempts,
+		Backoff:      dagengine.BackoffStrategy(retry.Backoff),
+		InitialDelay: time.Duration(retry.InitialDelaySeconds * float64(time.Second)),
+		MaxDelay:     time.Duration(retry.MaxDelaySeconds * float64(time.Second)),
+		Multiplier:   retry.Multiplier,
+		Jitter:       retry.Jitter,
+		RetryOn:      retry.RetryOn,
+	}
+}
+
+// convertNodeTimeout returns a node's per-attempt deadline.
+// Nodes without timeout_seconds fall back to the legacy metadata.timeout (seconds).
+func convertNodeTimeout(timeoutSec int, metadata map[string]interface{}) time.Duration {
+	if timeoutSec == 0 {
+		timeoutSec, _ = metadata["timeout"].(int)
+	}
+	if timeoutSec <= 0 {
+		return 0
+	}
+	return time.Duration(timeoutSec) * time.Second
+}
+
+// convertCacheSpec converts a node's cache spec to an engine cache configuration.
+func convertCacheSpec(cacheSpec *spec.CacheSpec) *dagengine.CacheConfig {
+	if cacheSpec == nil {
+		return nil
+	}
+	return &dagengine.CacheConfig{
+		TTL: time.Duration(cacheSpec.TTLSec) * time.Second,
+	}
+}
+
+// convertMapSpec converts a node's map spec to an engine map configuration.
+func convertMapSpec(mapSpec *spec.MapSpec) *dagengine.MapConfig {
+	if mapSpec == nil {
+		return nil
+	}
+	return &dagengine.MapConfig{
+		Over:        mapSpec.Over,
+		As:          mapSpec.As,
+		Concurrency: mapSpec.Concurrency,
+	}
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/gbasilveira/dag-engine/spec"
 )
 
@@ -146,3 +149,699 @@ spec:
 	}
 }
 
+
+func TestValidateYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string // Part of the expected error
+	}{
+		{
+			name: "cyclic dependencies",
+			yaml: `
+apiVersion: workflows/v1
+kind: Workflow
+metadata:
+  id: "test"
+  name: "Test"
+  version: "1.0.0"
+spec:
+  nodes:
+    - id: "extract"
+      dependencies: ["load"]
+      executor:
+        type: "lua"
+        code: "print('extract')"
+    - id: "load"
+      dependencies: ["extract"]
+      executor:
+        type: "lua"
+        code: "print('load')"
+`,
+			wantErr: "cycle detected: extract -> load -> extract",
+		},
+		{
+			name: "invalid retry backoff",
+			yaml: `
+apiVersion: workflows/v1
+kind: Workflow
+metadata:
+  id: "test"
+  name: "Test"
+  version: "1.0.0"
+spec:
+  nodes:
+    - id: "extract"
+      retry:
+        max_attempts: 3
+        backoff: "linear"
+      executor:
+        type: "lua"
+        code: "print('extract')"
+`,
+			wantErr: "unsupported backoff: linear",
+		},
+		{
+			name: "invalid trigger rule",
+			yaml: `
+apiVersion: workflows/v1
+kind: Workflow
+metadata:
+  id: "test"
+  name: "Test"
+  version: "1.0.0"
+spec:
+  nodes:
+    - id: "extract"
+      executor:
+        type: "lua"
+        code: "print('extract')"
+    - id: "cleanup"
+      dependencies: ["extract"]
+      trigger_rule: "on_failure"
+      executor:
+        type: "lua"
+        code: "print('cleanup')"
+`,
+			wantErr: "unsupported trigger_rule: on_failure",
+		},
+		{
+			name: "map over non-dependency",
+			yaml: `
+apiVersion: workflows/v1
+kind: Workflow
+metadata:
+  id: "test"
+  name: "Test"
+  version: "1.0.0"
+spec:
+  nodes:
+    - id: "discover"
+      executor:
+        type: "lua"
+        code: "return {files = {}}"
+    - id: "process"
+      map:
+        over: "discover.files"
+        as: "file"
+        concurrency: 4
+      executor:
+        type: "lua"
+        code: "print(inputs.file)"
+`,
+			wantErr: "does not read from one of the node's dependencies",
+		},
+		{
+			name: "map element named after a dependency",
+			yaml: `
+apiVersion: workflows/v1
+kind: Workflow
+metadata:
+  id: "test"
+  name: "Test"
+  version: "1.0.0"
+spec:
+  nodes:
+    - id: "discover"
+      executor:
+        type: "lua"
+        code: "return {files = {}}"
+    - id: "process"
+      dependencies: ["discover"]
+      map:
+        over: "discover.files"
+        as: "discover"
+      executor:
+        type: "lua"
+        code: "print(inputs.discover)"
+`,
+			wantErr: "map element name 'discover' is the name of a dependency",
+		},
+		{
+			name: "undefined pool",
+			yaml: `
+apiVersion: workflows/v1
+kind: Workflow
+metadata:
+  id: "test"
+  name: "Test"
+  version: "1.0.0"
+spec:
+  max_concurrency: 4
+  pools:
+    db: 2
+  nodes:
+    - id: "extract"
+      pool: "warehouse"
+      priority: 10
+      executor:
+        type: "lua"
+        code: "print('extract')"
+`,
+			wantErr: "undefined pool: warehouse",
+		},
+		{
+			name: "negative cache ttl",
+			yaml: `
+apiVersion: workflows/v1
+kind: Workflow
+metadata:
+  id: "test"
+  name: "Test"
+  version: "1.0.0"
+spec:
+  nodes:
+    - id: "transform"
+      cache:
+        ttl_seconds: -60
+      executor:
+        type: "lua"
+        code: "print('transform')"
+`,
+			wantErr: "ttl_seconds must not be negative",
+		},
+		{
+			name: "unknown lua module",
+			yaml: `
+apiVersion: workflows/v1
+kind: Workflow
+metadata:
+  id: "test"
+  name: "Test"
+  version: "1.0.0"
+spec:
+  nodes:
+    - id: "transform"
+      executor:
+        type: "lua"
+        code: "return {}"
+        config:
+          modules: ["json", "socket"]
+`,
+			wantErr: "unknown lua module 'socket'",
+		},
+		{
+			name: "shell without code",
+			yaml: `
+apiVersion: workflows/v1
+kind: Workflow
+metadata:
+  id: "test"
+  name: "Test"
+  version: "1.0.0"
+spec:
+  nodes:
+    - id: "backup"
+      executor:
+        type: "shell"
+        config:
+          interpreter: "bash -c"
+`,
+			wantErr: "code is required for shell executor",
+		},
+		{
+			name: "http without url",
+			yaml: `
+apiVersion: workflows/v1
+kind: Workflow
+metadata:
+  id: "test"
+  name: "Test"
+  version: "1.0.0"
+spec:
+  nodes:
+    - id: "notify"
+      executor:
+        type: "http"
+        config:
+          method: "POST"
+`,
+			wantErr: "http url is required",
+		},
+		{
+			name: "grpc without method",
+			yaml: `
+apiVersion: workflows/v1
+kind: Workflow
+metadata:
+  id: "test"
+  name: "Test"
+  version: "1.0.0"
+spec:
+  nodes:
+    - id: "lookup"
+      executor:
+        type: "grpc"
+        config:
+          address: "users:9090"
+`,
+			wantErr: "grpc method",
+		},
+		{
+			name: "unknown executor config key",
+			yaml: `
+apiVersion: workflows/v1
+kind: Workflow
+metadata:
+  id: "test"
+  name: "Test"
+  version: "1.0.0"
+spec:
+  nodes:
+    - id: "notify"
+      executor:
+        type: "http"
+        config:
+          url: "https://hooks.internal/notify"
+          timeout: 5
+`,
+			wantErr: "unknown http config key 'timeout'",
+		},
+		{
+			name: "wasm without module",
+			yaml: `
+apiVersion: workflows/v1
+kind: Workflow
+metadata:
+  id: "test"
+  name: "Test"
+  version: "1.0.0"
+spec:
+  nodes:
+    - id: "enrich"
+      executor:
+        type: "wasm"
+        config:
+          max_fuel: 1000000
+`,
+			wantErr: "wasm module is required",
+		},
+		{
+			name: "subworkflow with version range",
+			yaml: `
+apiVersion: workflows/v1
+kind: Workflow
+metadata:
+  id: "test"
+  name: "Test"
+  version: "1.0.0"
+spec:
+  nodes:
+    - id: "enrich"
+      executor:
+        type: "subworkflow"
+        config:
+          workflow_id: "enrich-user"
+          version: ">=1.2"
+`,
+			wantErr: "version constraint '>=1.2'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseYAMLFromBytes([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// roundTripHeader starts the workflows of TestParseYAMLRoundTrip, which add
+// their spec section.
+const roundTripHeader = `
+apiVersion: workflows/v1
+kind: Workflow
+metadata:
+  id: "test"
+  name: "Test"
+  version: "1.0.0"
+`
+
+func TestParseYAMLRoundTrip(t *testing.T) {
+	lua := func(code string) spec.ExecutorSpec {
+		return spec.ExecutorSpec{Type: "lua", Code: code}
+	}
+
+	tests := []struct {
+		name string
+		yaml string
+		want spec.WorkflowSpecDef
+	}{
+		{
+			name: "failure policy",
+			yaml: `
+spec:
+  failure_policy: "continue"
+  nodes:
+    - id: "extract"
+      executor:
+        type: "lua"
+        code: "print('extract')"
+`,
+			want: spec.WorkflowSpecDef{
+				FailurePolicy: "continue",
+				Nodes:         []spec.NodeSpec{{ID: "extract", Executor: lua("print('extract')")}},
+			},
+		},
+		{
+			name: "retry",
+			yaml: `
+spec:
+  nodes:
+    - id: "notify"
+      retry:
+        max_attempts: 4
+        backoff: "exponential"
+        initial_delay_seconds: 0.5
+        max_delay_seconds: 30
+        multiplier: 3
+        jitter: 0.2
+        retry_on: ["timeout", "unavailable"]
+      executor:
+        type: "lua"
+        code: "print('notify')"
+`,
+			want: spec.WorkflowSpecDef{Nodes: []spec.NodeSpec{{
+				ID: "notify",
+				Retry: &spec.RetrySpec{
+					MaxAttempts:         4,
+					Backoff:             "exponential",
+					InitialDelaySeconds: 0.5,
+					MaxDelaySeconds:     30,
+					Multiplier:          3,
+					Jitter:              0.2,
+					RetryOn:             []string{"timeout", "unavailable"},
+				},
+				Executor: lua("print('notify')"),
+			}}},
+		},
+		{
+			name: "timeouts",
+			yaml: `
+spec:
+  timeout_seconds: 300
+  nodes:
+    - id: "extract"
+      timeout_seconds: 30
+      executor:
+        type: "lua"
+        code: "print('extract')"
+`,
+			want: spec.WorkflowSpecDef{
+				TimeoutSec: 300,
+				Nodes:      []spec.NodeSpec{{ID: "extract", TimeoutSec: 30, Executor: lua("print('extract')")}},
+			},
+		},
+		{
+			name: "condition and trigger rule",
+			yaml: `
+spec:
+  nodes:
+    - id: "transform"
+      executor:
+        type: "lua"
+        code: "return {rows = 1}"
+    - id: "load"
+      dependencies: ["transform"]
+      condition: "transform.rows > 0"
+      executor:
+        type: "lua"
+        code: "print('load')"
+    - id: "cleanup"
+      dependencies: ["load"]
+      trigger_rule: "all_done"
+      executor:
+        type: "lua"
+        code: "print('cleanup')"
+`,
+			want: spec.WorkflowSpecDef{Nodes: []spec.NodeSpec{
+				{ID: "transform", Executor: lua("return {rows = 1}")},
+				{ID: "load", Dependencies: []string{"transform"}, Condition: "transform.rows > 0", Executor: lua("print('load')")},
+				{ID: "cleanup", Dependencies: []string{"load"}, TriggerRule: "all_done", Executor: lua("print('cleanup')")},
+			}},
+		},
+		{
+			name: "map",
+			yaml: `
+spec:
+  nodes:
+    - id: "discover"
+      executor:
+        type: "lua"
+        code: "return {files = {}}"
+    - id: "process"
+      dependencies: ["discover"]
+      map:
+        over: "discover.files"
+        as: "file"
+        concurrency: 4
+      executor:
+        type: "lua"
+        code: "print(inputs.file)"
+`,
+			want: spec.WorkflowSpecDef{Nodes: []spec.NodeSpec{
+				{ID: "discover", Executor: lua("return {files = {}}")},
+				{
+					ID:           "process",
+					Dependencies: []string{"discover"},
+					Map:          &spec.MapSpec{Over: "discover.files", As: "file", Concurrency: 4},
+					Executor:     lua("print(inputs.file)"),
+				},
+			}},
+		},
+		{
+			name: "pools",
+			yaml: `
+spec:
+  max_concurrency: 4
+  pools:
+    db: 2
+  nodes:
+    - id: "extract"
+      pool: "db"
+      pool_slots: 2
+      priority: 10
+      executor:
+        type: "lua"
+        code: "print('extract')"
+`,
+			want: spec.WorkflowSpecDef{
+				MaxConcurrency: 4,
+				Pools:          map[string]int{"db": 2},
+				Nodes:          []spec.NodeSpec{{ID: "extract", Pool: "db", PoolSlots: 2, Priority: 10, Executor: lua("print('extract')")}},
+			},
+		},
+		{
+			name: "cache",
+			yaml: `
+spec:
+  nodes:
+    - id: "transform"
+      cache:
+        ttl_seconds: 3600
+      executor:
+        type: "lua"
+        code: "print('transform')"
+`,
+			want: spec.WorkflowSpecDef{Nodes: []spec.NodeSpec{
+				{ID: "transform", Cache: &spec.CacheSpec{TTLSec: 3600}, Executor: lua("print('transform')")},
+			}},
+		},
+		{
+			name: "estimated duration",
+			yaml: `
+spec:
+  nodes:
+    - id: "extract"
+      estimated_duration_seconds: 12.5
+      executor:
+        type: "lua"
+        code: "print('extract')"
+`,
+			want: spec.WorkflowSpecDef{Nodes: []spec.NodeSpec{
+				{ID: "extract", EstimatedDurationSec: 12.5, Executor: lua("print('extract')")},
+			}},
+		},
+		{
+			name: "lua modules",
+			yaml: `
+spec:
+  nodes:
+    - id: "transform"
+      executor:
+        type: "lua"
+        code: "return json.decode(inputs.raw)"
+        config:
+          modules: ["json", "log"]
+`,
+			want: spec.WorkflowSpecDef{Nodes: []spec.NodeSpec{{ID: "transform", Executor: spec.ExecutorSpec{
+				Type:   "lua",
+				Code:   "return json.decode(inputs.raw)",
+				Config: map[string]interface{}{"modules": []interface{}{"json", "log"}},
+			}}}},
+		},
+		{
+			name: "shell config",
+			yaml: `
+spec:
+  nodes:
+    - id: "backup"
+      executor:
+        type: "shell"
+        code: "pg_dump users > /backups/users.sql"
+        config:
+          interpreter: "bash -c"
+          working_dir: "/backups"
+          env:
+            PGHOST: "db.internal"
+`,
+			want: spec.WorkflowSpecDef{Nodes: []spec.NodeSpec{{ID: "backup", Executor: spec.ExecutorSpec{
+				Type: "shell",
+				Code: "pg_dump users > /backups/users.sql",
+				Config: map[string]interface{}{
+					"interpreter": "bash -c",
+					"working_dir": "/backups",
+					"env":         map[string]interface{}{"PGHOST": "db.internal"},
+				},
+			}}}},
+		},
+		{
+			name: "http config",
+			yaml: `
+spec:
+  nodes:
+    - id: "notify"
+      executor:
+        type: "http"
+        config:
+          method: "POST"
+          url: "https://hooks.internal/notify"
+          headers:
+            X-Request-Source: "workflow"
+          body:
+            event: "signup"
+            count: "{{ extract.count }}"
+          expected_status: [200, 201]
+          timeout_seconds: 10
+`,
+			want: spec.WorkflowSpecDef{Nodes: []spec.NodeSpec{{ID: "notify", Executor: spec.ExecutorSpec{
+				Type: "http",
+				Config: map[string]interface{}{
+					"method":          "POST",
+					"url":             "https://hooks.internal/notify",
+					"headers":         map[string]interface{}{"X-Request-Source": "workflow"},
+					"body":            map[string]interface{}{"event": "signup", "count": "{{ extract.count }}"},
+					"expected_status": []interface{}{200, 201},
+					"timeout_seconds": 10,
+				},
+			}}}},
+		},
+		{
+			name: "grpc config",
+			yaml: `
+spec:
+  nodes:
+    - id: "lookup"
+      executor:
+        type: "grpc"
+        config:
+          address: "users.internal:9090"
+          method: "users.v1.UserService/GetUser"
+          request:
+            id: "{{ workflow.user_id }}"
+          tls: true
+          timeout_seconds: 5
+`,
+			want: spec.WorkflowSpecDef{Nodes: []spec.NodeSpec{{ID: "lookup", Executor: spec.ExecutorSpec{
+				Type: "grpc",
+				Config: map[string]interface{}{
+					"address":         "users.internal:9090",
+					"method":          "users.v1.UserService/GetUser",
+					"request":         map[string]interface{}{"id": "{{ workflow.user_id }}"},
+					"tls":             true,
+					"timeout_seconds": 5,
+				},
+			}}}},
+		},
+		{
+			name: "wasm config",
+			yaml: `
+spec:
+  nodes:
+    - id: "enrich"
+      executor:
+        type: "wasm"
+        config:
+          artifact: "/modules/enrich.wasm"
+          env:
+            LOG_LEVEL: "debug"
+          max_memory_mb: 32
+          max_fuel: 10000000
+          timeout_seconds: 5
+`,
+			want: spec.WorkflowSpecDef{Nodes: []spec.NodeSpec{{ID: "enrich", Executor: spec.ExecutorSpec{
+				Type: "wasm",
+				Config: map[string]interface{}{
+					"artifact":        "/modules/enrich.wasm",
+					"env":             map[string]interface{}{"LOG_LEVEL": "debug"},
+					"max_memory_mb":   32,
+					"max_fuel":        10000000,
+					"timeout_seconds": 5,
+				},
+			}}}},
+		},
+		{
+			name: "subworkflow config",
+			yaml: `
+spec:
+  nodes:
+    - id: "enrich"
+      executor:
+        type: "subworkflow"
+        config:
+          workflow_id: "enrich-user"
+          version: "1.x"
+          inputs:
+            user_id: "{{ workflow.user_id }}"
+          outputs:
+            score: "rate.value"
+`,
+			want: spec.WorkflowSpecDef{Nodes: []spec.NodeSpec{{ID: "enrich", Executor: spec.ExecutorSpec{
+				Type: "subworkflow",
+				Config: map[string]interface{}{
+					"workflow_id": "enrich-user",
+					"version":     "1.x",
+					"inputs":      map[string]interface{}{"user_id": "{{ workflow.user_id }}"},
+					"outputs":     map[string]interface{}{"score": "rate.value"},
+				},
+			}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseYAMLFromBytes([]byte(roundTripHeader + tt.yaml))
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+			if !reflect.DeepEqual(parsed.Spec, tt.want) {
+				t.Errorf("Expected spec %+v, got %+v", tt.want, parsed.Spec)
+			}
+
+			// Writing the spec back must not lose anything
+			data, err := yaml.Marshal(parsed)
+			if err != nil {
+				t.Fatalf("Failed to marshal the spec: %v", err)
+			}
+			reparsed, err := ParseYAMLFromBytes(data)
+			if err != nil {
+				t.Fatalf("Failed to parse the marshalled spec: %v\n%s", err, data)
+			}
+			if !reflect.DeepEqual(reparsed, parsed) {
+				t.Errorf("Expected the marshalled spec to parse as %+v, got %+v", parsed, reparsed)
+			}
+		})
+	}
+}
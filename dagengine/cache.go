@@ -0,0 +1,178 @@
+package dagengine
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+)
+
+// CacheConfig enables result caching for a node whose task is a pure
+// function of its code, configuration and inputs. When a previous result
+// for the same executor type, code, configuration and resolved inputs is
+// found in DAGEngine.Cache and has not expired, the task is not run again.
+//
+// Resolved inputs include the workflow-level inputs, so inputs that change
+// on every run (such as a trigger timestamp) defeat the cache. The elements
+// of a map node are cached individually.
+type CacheConfig struct {
+    TTL time.Duration // How long a result stays valid; 0 means it never expires
+}
+
+// Describer is implemented by executors that can describe what they run.
+// Only nodes whose task implements it can be cached.
+type Describer interface {
+    Describe() ExecutorDescription
+}
+
+// ExecutorDescription identifies the work an executor performs.
+type ExecutorDescription struct {
+    Type   string                 // Executor type, e.g. "lua"
+    Code   string                 // Script or command run by the executor
+    Config map[string]interface{} // Executor configuration
+}
+
+// CacheEntry is a node result stored in a ResultCache.
+type CacheEntry struct {
+    Result    map[string]interface{}
+    CreatedAt time.Time
+}
+
+// ResultCache stores node results by cache key. Implementations must be safe
+// for concurrent use.
+type ResultCache interface {
+    // Get returns the entry stored under key, or found == false.
+    Get(key string) (entry *CacheEntry, found bool, err error)
+    // Put stores an entry under key, replacing any previous one.
+    Put(key string, entry *CacheEntry) error
+}
+
+// validateCache checks that a node's cache settings can be honoured.
+func validateCache(n *Node) error {
+    if n.Cache == nil {
+        return nil
+    }
+    if n.Cache.TTL < 0 {
+        return fmt.Errorf("node '%s' has a negative cache TTL: %v", n.ID, n.Cache.TTL)
+    }
+    if _, ok := n.Task.(Describer); !ok {
+        return fmt.Errorf("node '%s' is cached but its executor (%T) cannot describe what it runs", n.ID, n.Task)
+    }
+    return nil
+}
+
+// CacheKey returns the content address of running an executor with inputs:
+// the hex SHA-256 of the executor's description and the inputs, encoded as
+// JSON with sorted map keys.
+func CacheKey(description ExecutorDescription, inputs map[string]interface{}) (string, error) {
+    data, err := json.Marshal(struct {
+        Type   string
+        Code   string
+        Config map[string]interface{}
+        Inputs map[string]interface{}
+    }{description.Type, description.Code, description.Config, inputs})
+    if err != nil {
+        return "", fmt.Errorf("cannot compute cache key: %w", err)
+    }
+    sum := sha256.Sum256(data)
+    return hex.EncodeToString(sum[:]), nil
+}
+
+// cachedResult looks up the result of a cached node. It returns the cache
+// key to store the result under on a miss, or "" if the node cannot be
+// cached for these inputs. Cache failures never fail the node: they only
+// make it run.
+func (r *Run) cachedResult(n *Node, inputs map[string]interface{}) (map[string]interface{}, string, bool) {
+    if n.Cache == nil || r.cache == nil {
+        return nil, "", false
+    }
+    describer, ok := n.Task.(Describer)
+    if !ok {
+        return nil, "", false
+    }
+    key, err := CacheKey(describer.Describe(), inputs)
+    if err != nil {
+        return nil, "", false
+    }
+
+    entry, found, err := r.cache.Get(key)
+    if err != nil || !found {
+        return nil, key, false
+    }
+    if n.Cache.TTL > 0 && time.Since(entry.CreatedAt) > n.Cache.TTL {
+        return nil, key, false
+    }
+    return entry.Result, key, true
+}
+
+// FileResultCache is a ResultCache that keeps one JSON file per key in a
+// directory. Results are stored as JSON: numbers come back as float64.
+type FileResultCache struct {
+    Dir string
+}
+
+// NewFileResultCache creates a FileResultCache, creating dir if needed.
+func NewFileResultCache(dir string) (*FileResultCache, error) {
+    if err := os.MkdirAll(dir, 0o755); err != nil {
+        return nil, fmt.Errorf("failed to create cache directory: %w", err)
+    }
+    return &FileResultCache{Dir: dir}, nil
+}
+
+// path returns the file holding a key's entry. Keys are spread over
+// subdirectories named after their first two characters.
+func (c *FileResultCache) path(key string) (string, error) {
+    if len(key) < 3 {
+        return "", fmt.Errorf("invalid cache key '%s'", key)
+    }
+    if _, err := hex.DecodeString(key); err != nil {
+        return "", fmt.Errorf("invalid cache key '%s'", key)
+    }
+    return filepath.Join(c.Dir, key[:2], key+".json"), nil
+}
+
+// Get reads the entry stored under key.
+func (c *FileResultCache) Get(key string) (*CacheEntry, bool, error) {
+    path, err := c.path(key)
+    if err != nil {
+        return nil, false, err
+    }
+
+    data, err := os.ReadFile(path)
+    if errors.Is(err, os.ErrNotExist) {
+        return nil, false, nil
+    }
+    if err != nil {
+        return nil, false, fmt.Errorf("failed to read cache entry %s: %w", key, err)
+    }
+
+    var entry CacheEntry
+    if err := json.Unmarshal(data, &entry); err != nil {
+        return nil, false, fmt.Errorf("failed to decode cache entry %s: %w", key, err)
+    }
+    return &entry, true, nil
+}
+
+// Put writes the entry stored under key.
+func (c *FileResultCache) Put(key string, entry *CacheEntry) error {
+    path, err := c.path(key)
+    if err != nil {
+        return err
+    }
+
+    data, err := json.Marshal(entry)
+    if err != nil {
+        return fmt.Errorf("failed to encode cache entry %s: %w", key, err)
+    }
+    if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+        return fmt.Errorf("failed to write cache entry %s: %w", key, err)
+    }
+    if err := writeFileAtomic(path, data); err != nil {
+        return fmt.Errorf("failed to write cache entry %s: %w", key, err)
+    }
+    return nil
+}
@@ -0,0 +1,143 @@
+package dagengine
+
+import (
+    "context"
+    "sync"
+    "testing"
+    "time"
+)
+
+// describedExecutor is a cacheable executor that counts its calls.
+type describedExecutor struct {
+    mu    sync.Mutex
+    code  string
+    calls int
+}
+
+func (d *describedExecutor) Describe() ExecutorDescription {
+    return ExecutorDescription{Type: "test", Code: d.code}
+}
+
+func (d *describedExecutor) Execute(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    d.calls++
+    return map[string]interface{}{"code": d.code}, nil
+}
+
+func TestCacheKey(t *testing.T) {
+    base := ExecutorDescription{Type: "lua", Code: "return 1", Config: map[string]interface{}{"a": 1}}
+    inputs := map[string]interface{}{"x": 1, "y": []interface{}{"a", "b"}}
+
+    key, err := CacheKey(base, inputs)
+    if err != nil {
+        t.Fatalf("CacheKey failed: %v", err)
+    }
+    if again, _ := CacheKey(base, map[string]interface{}{"y": []interface{}{"a", "b"}, "x": 1}); again != key {
+        t.Error("Expected the key not to depend on map order")
+    }
+
+    tests := []struct {
+        name        string
+        description ExecutorDescription
+        inputs      map[string]interface{}
+    }{
+        {"type", ExecutorDescription{Type: "shell", Code: base.Code, Config: base.Config}, inputs},
+        {"code", ExecutorDescription{Type: base.Type, Code: "return 2", Config: base.Config}, inputs},
+        {"config", ExecutorDescription{Type: base.Type, Code: base.Code, Config: map[string]interface{}{"a": 2}}, inputs},
+        {"inputs", base, map[string]interface{}{"x": 2, "y": []interface{}{"a", "b"}}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            other, err := CacheKey(tt.description, tt.inputs)
+            if err != nil {
+                t.Fatalf("CacheKey failed: %v", err)
+            }
+            if other == key {
+                t.Errorf("Expected a different key when the %s changes", tt.name)
+            }
+        })
+    }
+}
+
+func TestRunReusesCachedResults(t *testing.T) {
+    cache, err := NewFileResultCache(t.TempDir())
+    if err != nil {
+        t.Fatalf("NewFileResultCache failed: %v", err)
+    }
+
+    task := &describedExecutor{code: "transform"}
+    engine := NewDAGEngine()
+    engine.Cache = cache
+    engine.AddNode(NewNode("A", nil, task))
+    engine.Nodes["A"].Cache = &CacheConfig{TTL: time.Hour}
+
+    recorder := &eventRecorder{}
+    engine.AddObserver(recorder)
+
+    for i := 0; i < 2; i++ {
+        result, err := engine.Run(context.Background(), map[string]interface{}{"day": "monday"})
+        if err != nil {
+            t.Fatalf("Run %d failed: %v", i, err)
+        }
+        if result.NodeStatuses["A"] != StatusCompleted || result.Outputs["A"]["code"] != "transform" {
+            t.Errorf("Run %d: expected A to complete with its result, got %s %v", i, result.NodeStatuses["A"], result.Outputs["A"])
+        }
+    }
+    if task.calls != 1 {
+        t.Errorf("Expected the task to run once, ran %d times", task.calls)
+    }
+    if !recorder.contains("complete A CACHED") {
+        t.Errorf("Expected a CACHED completion event, got %v", recorder.events)
+    }
+
+    // Different inputs are a different content address
+    if _, err := engine.Run(context.Background(), map[string]interface{}{"day": "tuesday"}); err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+    if task.calls != 2 {
+        t.Errorf("Expected the task to run for new inputs, ran %d times", task.calls)
+    }
+}
+
+func TestRunIgnoresExpiredCacheEntries(t *testing.T) {
+    cache, err := NewFileResultCache(t.TempDir())
+    if err != nil {
+        t.Fatalf("NewFileResultCache failed: %v", err)
+    }
+
+    task := &describedExecutor{code: "transform"}
+    key, err := CacheKey(task.Describe(), baseInputs(nil, nil))
+    if err != nil {
+        t.Fatalf("CacheKey failed: %v", err)
+    }
+    cache.Put(key, &CacheEntry{Result: map[string]interface{}{"code": "stale"}, CreatedAt: time.Now().Add(-2 * time.Minute)})
+
+    engine := NewDAGEngine()
+    engine.Cache = cache
+    engine.AddNode(NewNode("A", nil, task))
+    engine.Nodes["A"].Cache = &CacheConfig{TTL: time.Minute}
+
+    result, err := engine.Run(context.Background(), nil)
+    if err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+    if task.calls != 1 || result.Outputs["A"]["code"] != "transform" {
+        t.Errorf("Expected the expired entry to be ignored, got %d calls and %v", task.calls, result.Outputs["A"])
+    }
+
+    entry, found, err := cache.Get(key)
+    if err != nil || !found || entry.Result["code"] != "transform" {
+        t.Errorf("Expected the entry to be refreshed, got %+v (found=%v, err=%v)", entry, found, err)
+    }
+}
+
+func TestPreprocessRejectsUncacheableNode(t *testing.T) {
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("A", nil, &recordingExecutor{}))
+    engine.Nodes["A"].Cache = &CacheConfig{}
+
+    if err := engine.PreprocessDAG(); err == nil {
+        t.Error("Expected an error for a cached node whose executor cannot describe itself")
+    }
+}
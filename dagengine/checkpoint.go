@@ -0,0 +1,242 @@
+package dagengine
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+)
+
+// ErrCheckpointNotFound is returned by a StateStore when no checkpoint exists
+// for an execution ID.
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+// Checkpoint is the persisted state of an execution. It is saved after every
+// node of the plan reaches a terminal state, so that the execution can be
+// resumed with only the remaining nodes after a crash.
+//
+// Inputs and results are stored as JSON: numbers come back as float64 and
+// values that cannot be encoded make the save fail.
+type Checkpoint struct {
+    ExecutionID string
+    Status      string                 // RunRunning while the execution is in progress, then one of the Run* constants
+    Inputs      map[string]interface{} // Workflow-level inputs
+    Nodes       map[string]NodeCheckpoint
+    UpdatedAt   time.Time
+}
+
+// NodeCheckpoint is the persisted state of a node that reached a terminal state.
+type NodeCheckpoint struct {
+    Status   string
+    Result   map[string]interface{} `json:",omitempty"`
+    Error    string                 `json:",omitempty"`
+    Attempts int                    `json:",omitempty"`
+}
+
+// StateStore persists execution checkpoints. Implementations must be safe for
+// concurrent use; saves of one execution are never issued concurrently.
+type StateStore interface {
+    // Save stores a checkpoint, replacing the previous one of the same execution.
+    Save(checkpoint *Checkpoint) error
+    // Load returns the checkpoint of an execution, or an error wrapping
+    // ErrCheckpointNotFound.
+    Load(executionID string) (*Checkpoint, error)
+    // Delete removes the checkpoint of an execution.
+    Delete(executionID string) error
+}
+
+// FileStateStore is a StateStore that keeps one JSON file per execution in a
+// directory. Files are replaced atomically, so a crash during a save leaves
+// the previous checkpoint intact.
+type FileStateStore struct {
+    Dir string
+}
+
+// NewFileStateStore creates a FileStateStore, creating dir if needed.
+func NewFileStateStore(dir string) (*FileStateStore, error) {
+    if err := os.MkdirAll(dir, 0o755); err != nil {
+        return nil, fmt.Errorf("failed to create state directory: %w", err)
+    }
+    return &FileStateStore{Dir: dir}, nil
+}
+
+// path returns the file holding an execution's checkpoint.
+func (s *FileStateStore) path(executionID string) (string, error) {
+    if executionID == "" || strings.ContainsAny(executionID, `/\`) || executionID == "." || executionID == ".." {
+        return "", fmt.Errorf("invalid execution ID '%s'", executionID)
+    }
+    return filepath.Join(s.Dir, executionID+".json"), nil
+}
+
+// Save writes the checkpoint to a temporary file and renames it into place.
+func (s *FileStateStore) Save(checkpoint *Checkpoint) error {
+    path, err := s.path(checkpoint.ExecutionID)
+    if err != nil {
+        return err
+    }
+
+    data, err := json.Marshal(checkpoint)
+    if err != nil {
+        return fmt.Errorf("failed to encode checkpoint of '%s': %w", checkpoint.ExecutionID, err)
+    }
+
+    if err := writeFileAtomic(path, data); err != nil {
+        return fmt.Errorf("failed to save checkpoint of '%s': %w", checkpoint.ExecutionID, err)
+    }
+    return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so that readers never see a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+    tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name()) // No-op once renamed
+
+    if _, err := tmp.Write(data); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Sync(); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+    return os.Rename(tmp.Name(), path)
+}
+
+// Load reads the checkpoint of an execution.
+func (s *FileStateStore) Load(executionID string) (*Checkpoint, error) {
+    path, err := s.path(executionID)
+    if err != nil {
+        return nil, err
+    }
+
+    data, err := os.ReadFile(path)
+    if errors.Is(err, os.ErrNotExist) {
+        return nil, fmt.Errorf("execution '%s': %w", executionID, ErrCheckpointNotFound)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to load checkpoint of '%s': %w", executionID, err)
+    }
+
+    var checkpoint Checkpoint
+    if err := json.Unmarshal(data, &checkpoint); err != nil {
+        return nil, fmt.Errorf("failed to decode checkpoint of '%s': %w", executionID, err)
+    }
+    return &checkpoint, nil
+}
+
+// Delete removes the checkpoint of an execution. Deleting a missing
+// checkpoint is not an error.
+func (s *FileStateStore) Delete(executionID string) error {
+    path, err := s.path(executionID)
+    if err != nil {
+        return err
+    }
+    if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+        return fmt.Errorf("failed to delete checkpoint of '%s': %w", executionID, err)
+    }
+    return nil
+}
+
+// saveCheckpoint persists the terminal states of the plan's nodes. Saves are
+// serialized so that an older snapshot never overwrites a newer one. The
+// first failure is kept and reported when the run finishes.
+func (r *Run) saveCheckpoint(status string) {
+    if r.store == nil {
+        return
+    }
+
+    r.checkpointMu.Lock()
+    defer r.checkpointMu.Unlock()
+
+    checkpoint := &Checkpoint{
+        ExecutionID: r.ID,
+        Status:      status,
+        Inputs:      r.inputs,
+        Nodes:       make(map[string]NodeCheckpoint, len(r.plan.nodes)),
+        UpdatedAt:   time.Now(),
+    }
+    for id := range r.plan.nodes {
+        state := r.state(id)
+        state.mu.RLock()
+        switch state.status {
+        case StatusPending, StatusRunning:
+        default:
+            node := NodeCheckpoint{Status: state.status, Attempts: state.attempts}
+            if state.status == StatusCompleted {
+                node.Result = state.result
+            }
+            if state.err != nil {
+                node.Error = state.err.Error()
+            }
+            checkpoint.Nodes[id] = node
+        }
+        state.mu.RUnlock()
+    }
+
+    if err := r.store.Save(checkpoint); err != nil && r.checkpointErr == nil {
+        r.checkpointErr = err
+    }
+}
+
+// restore marks the nodes completed in a checkpoint as completed in the run,
+// with their saved results. Other nodes run again.
+func (r *Run) restore(checkpoint *Checkpoint) error {
+    return r.restoreNodes(checkpoint, func(id string, node NodeCheckpoint) bool {
+        return node.Status == StatusCompleted
+    })
+}
+
+// restoreExcept restores every node of a finished execution, whatever its
+// status, except the nodes in rerun, which run again.
+func (r *Run) restoreExcept(checkpoint *Checkpoint, rerun []string) error {
+    if checkpoint.Status == RunRunning {
+        return fmt.Errorf("execution '%s' has not finished; resume it instead", checkpoint.ExecutionID)
+    }
+
+    again := make(map[string]bool, len(rerun))
+    for _, id := range rerun {
+        again[id] = true
+    }
+    for id := range r.plan.nodes {
+        if _, saved := checkpoint.Nodes[id]; !saved && !again[id] {
+            return fmt.Errorf("checkpoint of '%s' does not match the plan: no state for node '%s'", checkpoint.ExecutionID, id)
+        }
+    }
+
+    return r.restoreNodes(checkpoint, func(id string, node NodeCheckpoint) bool {
+        return !again[id]
+    })
+}
+
+// restoreNodes sets the saved state of the checkpointed nodes selected by keep.
+func (r *Run) restoreNodes(checkpoint *Checkpoint, keep func(id string, node NodeCheckpoint) bool) error {
+    for id, node := range checkpoint.Nodes {
+        state, exists := r.states[id]
+        if !exists {
+            return fmt.Errorf("checkpoint of '%s' does not match the plan: unknown node '%s'", checkpoint.ExecutionID, id)
+        }
+        if !keep(id, node) {
+            continue
+        }
+        state.status = node.Status
+        state.attempts = node.Attempts
+        if node.Status == StatusCompleted {
+            state.result = node.Result
+        }
+        if node.Error != "" {
+            state.err = errors.New(node.Error)
+        }
+        r.restored = append(r.restored, id)
+    }
+    return nil
+}
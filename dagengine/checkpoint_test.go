@@ -0,0 +1,211 @@
+package dagengine
+
+import (
+    "context"
+    "errors"
+    "testing"
+)
+
+func TestFileStateStore(t *testing.T) {
+    store, err := NewFileStateStore(t.TempDir())
+    if err != nil {
+        t.Fatalf("NewFileStateStore failed: %v", err)
+    }
+
+    checkpoint := &Checkpoint{
+        ExecutionID: "exec-1",
+        Status:      RunRunning,
+        Inputs:      map[string]interface{}{"name": "test"},
+        Nodes: map[string]NodeCheckpoint{
+            "A": {Status: StatusCompleted, Result: map[string]interface{}{"count": 3}, Attempts: 2},
+        },
+    }
+    if err := store.Save(checkpoint); err != nil {
+        t.Fatalf("Save failed: %v", err)
+    }
+
+    loaded, err := store.Load("exec-1")
+    if err != nil {
+        t.Fatalf("Load failed: %v", err)
+    }
+    if loaded.Status != RunRunning || loaded.Inputs["name"] != "test" {
+        t.Errorf("Expected status and inputs to round-trip, got %+v", loaded)
+    }
+    if a := loaded.Nodes["A"]; a.Status != StatusCompleted || a.Attempts != 2 || a.Result["count"] != float64(3) {
+        t.Errorf("Expected node A to round-trip, got %+v", a)
+    }
+
+    if err := store.Delete("exec-1"); err != nil {
+        t.Fatalf("Delete failed: %v", err)
+    }
+    if _, err := store.Load("exec-1"); !errors.Is(err, ErrCheckpointNotFound) {
+        t.Errorf("Expected ErrCheckpointNotFound after Delete, got %v", err)
+    }
+    if err := store.Save(&Checkpoint{ExecutionID: "../escape"}); err == nil {
+        t.Error("Expected an error for an execution ID containing a path separator")
+    }
+}
+
+// newCheckpointedChain builds the A -> B -> C graph used by the resume tests.
+func newCheckpointedChain(store StateStore, a, b Executor, c *recordingExecutor) *DAGEngine {
+    engine := NewDAGEngine()
+    engine.StateStore = store
+    engine.AddNode(NewNode("A", nil, a))
+    engine.AddNode(NewNode("B", []string{"A"}, b))
+    engine.AddNode(NewNode("C", []string{"B"}, c))
+    return engine
+}
+
+func TestResumeRunsOnlyUnfinishedNodes(t *testing.T) {
+    store, err := NewFileStateStore(t.TempDir())
+    if err != nil {
+        t.Fatalf("NewFileStateStore failed: %v", err)
+    }
+
+    // First attempt: A completes, B fails and C never runs
+    first := newCheckpointedChain(store,
+        &flakyExecutor{},
+        &flakyExecutor{failures: 1, err: errors.New("crash")},
+        &recordingExecutor{})
+    plan, err := first.Compile()
+    if err != nil {
+        t.Fatalf("Compile failed: %v", err)
+    }
+    if _, err := first.StartExecution(context.Background(), "exec-1", plan, map[string]interface{}{"day": "monday"}).Wait(); err == nil {
+        t.Fatal("Expected the first run to fail")
+    }
+
+    checkpoint, err := store.Load("exec-1")
+    if err != nil {
+        t.Fatalf("Load failed: %v", err)
+    }
+    if checkpoint.Status != RunFailed || checkpoint.Nodes["A"].Status != StatusCompleted || checkpoint.Nodes["B"].Status != StatusFailed {
+        t.Fatalf("Expected the checkpoint to record the failed run, got %+v", checkpoint)
+    }
+
+    // Resume on a new engine, as after a restart
+    a := &flakyExecutor{}
+    c := &recordingExecutor{result: map[string]interface{}{}}
+    resumed := newCheckpointedChain(store, a, &flakyExecutor{}, c)
+    result, err := resumed.Resume(context.Background(), "exec-1")
+    if err != nil {
+        t.Fatalf("Resume failed: %v", err)
+    }
+
+    if a.calls != 0 {
+        t.Errorf("Expected A not to run again, ran %d times", a.calls)
+    }
+    for _, id := range []string{"A", "B", "C"} {
+        if result.NodeStatuses[id] != StatusCompleted {
+            t.Errorf("Expected %s to be COMPLETED, got %s", id, result.NodeStatuses[id])
+        }
+    }
+    if got := result.Outputs["A"]["calls"]; got != float64(1) {
+        t.Errorf("Expected A's restored result, got %v", got)
+    }
+    inputs := c.seen()
+    if day := inputs[WorkflowInputsKey].(map[string]interface{})["day"]; day != "monday" {
+        t.Errorf("Expected the saved workflow inputs, got %v", day)
+    }
+
+    checkpoint, err = store.Load("exec-1")
+    if err != nil {
+        t.Fatalf("Load failed: %v", err)
+    }
+    if checkpoint.Status != RunCompleted {
+        t.Errorf("Expected the checkpoint status to be COMPLETED, got %s", checkpoint.Status)
+    }
+}
+
+func TestResumeErrors(t *testing.T) {
+    store, err := NewFileStateStore(t.TempDir())
+    if err != nil {
+        t.Fatalf("NewFileStateStore failed: %v", err)
+    }
+
+    engine := newCheckpointedChain(nil, &flakyExecutor{}, &flakyExecutor{}, &recordingExecutor{})
+    if _, err := engine.Resume(context.Background(), "exec-1"); err == nil {
+        t.Error("Expected an error when the engine has no state store")
+    }
+
+    engine.StateStore = store
+    if _, err := engine.Resume(context.Background(), "missing"); !errors.Is(err, ErrCheckpointNotFound) {
+        t.Errorf("Expected ErrCheckpointNotFound, got %v", err)
+    }
+
+    store.Save(&Checkpoint{
+        ExecutionID: "other",
+        Nodes:       map[string]NodeCheckpoint{"X": {Status: StatusCompleted}},
+    })
+    if _, err := engine.Resume(context.Background(), "other"); err == nil {
+        t.Error("Expected an error for a checkpoint of a different graph")
+    }
+}
+
+func TestRerunFromNode(t *testing.T) {
+    store, err := NewFileStateStore(t.TempDir())
+    if err != nil {
+        t.Fatalf("NewFileStateStore failed: %v", err)
+    }
+
+    // extract -> transform -> load -> report, and extract -> audit
+    extract, transform, audit := &flakyExecutor{}, &flakyExecutor{}, &flakyExecutor{}
+    load := &flakyExecutor{failures: 1, err: errors.New("destination unavailable")}
+    report := &recordingExecutor{result: map[string]interface{}{}}
+
+    engine := NewDAGEngine()
+    engine.Policy = ContinueIndependent
+    engine.StateStore = store
+    engine.AddNode(NewNode("extract", nil, extract))
+    engine.AddNode(NewNode("transform", []string{"extract"}, transform))
+    engine.AddNode(NewNode("load", []string{"transform"}, load))
+    engine.AddNode(NewNode("report", []string{"load"}, report))
+    engine.AddNode(NewNode("audit", []string{"extract"}, audit))
+    plan, err := engine.Compile()
+    if err != nil {
+        t.Fatalf("Compile failed: %v", err)
+    }
+
+    if ids, _ := plan.Downstream("load"); len(ids) != 2 || ids[0] != "load" || ids[1] != "report" {
+        t.Errorf("Expected the downstream closure of load to be [load report], got %v", ids)
+    }
+
+    if _, err := engine.StartExecution(context.Background(), "exec-1", plan, nil).Wait(); err == nil {
+        t.Fatal("Expected the first run to fail")
+    }
+
+    run, err := engine.RerunExecution(context.Background(), "exec-1", plan, "load")
+    if err != nil {
+        t.Fatalf("RerunExecution failed: %v", err)
+    }
+    result, err := run.Wait()
+    if err != nil {
+        t.Fatalf("Rerun failed: %v", err)
+    }
+
+    for _, id := range []string{"extract", "transform", "load", "report", "audit"} {
+        if result.NodeStatuses[id] != StatusCompleted {
+            t.Errorf("Expected %s to be COMPLETED, got %s", id, result.NodeStatuses[id])
+        }
+    }
+    if extract.calls != 1 || transform.calls != 1 || audit.calls != 1 {
+        t.Errorf("Expected upstream nodes to run once, got extract=%d transform=%d audit=%d", extract.calls, transform.calls, audit.calls)
+    }
+    if load.calls != 2 {
+        t.Errorf("Expected load to run again, got %d calls", load.calls)
+    }
+    if got := report.seen()["load"].(map[string]interface{})["calls"]; got != 2 {
+        t.Errorf("Expected report to receive the new result of load, got %v", got)
+    }
+    if got := result.Outputs["transform"]["calls"]; got != float64(1) {
+        t.Errorf("Expected transform's saved result, got %v", got)
+    }
+
+    if _, err := engine.RerunExecution(context.Background(), "exec-1", plan, "missing"); err == nil {
+        t.Error("Expected an error for an unknown node")
+    }
+    store.Save(&Checkpoint{ExecutionID: "exec-2", Status: RunRunning})
+    if _, err := engine.RerunExecution(context.Background(), "exec-2", plan, "load"); err == nil {
+        t.Error("Expected an error for an execution that has not finished")
+    }
+}
@@ -0,0 +1,501 @@
+package dagengine
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "unicode"
+)
+
+// Condition is a compiled boolean expression that decides whether a node runs.
+//
+// Expressions are evaluated against the node's inputs: every parent's result
+// under the parent's ID and the workflow-level inputs under WorkflowInputsKey.
+// They support dot paths ("transform.rows", "workflow.mode", "extract.files.0"),
+// number, string ('...' or "..."), true/false/null literals, comparisons
+// (==, !=, <, <=, >, >=), logical operators (&&, ||, !, and, or, not) and
+// parentheses. A path that does not resolve evaluates to null.
+type Condition struct {
+    expr string
+    root condExpr
+}
+
+// CompileCondition parses an expression into a Condition.
+func CompileCondition(expr string) (*Condition, error) {
+    tokens, err := tokenizeCondition(expr)
+    if err != nil {
+        return nil, fmt.Errorf("invalid condition %q: %w", expr, err)
+    }
+
+    p := &condParser{tokens: tokens}
+    root, err := p.parseOr()
+    if err == nil && !p.done() {
+        err = fmt.Errorf("unexpected %q", p.peek().text)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("invalid condition %q: %w", expr, err)
+    }
+    return &Condition{expr: expr, root: root}, nil
+}
+
+// String returns the source expression.
+func (c *Condition) String() string {
+    return c.expr
+}
+
+// Evaluate evaluates the condition against vars and reports whether it holds.
+func (c *Condition) Evaluate(vars map[string]interface{}) (bool, error) {
+    value, err := c.root.eval(vars)
+    if err != nil {
+        return false, fmt.Errorf("condition %q: %w", c.expr, err)
+    }
+    return truthy(value), nil
+}
+
+// condExpr is a node of the parsed expression tree.
+type condExpr interface {
+    eval(vars map[string]interface{}) (interface{}, error)
+}
+
+type condLiteral struct {
+    value interface{}
+}
+
+func (l condLiteral) eval(map[string]interface{}) (interface{}, error) {
+    return l.value, nil
+}
+
+type condPath struct {
+    parts []string
+}
+
+func (p condPath) eval(vars map[string]interface{}) (interface{}, error) {
+    var current interface{} = vars
+    for _, part := range p.parts {
+        switch v := current.(type) {
+        case map[string]interface{}:
+            current = v[part]
+        case []interface{}:
+            index, err := strconv.Atoi(part)
+            if err != nil || index < 0 || index >= len(v) {
+                return nil, nil
+            }
+            current = v[index]
+        default:
+            return nil, nil
+        }
+    }
+    return current, nil
+}
+
+type condNot struct {
+    operand condExpr
+}
+
+func (n condNot) eval(vars map[string]interface{}) (interface{}, error) {
+    value, err := n.operand.eval(vars)
+    if err != nil {
+        return nil, err
+    }
+    return !truthy(value), nil
+}
+
+type condNegate struct {
+    operand condExpr
+}
+
+func (n condNegate) eval(vars map[string]interface{}) (interface{}, error) {
+    value, err := n.operand.eval(vars)
+    if err != nil {
+        return nil, err
+    }
+    number, ok := toFloat(value)
+    if !ok {
+        return nil, fmt.Errorf("cannot negate %v", value)
+    }
+    return -number, nil
+}
+
+type condLogical struct {
+    op          string // "&&" or "||"
+    left, right condExpr
+}
+
+func (l condLogical) eval(vars map[string]interface{}) (interface{}, error) {
+    left, err := l.left.eval(vars)
+    if err != nil {
+        return nil, err
+    }
+    // Short-circuit evaluation
+    if l.op == "&&" && !truthy(left) {
+        return false, nil
+    }
+    if l.op == "||" && truthy(left) {
+        return true, nil
+    }
+
+    right, err := l.right.eval(vars)
+    if err != nil {
+        return nil, err
+    }
+    return truthy(right), nil
+}
+
+type condCompare struct {
+    op          string
+    left, right condExpr
+}
+
+func (c condCompare) eval(vars map[string]interface{}) (interface{}, error) {
+    left, err := c.left.eval(vars)
+    if err != nil {
+        return nil, err
+    }
+    right, err := c.right.eval(vars)
+    if err != nil {
+        return nil, err
+    }
+
+    switch c.op {
+    case "==":
+        return equalValues(left, right), nil
+    case "!=":
+        return !equalValues(left, right), nil
+    }
+
+    var cmp int
+    if l, ok := toFloat(left); ok {
+        r, ok := toFloat(right)
+        if !ok {
+            return nil, fmt.Errorf("cannot compare %v %s %v", left, c.op, right)
+        }
+        switch {
+        case l < r:
+            cmp = -1
+        case l > r:
+            cmp = 1
+        }
+    } else if l, ok := left.(string); ok {
+        r, ok := right.(string)
+        if !ok {
+            return nil, fmt.Errorf("cannot compare %v %s %v", left, c.op, right)
+        }
+        cmp = strings.Compare(l, r)
+    } else {
+        return nil, fmt.Errorf("cannot compare %v %s %v", left, c.op, right)
+    }
+
+    switch c.op {
+    case "<":
+        return cmp < 0, nil
+    case "<=":
+        return cmp <= 0, nil
+    case ">":
+        return cmp > 0, nil
+    default: // ">="
+        return cmp >= 0, nil
+    }
+}
+
+// truthy converts a value to a boolean: false, null, 0 and "" are false.
+func truthy(value interface{}) bool {
+    if number, ok := toFloat(value); ok {
+        return number != 0
+    }
+    switch v := value.(type) {
+    case nil:
+        return false
+    case bool:
+        return v
+    case string:
+        return v != ""
+    }
+    return true
+}
+
+// equalValues compares two values, treating all numeric types as equal by value.
+func equalValues(a, b interface{}) bool {
+    if x, ok := toFloat(a); ok {
+        y, ok := toFloat(b)
+        return ok && x == y
+    }
+    switch x := a.(type) {
+    case nil:
+        return b == nil
+    case bool:
+        y, ok := b.(bool)
+        return ok && x == y
+    case string:
+        y, ok := b.(string)
+        return ok && x == y
+    }
+    return false
+}
+
+// toFloat converts any Go numeric type to float64.
+func toFloat(value interface{}) (float64, bool) {
+    switch v := value.(type) {
+    case float64:
+        return v, true
+    case float32:
+        return float64(v), true
+    case int:
+        return float64(v), true
+    case int8:
+        return float64(v), true
+    case int16:
+        return float64(v), true
+    case int32:
+        return float64(v), true
+    case int64:
+        return float64(v), true
+    case uint:
+        return float64(v), true
+    case uint8:
+        return float64(v), true
+    case uint16:
+        return float64(v), true
+    case uint32:
+        return float64(v), true
+    case uint64:
+        return float64(v), true
+    }
+    return 0, false
+}
+
+// Tokenizer
+
+type condTokenKind int
+
+const (
+    tokenPath condTokenKind = iota
+    tokenNumber
+    tokenString
+    tokenOperator
+)
+
+type condToken struct {
+    kind  condTokenKind
+    text  string
+    value interface{} // Parsed value of number and string tokens
+}
+
+func tokenizeCondition(expr string) ([]condToken, error) {
+    var tokens []condToken
+    runes := []rune(expr)
+
+    for i := 0; i < len(runes); {
+        r := runes[i]
+        switch {
+        case unicode.IsSpace(r):
+            i++
+
+        case r == '\'' || r == '"':
+            j := i + 1
+            var sb strings.Builder
+            for ; j < len(runes) && runes[j] != r; j++ {
+                if runes[j] == '\\' && j+1 < len(runes) {
+                    j++
+                }
+                sb.WriteRune(runes[j])
+            }
+            if j >= len(runes) {
+                return nil, fmt.Errorf("unterminated string at position %d", i)
+            }
+            tokens = append(tokens, condToken{kind: tokenString, text: string(runes[i : j+1]), value: sb.String()})
+            i = j + 1
+
+        case unicode.IsDigit(r):
+            j := i
+            for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+                j++
+            }
+            text := string(runes[i:j])
+            number, err := strconv.ParseFloat(text, 64)
+            if err != nil {
+                return nil, fmt.Errorf("invalid number %q", text)
+            }
+            tokens = append(tokens, condToken{kind: tokenNumber, text: text, value: number})
+            i = j
+
+        case unicode.IsLetter(r) || r == '_':
+            // Paths may contain '-' so that node IDs such as "load-data" can be referenced
+            j := i
+            for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || strings.ContainsRune("_-.", runes[j])) {
+                j++
+            }
+            tokens = append(tokens, condToken{kind: tokenPath, text: string(runes[i:j])})
+            i = j
+
+        default:
+            op := ""
+            for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "-"} {
+                if strings.HasPrefix(string(runes[i:]), candidate) {
+                    op = candidate
+                    break
+                }
+            }
+            if op == "" {
+                return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+            }
+            tokens = append(tokens, condToken{kind: tokenOperator, text: op})
+            i += len(op)
+        }
+    }
+
+    if len(tokens) == 0 {
+        return nil, fmt.Errorf("empty expression")
+    }
+    return tokens, nil
+}
+
+// Parser (recursive descent, lowest precedence first)
+
+type condParser struct {
+    tokens []condToken
+    pos    int
+}
+
+func (p *condParser) done() bool {
+    return p.pos >= len(p.tokens)
+}
+
+func (p *condParser) peek() condToken {
+    if p.done() {
+        return condToken{}
+    }
+    return p.tokens[p.pos]
+}
+
+// accept consumes the next token if it is one of the given operators or keywords.
+func (p *condParser) accept(ops ...string) (string, bool) {
+    if p.done() {
+        return "", false
+    }
+    tok := p.tokens[p.pos]
+    if tok.kind != tokenOperator && tok.kind != tokenPath {
+        return "", false
+    }
+    for _, op := range ops {
+        if tok.text == op {
+            p.pos++
+            return op, true
+        }
+    }
+    return "", false
+}
+
+func (p *condParser) parseOr() (condExpr, error) {
+    left, err := p.parseAnd()
+    if err != nil {
+        return nil, err
+    }
+    for {
+        if _, ok := p.accept("||", "or"); !ok {
+            return left, nil
+        }
+        right, err := p.parseAnd()
+        if err != nil {
+            return nil, err
+        }
+        left = condLogical{op: "||", left: left, right: right}
+    }
+}
+
+func (p *condParser) parseAnd() (condExpr, error) {
+    left, err := p.parseNot()
+    if err != nil {
+        return nil, err
+    }
+    for {
+        if _, ok := p.accept("&&", "and"); !ok {
+            return left, nil
+        }
+        right, err := p.parseNot()
+        if err != nil {
+            return nil, err
+        }
+        left = condLogical{op: "&&", left: left, right: right}
+    }
+}
+
+func (p *condParser) parseNot() (condExpr, error) {
+    if _, ok := p.accept("!", "not"); ok {
+        operand, err := p.parseNot()
+        if err != nil {
+            return nil, err
+        }
+        return condNot{operand: operand}, nil
+    }
+    return p.parseComparison()
+}
+
+func (p *condParser) parseComparison() (condExpr, error) {
+    left, err := p.parsePrimary()
+    if err != nil {
+        return nil, err
+    }
+    op, ok := p.accept("==", "!=", "<=", ">=", "<", ">")
+    if !ok {
+        return left, nil
+    }
+    right, err := p.parsePrimary()
+    if err != nil {
+        return nil, err
+    }
+    return condCompare{op: op, left: left, right: right}, nil
+}
+
+func (p *condParser) parsePrimary() (condExpr, error) {
+    if p.done() {
+        return nil, fmt.Errorf("unexpected end of expression")
+    }
+
+    if _, ok := p.accept("("); ok {
+        inner, err := p.parseOr()
+        if err != nil {
+            return nil, err
+        }
+        if _, ok := p.accept(")"); !ok {
+            return nil, fmt.Errorf("missing closing parenthesis")
+        }
+        return inner, nil
+    }
+
+    if _, ok := p.accept("-"); ok {
+        operand, err := p.parsePrimary()
+        if err != nil {
+            return nil, err
+        }
+        return condNegate{operand: operand}, nil
+    }
+
+    tok := p.tokens[p.pos]
+    switch tok.kind {
+    case tokenNumber, tokenString:
+        p.pos++
+        return condLiteral{value: tok.value}, nil
+    case tokenPath:
+        switch tok.text {
+        case "true":
+            p.pos++
+            return condLiteral{value: true}, nil
+        case "false":
+            p.pos++
+            return condLiteral{value: false}, nil
+        case "null", "nil":
+            p.pos++
+            return condLiteral{value: nil}, nil
+        case "and", "or", "not":
+            return nil, fmt.Errorf("unexpected %q", tok.text)
+        }
+        for _, part := range strings.Split(tok.text, ".") {
+            if part == "" {
+                return nil, fmt.Errorf("invalid path %q", tok.text)
+            }
+        }
+        p.pos++
+        return condPath{parts: strings.Split(tok.text, ".")}, nil
+    }
+    return nil, fmt.Errorf("unexpected %q", tok.text)
+}
@@ -0,0 +1,68 @@
+package dagengine
+
+import (
+    "testing"
+)
+
+func TestConditionEvaluate(t *testing.T) {
+    vars := map[string]interface{}{
+        "transform": map[string]interface{}{"rows": 0, "status": "ok"},
+        "extract":   map[string]interface{}{"files": []interface{}{"a.csv", "b.csv"}, "count": 2.0},
+        "load-data": map[string]interface{}{"done": true},
+        WorkflowInputsKey: map[string]interface{}{"mode": "full", "dry_run": false},
+    }
+
+    tests := []struct {
+        expr string
+        want bool
+    }{
+        {"transform.rows > 0", false},
+        {"transform.rows == 0", true},
+        {"extract.count >= 2", true},
+        {"extract.count == 2 && transform.status == 'ok'", true},
+        {"transform.rows > 0 || workflow.mode == \"full\"", true},
+        {"!workflow.dry_run", true},
+        {"not workflow.dry_run and extract.files.1 == 'b.csv'", true},
+        {"load-data.done", true},
+        {"missing.field == null", true},
+        {"missing.field", false},
+        {"(transform.rows > 0 || extract.count > 1) && workflow.mode != 'delta'", true},
+        {"extract.count > -1", true},
+        {"transform.status < 'z'", true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.expr, func(t *testing.T) {
+            condition, err := CompileCondition(tt.expr)
+            if err != nil {
+                t.Fatalf("CompileCondition failed: %v", err)
+            }
+            got, err := condition.Evaluate(vars)
+            if err != nil {
+                t.Fatalf("Evaluate failed: %v", err)
+            }
+            if got != tt.want {
+                t.Errorf("Expected %v, got %v", tt.want, got)
+            }
+        })
+    }
+}
+
+func TestCompileConditionErrors(t *testing.T) {
+    for _, expr := range []string{"", "a >", "(a == 1", "a == 'open", "a $ b", "a == 1 b", "and"} {
+        if _, err := CompileCondition(expr); err == nil {
+            t.Errorf("Expected error compiling %q, got none", expr)
+        }
+    }
+}
+
+func TestConditionEvaluateTypeMismatch(t *testing.T) {
+    condition, err := CompileCondition("transform.status > 1")
+    if err != nil {
+        t.Fatalf("CompileCondition failed: %v", err)
+    }
+    vars := map[string]interface{}{"transform": map[string]interface{}{"status": "ok"}}
+    if _, err := condition.Evaluate(vars); err == nil {
+        t.Error("Expected error comparing a string with a number, got none")
+    }
+}
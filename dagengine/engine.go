@@ -4,118 +4,197 @@ import (
     "context"
     "fmt"
     "sync"
+    "sync/atomic"
+    "time"
 )
 
 // DAGEngine manages the graph structure and handles execution.
+//
+// Nodes are added with AddNode and compiled into an immutable Plan by
+// Compile (or PreprocessDAG, which keeps the plan for Run). Every execution
+// of a Plan gets its own Run, so the same engine and Plan can serve many
+// executions concurrently.
+//
+// If StateStore is set, every run saves a checkpoint after each node
+// finishes, and an interrupted execution can be continued with Resume.
 type DAGEngine struct {
-    Nodes map[string]*Node 
-    mu    sync.Mutex
-    wg    sync.WaitGroup // Use a WaitGroup to wait for all nodes to finish
+    Nodes          map[string]*Node
+    Policy         FailurePolicy  // How node failures affect the rest of the run (default: FailFast)
+    Timeout        time.Duration  // Deadline for a whole run; 0 means no limit
+    MaxConcurrency int            // Maximum number of nodes running at once in a run; 0 means no limit
+    ResourcePools  map[string]int // Named pools of slots that nodes claim through Node.Pool
+    StateStore     StateStore     // Where runs save their checkpoints; nil disables checkpointing
+    Cache          ResultCache    // Where the results of nodes with a CacheConfig are kept; nil disables caching
+    Executors      *ExecutorRegistry // Types of the nodes created by NodeFromSpec (default: DefaultExecutorRegistry())
+    mu             sync.Mutex
+    plan           *Plan  // Plan compiled by PreprocessDAG, executed by Run
+    runSeq         uint64 // Used to generate run IDs
+    observers      []Observer
 }
 
 func NewDAGEngine() *DAGEngine {
     return &DAGEngine{
-        Nodes: make(map[string]*Node),
+        Nodes:  make(map[string]*Node),
+        Policy: FailFast,
     }
 }
 
-// Run starts the concurrent execution of the entire DAG.
-func (e *DAGEngine) Run(ctx context.Context) error {
-    // Basic dependency verification and cycle check (critical but omitted for brevity)
-    
-    // Identify and start all root nodes (those with no dependencies)
-    for _, node := range e.Nodes {
-        if len(node.Dependencies) == 0 {
-            e.wg.Add(1) // Increment counter for each root node started
-            go e.executeNode(ctx, node)
+// Run executes the plan compiled by PreprocessDAG and waits for it to finish.
+// The workflow-level inputs are made available to every node under WorkflowInputsKey.
+// If PreprocessDAG has not been called, the current nodes are compiled first.
+//
+// The returned RunResult holds the final status of every node. If any node
+// failed, the error is a *RunError describing all failures. If the run
+// exceeded e.Timeout or the deadline of ctx, nodes that were still running
+// are marked TIMED_OUT and the run status is RunTimedOut.
+func (e *DAGEngine) Run(ctx context.Context, inputs map[string]interface{}) (*RunResult, error) {
+    plan, err := e.currentPlan()
+    if err != nil {
+        return nil, err
+    }
+    return e.Execute(ctx, plan, inputs)
+}
+
+// currentPlan returns the plan compiled by PreprocessDAG, compiling the
+// current nodes if there is none yet.
+func (e *DAGEngine) currentPlan() (*Plan, error) {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+
+    if e.plan == nil {
+        plan, err := e.compileLocked()
+        if err != nil {
+            return nil, err
         }
+        e.plan = plan
     }
-    
-    // Block until all nodes added to the WaitGroup are finished.
-    e.wg.Wait()
+    return e.plan, nil
+}
 
-    // Check final status for overall success/failure
-    // ...
-    return nil
+// Execute runs a plan and waits for it to finish. It is safe to call
+// concurrently, with the same or different plans.
+func (e *DAGEngine) Execute(ctx context.Context, plan *Plan, inputs map[string]interface{}) (*RunResult, error) {
+    return e.Start(ctx, plan, inputs).Wait()
+}
+
+// Start begins an execution of a plan and returns without waiting for it.
+// The run gets a generated, unique ID.
+func (e *DAGEngine) Start(ctx context.Context, plan *Plan, inputs map[string]interface{}) *Run {
+    id := fmt.Sprintf("run-%d-%d", time.Now().UnixNano(), atomic.AddUint64(&e.runSeq, 1))
+    return e.StartExecution(ctx, id, plan, inputs)
 }
 
-// executeNode is the concurrent worker function for a single node.
-func (e *DAGEngine) executeNode(ctx context.Context, n *Node) {
-    defer e.wg.Done() // Signal completion when the goroutine exits
-    
-    // 1. Gather Inputs (Simplified: actual logic needs careful data mapping)
-    // ... logic to read n.Dependencies[i].Result into the inputs map ...
-
-    // 2. Execute Task
-    n.mu.Lock()
-    n.Status = "RUNNING"
-    n.mu.Unlock()
-    
-    result, err := n.Task.Execute(ctx, nil) // passing nil for simplified inputs
-    
-    // 3. Update Status and Trigger Dependents
-    n.mu.Lock()
+// StartExecution is like Start, with an execution ID chosen by the caller,
+// which is also the key of the run's checkpoints.
+func (e *DAGEngine) StartExecution(ctx context.Context, executionID string, plan *Plan, inputs map[string]interface{}) *Run {
+    run := e.newRun(executionID, plan, inputs)
+    run.start(ctx)
+    return run
+}
+
+// Resume continues an execution from its last checkpoint using the plan
+// compiled by PreprocessDAG, and waits for it to finish. Nodes that had
+// completed keep their saved results and are not run again; all other nodes
+// run as in a new execution, with the saved workflow inputs.
+func (e *DAGEngine) Resume(ctx context.Context, executionID string) (*RunResult, error) {
+    plan, err := e.currentPlan()
     if err != nil {
-        n.Status = "FAILED"
-        fmt.Printf("Node %s FAILED: %v\n", n.ID, err)
-        // Add logic to cascade failure (fail-fast)
-        return
+        return nil, err
     }
-    
-    n.Status = "COMPLETED"
-    n.Result = result
-    fmt.Printf("Node %s COMPLETED. Result: %v\n", n.ID, result)
-    n.mu.Unlock()
+    run, err := e.ResumeExecution(ctx, executionID, plan)
+    if err != nil {
+        return nil, err
+    }
+    return run.Wait()
+}
 
-    // 4. Trigger Children
-	e.triggerChildren(ctx, n)
+// ResumeExecution is like Resume, for a given plan, and returns without
+// waiting for the run. The plan must contain every node of the checkpoint.
+func (e *DAGEngine) ResumeExecution(ctx context.Context, executionID string, plan *Plan) (*Run, error) {
+    if e.StateStore == nil {
+        return nil, fmt.Errorf("cannot resume execution '%s': engine has no state store", executionID)
+    }
+    checkpoint, err := e.StateStore.Load(executionID)
+    if err != nil {
+        return nil, fmt.Errorf("cannot resume execution '%s': %w", executionID, err)
+    }
+
+    run := e.newRun(executionID, plan, checkpoint.Inputs)
+    if err := run.restore(checkpoint); err != nil {
+        return nil, err
+    }
+    run.start(ctx)
+    return run, nil
 }
 
-// triggerChildren iterates over the pre-calculated direct children.
-func (e *DAGEngine) triggerChildren(ctx context.Context, parentNode *Node) {
-    e.mu.Lock()
-    defer e.mu.Unlock()
-    
-    // Iterate over the cached list of direct children
-    for _, childID := range parentNode.Children {
-        childNode, exists := e.Nodes[childID]
-        if !exists {
-            // Should not happen if FinalizeDAG ran correctly, but good for safety
-            continue
-        }
-        
-        childNode.mu.Lock()
-        
-        // This is the only logic needed for concurrency control:
-        childNode.ReadyCounter-- 
-
-        fmt.Printf("Node %s dependency fulfilled by %s. ReadyCounter: %d\n", 
-            childNode.ID, parentNode.ID, childNode.ReadyCounter)
-
-        if childNode.ReadyCounter == 0 {
-            if childNode.Status == "PENDING" {
-                e.wg.Add(1)
-                go e.executeNode(ctx, childNode)
-            }
-        }
-        childNode.mu.Unlock()
+// Rerun executes again the node from and all its descendants in a finished
+// execution, using the plan compiled by PreprocessDAG, and waits for it to
+// finish. All other nodes keep the state and results saved in the
+// execution's checkpoint, which is updated in place.
+func (e *DAGEngine) Rerun(ctx context.Context, executionID, from string) (*RunResult, error) {
+    plan, err := e.currentPlan()
+    if err != nil {
+        return nil, err
+    }
+    run, err := e.RerunExecution(ctx, executionID, plan, from)
+    if err != nil {
+        return nil, err
+    }
+    return run.Wait()
+}
+
+// RerunExecution is like Rerun, for a given plan, and returns without
+// waiting for the run.
+func (e *DAGEngine) RerunExecution(ctx context.Context, executionID string, plan *Plan, from string) (*Run, error) {
+    if e.StateStore == nil {
+        return nil, fmt.Errorf("cannot rerun execution '%s': engine has no state store", executionID)
+    }
+    rerun, err := plan.Downstream(from)
+    if err != nil {
+        return nil, fmt.Errorf("cannot rerun execution '%s': %w", executionID, err)
+    }
+    checkpoint, err := e.StateStore.Load(executionID)
+    if err != nil {
+        return nil, fmt.Errorf("cannot rerun execution '%s': %w", executionID, err)
+    }
+
+    run := e.newRun(executionID, plan, checkpoint.Inputs)
+    if err := run.restoreExcept(checkpoint, rerun); err != nil {
+        return nil, err
     }
+    run.start(ctx)
+    return run, nil
+}
+
+// newRun prepares a run with the engine's observers and state store.
+func (e *DAGEngine) newRun(id string, plan *Plan, inputs map[string]interface{}) *Run {
+    e.mu.Lock()
+    observers := append([]Observer(nil), e.observers...)
+    e.mu.Unlock()
+
+    run := newRun(id, plan, inputs, observers)
+    run.store = e.StateStore
+    run.cache = e.Cache
+    return run
 }
 
 // AddNode registers a new Node into the graph.
-// It uses a mutex to ensure thread-safe map access.
+// It uses a mutex to ensure thread-safe map access. Nodes added while the
+// engine is running only take part in runs of plans compiled afterwards.
 func (e *DAGEngine) AddNode(node *Node) error {
     e.mu.Lock()
     defer e.mu.Unlock()
 
-    // 1. Check for duplicate ID
+    // 1. Check for duplicate or reserved ID
+    if node.ID == WorkflowInputsKey {
+        return fmt.Errorf("node ID '%s' is reserved for workflow inputs", node.ID)
+    }
     if _, exists := e.Nodes[node.ID]; exists {
         return fmt.Errorf("node with ID '%s' already exists", node.ID)
     }
 
-    // 2. Basic Dependency Check
-    // In a full engine, you'd check that all IDs in node.Dependencies 
-    // actually exist in e.Nodes. We'll skip that for the boilerplate.
+    // 2. Dependencies are validated as a whole by PreprocessDAG, since
+    // nodes may be added in any order.
 
     // 3. Add the Node to the map
     e.Nodes[node.ID] = node
@@ -123,32 +202,50 @@ func (e *DAGEngine) AddNode(node *Node) error {
     return nil
 }
 
-// dagengine/engine.go
+// NodeFromSpec creates a node whose executor is declared by spec, using the
+// engine's registry of executor types. The node gets a copy of the type's
+// retry policy, if it has one. It still has to be added with AddNode.
+func (e *DAGEngine) NodeFromSpec(id string, deps []string, spec ExecutorSpec, host *ExecutorHost) (*Node, error) {
+    registry := e.Executors
+    if registry == nil {
+        registry = DefaultExecutorRegistry()
+    }
+    executor, err := registry.New(spec, host)
+    if err != nil {
+        return nil, fmt.Errorf("node %s: %w", id, err)
+    }
+    node := NewNode(id, deps, executor)
+    if t, ok := registry.Lookup(spec.Type); ok && t.Retry != nil {
+        policy := *t.Retry
+        node.Retry = &policy
+    }
+    return node, nil
+}
 
-// PreprocessDAG processes all nodes to build the 'Children' list
-// and runs necessary validation before execution.
+// PreprocessDAG validates the graph and compiles it into the Plan executed
+// by Run. It also builds the 'Children' list of the engine's nodes.
 func (e *DAGEngine) PreprocessDAG() error {
     e.mu.Lock()
     defer e.mu.Unlock()
 
-    // 1. Clear existing children lists (in case of re-finalization)
-    for _, node := range e.Nodes {
-        node.Children = nil
+    plan, err := e.compileLocked()
+    if err != nil {
+        return err
     }
+    e.plan = plan
 
-    // 2. Build the Children (Adjacency) list
-    for childID, childNode := range e.Nodes {
-        for _, parentID := range childNode.Dependencies {
-            parentNode, exists := e.Nodes[parentID]
-            if !exists {
-                return fmt.Errorf("dependency error: node '%s' depends on non-existent node '%s'", childID, parentID)
-            }
-            // Append the child's ID to the parent's Children list
-            parentNode.Children = append(parentNode.Children, childID)
-        }
+    for id, node := range e.Nodes {
+        node.Children = append([]string(nil), plan.nodes[id].Children...)
     }
-    
-    // 3. (Optional but recommended): Run cycle detection here.
-    
     return nil
-}
\ No newline at end of file
+}
+
+// containsString reports whether s is present in list.
+func containsString(list []string, s string) bool {
+    for _, item := range list {
+        if item == s {
+            return true
+        }
+    }
+    return false
+}
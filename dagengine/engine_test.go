@@ -0,0 +1,532 @@
+package dagengine
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+// recordingExecutor returns a fixed result and records the inputs it was given.
+type recordingExecutor struct {
+    mu     sync.Mutex
+    result map[string]interface{}
+    inputs map[string]interface{}
+}
+
+func (r *recordingExecutor) Execute(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.inputs = inputs
+    return r.result, nil
+}
+
+func (r *recordingExecutor) seen() map[string]interface{} {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    return r.inputs
+}
+
+// newDiamond builds the A -> B,C -> D sample graph.
+func newDiamond(t *testing.T, execs map[string]Executor) *DAGEngine {
+    t.Helper()
+
+    engine := NewDAGEngine()
+    deps := map[string][]string{
+        "A": nil,
+        "B": {"A"},
+        "C": {"A"},
+        "D": {"B", "C"},
+    }
+    for _, id := range []string{"A", "B", "C", "D"} {
+        if err := engine.AddNode(NewNode(id, deps[id], execs[id])); err != nil {
+            t.Fatalf("Failed to add node %s: %v", id, err)
+        }
+    }
+    return engine
+}
+
+func TestRunPassesParentResultsInDiamond(t *testing.T) {
+    a := &recordingExecutor{result: map[string]interface{}{"rows": 3}}
+    b := &recordingExecutor{result: map[string]interface{}{"left": "b"}}
+    c := &recordingExecutor{result: map[string]interface{}{"right": "c"}}
+    d := &recordingExecutor{result: map[string]interface{}{"done": true}}
+
+    engine := newDiamond(t, map[string]Executor{"A": a, "B": b, "C": c, "D": d})
+    if err := engine.PreprocessDAG(); err != nil {
+        t.Fatalf("PreprocessDAG failed: %v", err)
+    }
+
+    workflowInputs := map[string]interface{}{"source": "test"}
+    if _, err := engine.Run(context.Background(), workflowInputs); err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+
+    rootInputs := a.seen()
+    if len(rootInputs) != 1 {
+        t.Errorf("Expected root node to only see workflow inputs, got %v", rootInputs)
+    }
+
+    bInputs := b.seen()
+    if got := bInputs["A"].(map[string]interface{})["rows"]; got != 3 {
+        t.Errorf("Expected B to see A.rows = 3, got %v", got)
+    }
+
+    dInputs := d.seen()
+    if _, ok := dInputs["A"]; ok {
+        t.Errorf("Expected D to only see its direct parents, got A in %v", dInputs)
+    }
+    if got := dInputs["B"].(map[string]interface{})["left"]; got != "b" {
+        t.Errorf("Expected D to see B.left = 'b', got %v", got)
+    }
+    if got := dInputs["C"].(map[string]interface{})["right"]; got != "c" {
+        t.Errorf("Expected D to see C.right = 'c', got %v", got)
+    }
+    if got := dInputs[WorkflowInputsKey].(map[string]interface{})["source"]; got != "test" {
+        t.Errorf("Expected D to see workflow input source = 'test', got %v", got)
+    }
+}
+
+func TestRunAppliesInputMappings(t *testing.T) {
+    a := &recordingExecutor{result: map[string]interface{}{
+        "data": map[string]interface{}{"count": 1000},
+    }}
+    b := &recordingExecutor{result: map[string]interface{}{"value": 1}}
+    c := &recordingExecutor{result: map[string]interface{}{"value": 2}}
+    d := &recordingExecutor{result: map[string]interface{}{}}
+
+    engine := newDiamond(t, map[string]Executor{"A": a, "B": b, "C": c, "D": d})
+    engine.Nodes["B"].Inputs = []InputMapping{
+        {From: "A", Field: "data.count"},
+    }
+    engine.Nodes["D"].Inputs = []InputMapping{
+        {From: "B", Field: "value", As: "left"},
+        {From: "C", Field: "value", As: "right"},
+        {From: "C", Field: "missing", As: "fallback", Default: "none"},
+        {From: WorkflowInputsKey, Field: "env"},
+    }
+    if err := engine.PreprocessDAG(); err != nil {
+        t.Fatalf("PreprocessDAG failed: %v", err)
+    }
+
+    if _, err := engine.Run(context.Background(), map[string]interface{}{"env": "prod"}); err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+
+    if got := b.seen()["count"]; got != 1000 {
+        t.Errorf("Expected B input 'count' = 1000, got %v", got)
+    }
+
+    tests := []struct {
+        key  string
+        want interface{}
+    }{
+        {"left", 1},
+        {"right", 2},
+        {"fallback", "none"},
+        {"env", "prod"},
+    }
+    dInputs := d.seen()
+    for _, tt := range tests {
+        if got := dInputs[tt.key]; got != tt.want {
+            t.Errorf("Expected D input '%s' = %v, got %v", tt.key, tt.want, got)
+        }
+    }
+}
+
+func TestRunFailsNodeOnMissingMappedField(t *testing.T) {
+    a := &recordingExecutor{result: map[string]interface{}{}}
+    b := &recordingExecutor{result: map[string]interface{}{}}
+
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("A", nil, a))
+    nodeB := NewNode("B", []string{"A"}, b)
+    nodeB.Inputs = []InputMapping{{From: "A", Field: "rows"}}
+    engine.AddNode(nodeB)
+    if err := engine.PreprocessDAG(); err != nil {
+        t.Fatalf("PreprocessDAG failed: %v", err)
+    }
+
+    result, err := engine.Run(context.Background(), nil)
+    if err == nil {
+        t.Error("Expected run to fail, got no error")
+    }
+
+    if result.NodeStatuses["B"] != StatusFailed {
+        t.Errorf("Expected B to fail on missing mapped field, got status %s", result.NodeStatuses["B"])
+    }
+    if b.seen() != nil {
+        t.Errorf("Expected B's executor not to run, but it saw %v", b.seen())
+    }
+}
+
+func TestPreprocessRejectsMappingFromNonDependency(t *testing.T) {
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("A", nil, &recordingExecutor{}))
+    nodeB := NewNode("B", nil, &recordingExecutor{})
+    nodeB.Inputs = []InputMapping{{From: "A", Field: "rows"}}
+    engine.AddNode(nodeB)
+
+    if err := engine.PreprocessDAG(); err == nil {
+        t.Error("Expected error for mapping from a node that is not a dependency, got none")
+    }
+}
+
+func TestPreprocessRejectsCollidingMappings(t *testing.T) {
+    tests := []struct {
+        name   string
+        inputs []InputMapping
+        want   string
+    }{
+        {"two aliases", []InputMapping{{From: "A", Field: "x", As: "v"}, {From: "B", Field: "y", As: "v"}}, "more than one value to 'v'"},
+        {"default names", []InputMapping{{From: "A", Field: "count"}, {From: "B", Field: "data.count"}}, "more than one value to 'count'"},
+        {"another parent", []InputMapping{{From: "A", Field: "x", As: "B"}}, "'B', which already names one of its sources"},
+        {"workflow inputs", []InputMapping{{From: "A", Field: "x", As: WorkflowInputsKey}}, "already names one of its sources"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            engine := NewDAGEngine()
+            engine.AddNode(NewNode("A", nil, &recordingExecutor{}))
+            engine.AddNode(NewNode("B", nil, &recordingExecutor{}))
+            nodeC := NewNode("C", []string{"A", "B"}, &recordingExecutor{})
+            nodeC.Inputs = tt.inputs
+            engine.AddNode(nodeC)
+
+            if err := engine.PreprocessDAG(); err == nil || !strings.Contains(err.Error(), tt.want) {
+                t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+            }
+        })
+    }
+}
+
+func TestRunCopiesWorkflowInputsPerNode(t *testing.T) {
+    mutate := ExecutorFunc(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+        inputs[WorkflowInputsKey].(map[string]interface{})["env"] = "changed"
+        return map[string]interface{}{}, nil
+    })
+    b := &recordingExecutor{result: map[string]interface{}{}}
+
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("A", nil, mutate))
+    engine.AddNode(NewNode("B", []string{"A"}, b))
+    workflowInputs := map[string]interface{}{"env": "prod"}
+    if _, err := engine.Run(context.Background(), workflowInputs); err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+
+    if got := b.seen()[WorkflowInputsKey].(map[string]interface{})["env"]; got != "prod" {
+        t.Errorf("Expected B to see env = 'prod', got %v", got)
+    }
+    if workflowInputs["env"] != "prod" {
+        t.Errorf("Expected the caller's inputs to be unchanged, got %v", workflowInputs)
+    }
+}
+
+func TestAddNodeRejectsReservedID(t *testing.T) {
+    engine := NewDAGEngine()
+    if err := engine.AddNode(NewNode(WorkflowInputsKey, nil, &recordingExecutor{})); err == nil {
+        t.Errorf("Expected error when adding node with reserved ID '%s', got none", WorkflowInputsKey)
+    }
+}
+
+func TestRunFailurePolicies(t *testing.T) {
+    // A fails; B depends on A; C is an independent slow branch; D depends on B and C.
+    tests := []struct {
+        name   string
+        policy FailurePolicy
+        want   map[string]string
+    }{
+        {
+            name:   "fail fast",
+            policy: FailFast,
+            want: map[string]string{
+                "A": StatusFailed, "B": StatusUpstreamFailed, "C": StatusCancelled, "D": StatusUpstreamFailed,
+            },
+        },
+        {
+            name:   "continue independent branches",
+            policy: ContinueIndependent,
+            want: map[string]string{
+                "A": StatusFailed, "B": StatusUpstreamFailed, "C": StatusCompleted, "D": StatusUpstreamFailed,
+            },
+        },
+        {
+            name:   "best effort",
+            policy: BestEffort,
+            want: map[string]string{
+                "A": StatusFailed, "B": StatusCompleted, "C": StatusCompleted, "D": StatusCompleted,
+            },
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            started := make(chan struct{})
+            failing := ExecutorFunc(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+                <-started // Make sure C is running when A fails
+                return nil, errors.New("boom")
+            })
+            slow := ExecutorFunc(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+                close(started)
+                select {
+                case <-time.After(50 * time.Millisecond):
+                    return map[string]interface{}{}, nil
+                case <-ctx.Done():
+                    return nil, ctx.Err()
+                }
+            })
+            ok := &recordingExecutor{result: map[string]interface{}{}}
+
+            engine := NewDAGEngine()
+            engine.Policy = tt.policy
+            engine.AddNode(NewNode("A", nil, failing))
+            engine.AddNode(NewNode("B", []string{"A"}, ok))
+            engine.AddNode(NewNode("C", nil, slow))
+            engine.AddNode(NewNode("D", []string{"B", "C"}, ok))
+            if err := engine.PreprocessDAG(); err != nil {
+                t.Fatalf("PreprocessDAG failed: %v", err)
+            }
+
+            result, err := engine.Run(context.Background(), nil)
+
+            var runErr *RunError
+            if !errors.As(err, &runErr) {
+                t.Fatalf("Expected *RunError, got %v", err)
+            }
+            if len(runErr.Failures) != 1 || runErr.Failures["A"] == nil {
+                t.Errorf("Expected only A in failures, got %v", runErr.Failures)
+            }
+            if result.Status != RunFailed {
+                t.Errorf("Expected run status %s, got %s", RunFailed, result.Status)
+            }
+            for id, want := range tt.want {
+                if got := result.NodeStatuses[id]; got != want {
+                    t.Errorf("Expected node %s status %s, got %s", id, want, got)
+                }
+            }
+        })
+    }
+}
+
+func TestRunReportsCancellation(t *testing.T) {
+    ctx, cancel := context.WithCancel(context.Background())
+
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("A", nil, ExecutorFunc(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+        cancel()
+        <-ctx.Done()
+        return nil, ctx.Err()
+    })))
+    engine.AddNode(NewNode("B", []string{"A"}, &recordingExecutor{}))
+    if err := engine.PreprocessDAG(); err != nil {
+        t.Fatalf("PreprocessDAG failed: %v", err)
+    }
+
+    result, err := engine.Run(ctx, nil)
+    if !errors.Is(err, context.Canceled) {
+        t.Fatalf("Expected context.Canceled, got %v", err)
+    }
+    if result.Status != RunCancelled {
+        t.Errorf("Expected run status %s, got %s", RunCancelled, result.Status)
+    }
+    if result.NodeStatuses["A"] != StatusCancelled || result.NodeStatuses["B"] != StatusSkipped {
+        t.Errorf("Expected A CANCELLED and B SKIPPED, got %v", result.NodeStatuses)
+    }
+}
+
+// blockingExecutor waits until its context is done.
+var blockingExecutor = ExecutorFunc(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+    <-ctx.Done()
+    return nil, ctx.Err()
+})
+
+func TestRunTimesOutNode(t *testing.T) {
+    engine := NewDAGEngine()
+    engine.Policy = ContinueIndependent
+    nodeA := NewNode("A", nil, blockingExecutor)
+    nodeA.Timeout = 10 * time.Millisecond
+    nodeA.Retry = &RetryPolicy{MaxAttempts: 2}
+    engine.AddNode(nodeA)
+    engine.AddNode(NewNode("B", []string{"A"}, &recordingExecutor{}))
+    engine.AddNode(NewNode("C", nil, &recordingExecutor{result: map[string]interface{}{}}))
+    if err := engine.PreprocessDAG(); err != nil {
+        t.Fatalf("PreprocessDAG failed: %v", err)
+    }
+
+    result, err := engine.Run(context.Background(), nil)
+
+    var runErr *RunError
+    if !errors.As(err, &runErr) || !errors.Is(runErr.Failures["A"], ErrNodeTimeout) {
+        t.Fatalf("Expected A to fail with ErrNodeTimeout, got %v", err)
+    }
+    if result.NodeAttempts["A"] != 2 {
+        t.Errorf("Expected timed-out attempts to be retried, got %d attempts", result.NodeAttempts["A"])
+    }
+    want := map[string]string{"A": StatusTimedOut, "B": StatusUpstreamFailed, "C": StatusCompleted}
+    for id, status := range want {
+        if got := result.NodeStatuses[id]; got != status {
+            t.Errorf("Expected node %s status %s, got %s", id, status, got)
+        }
+    }
+}
+
+func TestRunTimesOutWorkflow(t *testing.T) {
+    engine := NewDAGEngine()
+    engine.Timeout = 20 * time.Millisecond
+    engine.AddNode(NewNode("A", nil, blockingExecutor))
+    engine.AddNode(NewNode("B", []string{"A"}, &recordingExecutor{}))
+    if err := engine.PreprocessDAG(); err != nil {
+        t.Fatalf("PreprocessDAG failed: %v", err)
+    }
+
+    result, err := engine.Run(context.Background(), nil)
+    if !errors.Is(err, context.DeadlineExceeded) {
+        t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+    }
+    if result.Status != RunTimedOut {
+        t.Errorf("Expected run status %s, got %s", RunTimedOut, result.Status)
+    }
+    if result.NodeStatuses["A"] != StatusTimedOut {
+        t.Errorf("Expected A %s, got %s", StatusTimedOut, result.NodeStatuses["A"])
+    }
+    if result.NodeStatuses["B"] == StatusCompleted {
+        t.Errorf("Expected B not to run after the workflow timed out")
+    }
+}
+
+func TestRunSkipsNodeWhenConditionIsFalse(t *testing.T) {
+    tests := []struct {
+        name string
+        rows int
+        want map[string]string
+    }{
+        {"zero rows", 0, map[string]string{"load": StatusSkipped, "report": StatusSkipped, "notify": StatusCompleted}},
+        {"some rows", 10, map[string]string{"load": StatusCompleted, "report": StatusCompleted, "notify": StatusCompleted}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            engine := NewDAGEngine()
+            engine.AddNode(NewNode("transform", nil, &recordingExecutor{result: map[string]interface{}{"rows": tt.rows}}))
+            load := NewNode("load", []string{"transform"}, &recordingExecutor{result: map[string]interface{}{}})
+            load.Condition = "transform.rows > 0"
+            engine.AddNode(load)
+            engine.AddNode(NewNode("report", []string{"load"}, &recordingExecutor{result: map[string]interface{}{}}))
+            notify := NewNode("notify", []string{"load"}, &recordingExecutor{result: map[string]interface{}{}})
+            notify.TriggerRule = NoneFailed
+            engine.AddNode(notify)
+            if err := engine.PreprocessDAG(); err != nil {
+                t.Fatalf("PreprocessDAG failed: %v", err)
+            }
+
+            result, err := engine.Run(context.Background(), nil)
+            if err != nil {
+                t.Fatalf("Run failed: %v", err)
+            }
+            for id, want := range tt.want {
+                if got := result.NodeStatuses[id]; got != want {
+                    t.Errorf("Expected node %s status %s, got %s", id, want, got)
+                }
+            }
+        })
+    }
+}
+
+func TestPreprocessRejectsInvalidCondition(t *testing.T) {
+    engine := NewDAGEngine()
+    node := NewNode("A", nil, &recordingExecutor{})
+    node.Condition = "rows >"
+    engine.AddNode(node)
+
+    if err := engine.PreprocessDAG(); err == nil {
+        t.Error("Expected error for invalid condition, got none")
+    }
+}
+
+func TestRunTriggerRules(t *testing.T) {
+    // A fails and B completes; C depends on both and uses the rule under test.
+    tests := []struct {
+        rule TriggerRule
+        want string
+    }{
+        {AllSuccess, StatusUpstreamFailed},
+        {AllDone, StatusCompleted},
+        {OneFailed, StatusCompleted},
+        {OneSuccess, StatusCompleted},
+        {NoneFailed, StatusUpstreamFailed},
+    }
+
+    for _, tt := range tests {
+        t.Run(string(tt.rule), func(t *testing.T) {
+            engine := NewDAGEngine()
+            engine.Policy = ContinueIndependent
+            engine.AddNode(NewNode("A", nil, ExecutorFunc(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+                return nil, errors.New("boom")
+            })))
+            engine.AddNode(NewNode("B", nil, &recordingExecutor{result: map[string]interface{}{}}))
+            nodeC := NewNode("C", []string{"A", "B"}, &recordingExecutor{result: map[string]interface{}{}})
+            nodeC.TriggerRule = tt.rule
+            engine.AddNode(nodeC)
+            if err := engine.PreprocessDAG(); err != nil {
+                t.Fatalf("PreprocessDAG failed: %v", err)
+            }
+
+            result, _ := engine.Run(context.Background(), nil)
+            if got := result.NodeStatuses["C"]; got != tt.want {
+                t.Errorf("Expected C status %s, got %s", tt.want, got)
+            }
+        })
+    }
+}
+
+func TestRunTriggerRulesFailFast(t *testing.T) {
+    // Under the default policy A's failure cancels the run; the cleanup node
+    // still runs, under a context that is not cancelled, while B is skipped.
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("A", nil, ExecutorFunc(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+        return nil, errors.New("boom")
+    })))
+    engine.AddNode(NewNode("B", []string{"A"}, &recordingExecutor{result: map[string]interface{}{}}))
+    for _, rule := range []TriggerRule{OneFailed, AllDone} {
+        cleanup := NewNode("cleanup_"+string(rule), []string{"A"}, ExecutorFunc(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+            if ctx.Err() != nil {
+                return nil, ctx.Err()
+            }
+            return map[string]interface{}{}, nil
+        }))
+        cleanup.TriggerRule = rule
+        engine.AddNode(cleanup)
+    }
+    if err := engine.PreprocessDAG(); err != nil {
+        t.Fatalf("PreprocessDAG failed: %v", err)
+    }
+
+    result, err := engine.Run(context.Background(), nil)
+    var runErr *RunError
+    if !errors.As(err, &runErr) {
+        t.Fatalf("Expected a RunError, got %v", err)
+    }
+    want := map[string]string{
+        "A":                  StatusFailed,
+        "B":                  StatusUpstreamFailed,
+        "cleanup_one_failed": StatusCompleted,
+        "cleanup_all_done":   StatusCompleted,
+    }
+    for id, status := range want {
+        if got := result.NodeStatuses[id]; got != status {
+            t.Errorf("Expected node %s status %s, got %s", id, status, got)
+        }
+    }
+}
+
+func TestTriggerRuleWithoutFailures(t *testing.T) {
+    statuses := []string{StatusCompleted, StatusCompleted}
+    if got := OneFailed.blockedStatus(statuses, FailFast); got != StatusSkipped {
+        t.Errorf("Expected one_failed to skip when no parent failed, got %q", got)
+    }
+    if got := AllSuccess.blockedStatus(statuses, FailFast); got != "" {
+        t.Errorf("Expected all_success to run, got %q", got)
+    }
+}
@@ -1,10 +1,69 @@
 package dagengine
 
-import "context"
+import (
+    "context"
+    "fmt"
+    "time"
+)
 
 // Executor defines the contract for any task run by the DAG engine.
 type Executor interface {
     // Execute runs the node's logic. 
     // Inputs come from upstream dependencies. Outputs are passed downstream.
     Execute(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error)
-}
\ No newline at end of file
+}
+
+// ExecutorFunc adapts an ordinary function to the Executor interface.
+type ExecutorFunc func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error)
+
+// Execute calls f(ctx, inputs).
+func (f ExecutorFunc) Execute(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+    return f(ctx, inputs)
+}
+
+// configString returns the string at key in the config of an executor of
+// type kind, or "" if it is not set.
+func configString(kind string, config map[string]interface{}, key string) (string, error) {
+    value, ok := config[key]
+    if !ok || value == nil {
+        return "", nil
+    }
+    s, ok := value.(string)
+    if !ok {
+        return "", fmt.Errorf("%s %s must be a string, got %T", kind, key, value)
+    }
+    return s, nil
+}
+
+// configStringMap returns the map at key in the config of an executor of
+// type kind, with its values formatted as strings.
+func configStringMap(kind string, config map[string]interface{}, key string) (map[string]string, error) {
+    switch value := config[key].(type) {
+    case nil:
+        return nil, nil
+    case map[string]string:
+        return value, nil
+    case map[string]interface{}:
+        result := make(map[string]string, len(value))
+        for name, item := range value {
+            result[name] = fmt.Sprintf("%v", item)
+        }
+        return result, nil
+    default:
+        return nil, fmt.Errorf("%s %s must be a map, got %T", kind, key, value)
+    }
+}
+
+// configSeconds returns the duration given in seconds at key in the config
+// of an executor of type kind, or 0 if it is not set.
+func configSeconds(kind string, config map[string]interface{}, key string) (time.Duration, error) {
+    value, ok := config[key]
+    if !ok || value == nil {
+        return 0, nil
+    }
+    seconds, ok := toFloat(value)
+    if !ok || seconds < 0 {
+        return 0, fmt.Errorf("%s %s must be a non-negative number, got %v", kind, key, value)
+    }
+    return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -0,0 +1,212 @@
+package dagengine
+
+import (
+    "encoding/json"
+    "fmt"
+    "sort"
+    "strings"
+)
+
+// Graph export formats.
+const (
+    FormatDOT     = "dot"     // Graphviz DOT
+    FormatMermaid = "mermaid" // Mermaid flowchart
+    FormatJSON    = "json"    // Node and edge lists
+)
+
+// Graph is a renderable view of a workflow's nodes and dependencies, built
+// with NewGraph or DAGEngine.Graph.
+type Graph struct {
+    Name  string      `json:"name,omitempty"`
+    Nodes []GraphNode `json:"nodes"`
+    Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a node of a Graph.
+type GraphNode struct {
+    ID       string `json:"id"`
+    Executor string `json:"executor,omitempty"` // Executor type, e.g. "lua"
+    MapOver  string `json:"map_over,omitempty"` // Path of the list a map node fans out over
+    Status   string `json:"status,omitempty"`   // Status in an execution, set by SetStatuses
+}
+
+// GraphEdge is a dependency: To depends on From.
+type GraphEdge struct {
+    From string `json:"from"`
+    To   string `json:"to"`
+}
+
+// NewGraph builds a graph of nodes and the dependencies between them. Nodes
+// keep their order; edges follow it, in the order dependencies are listed.
+// Dependencies on unknown nodes are left out.
+func NewGraph(name string, nodes []GraphNode, deps map[string][]string) *Graph {
+    g := &Graph{Name: name, Nodes: append([]GraphNode{}, nodes...), Edges: []GraphEdge{}}
+
+    exists := make(map[string]bool, len(nodes))
+    for _, node := range nodes {
+        exists[node.ID] = true
+    }
+    for _, node := range nodes {
+        for _, dep := range deps[node.ID] {
+            if exists[dep] {
+                g.Edges = append(g.Edges, GraphEdge{From: dep, To: node.ID})
+            }
+        }
+    }
+    return g
+}
+
+// Graph returns the graph of the engine's nodes, sorted by ID. The executor
+// type is only known for executors that implement Describer.
+func (e *DAGEngine) Graph() *Graph {
+    ids := make([]string, 0, len(e.Nodes))
+    for id := range e.Nodes {
+        ids = append(ids, id)
+    }
+    sort.Strings(ids)
+
+    nodes := make([]GraphNode, 0, len(ids))
+    deps := make(map[string][]string, len(ids))
+    for _, id := range ids {
+        n := e.Nodes[id]
+        node := GraphNode{ID: id}
+        if describer, ok := n.Task.(Describer); ok {
+            node.Executor = describer.Describe().Type
+        }
+        if n.Map != nil {
+            node.MapOver = n.Map.Over
+        }
+        nodes = append(nodes, node)
+        deps[id] = n.Dependencies
+    }
+    return NewGraph("", nodes, deps)
+}
+
+// SetStatuses records the status of each node in an execution, e.g. from
+// RunResult.NodeStatuses. Renderers colour nodes by status.
+func (g *Graph) SetStatuses(statuses map[string]string) {
+    for i := range g.Nodes {
+        g.Nodes[i].Status = statuses[g.Nodes[i].ID]
+    }
+}
+
+// Export renders the graph in one of the Format* formats.
+func (g *Graph) Export(format string) ([]byte, error) {
+    switch format {
+    case FormatDOT:
+        return []byte(g.DOT()), nil
+    case FormatMermaid:
+        return []byte(g.Mermaid()), nil
+    case FormatJSON:
+        return json.MarshalIndent(g, "", "  ")
+    default:
+        return nil, fmt.Errorf("unsupported graph format '%s' (supported: dot, mermaid, json)", format)
+    }
+}
+
+// statusColors are the fill colours of nodes by status. Nodes without a
+// status are left white.
+var statusColors = map[string]string{
+    StatusPending:        "#ffffff",
+    StatusRunning:        "#89b4fa",
+    StatusCompleted:      "#a6e3a1",
+    StatusFailed:         "#f38ba8",
+    StatusTimedOut:       "#fab387",
+    StatusCancelled:      "#f9e2af",
+    StatusSkipped:        "#cdd6f4",
+    StatusUpstreamFailed: "#cdd6f4",
+}
+
+// label returns the text shown for a node.
+func (n GraphNode) label() []string {
+    lines := []string{n.ID}
+    if n.Executor != "" {
+        lines = append(lines, n.Executor)
+    }
+    if n.MapOver != "" {
+        lines = append(lines, "map over "+n.MapOver)
+    }
+    if n.Status != "" {
+        lines = append(lines, n.Status)
+    }
+    return lines
+}
+
+// DOT renders the graph in the Graphviz DOT language.
+func (g *Graph) DOT() string {
+    name := g.Name
+    if name == "" {
+        name = "workflow"
+    }
+
+    var b strings.Builder
+    fmt.Fprintf(&b, "digraph %s {\n", dotQuote(name))
+    b.WriteString("  rankdir=LR;\n")
+    b.WriteString("  node [shape=box, style=\"rounded,filled\", fillcolor=\"#ffffff\"];\n")
+    for _, node := range g.Nodes {
+        fmt.Fprintf(&b, "  %s [label=%s", dotQuote(node.ID), dotQuote(strings.Join(node.label(), "\n")))
+        if color, ok := statusColors[node.Status]; ok {
+            fmt.Fprintf(&b, ", fillcolor=%s", dotQuote(color))
+        }
+        b.WriteString("];\n")
+    }
+    for _, edge := range g.Edges {
+        fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(edge.From), dotQuote(edge.To))
+    }
+    b.WriteString("}\n")
+    return b.String()
+}
+
+// dotQuote returns s as a quoted DOT string.
+func dotQuote(s string) string {
+    s = strings.ReplaceAll(s, `\`, `\\`)
+    s = strings.ReplaceAll(s, `"`, `\"`)
+    s = strings.ReplaceAll(s, "\n", `\n`)
+    return `"` + s + `"`
+}
+
+// Mermaid renders the graph as a Mermaid flowchart. Nodes are named n0,
+// n1, ... in graph order so that any ID can be used as a label.
+func (g *Graph) Mermaid() string {
+    names := make(map[string]string, len(g.Nodes))
+    for i, node := range g.Nodes {
+        names[node.ID] = fmt.Sprintf("n%d", i)
+    }
+
+    var b strings.Builder
+    if g.Name != "" {
+        fmt.Fprintf(&b, "---\ntitle: %s\n---\n", g.Name)
+    }
+    b.WriteString("flowchart LR\n")
+    for _, node := range g.Nodes {
+        fmt.Fprintf(&b, "  %s[\"%s\"]\n", names[node.ID], mermaidEscape(strings.Join(node.label(), "<br/>")))
+    }
+    for _, edge := range g.Edges {
+        fmt.Fprintf(&b, "  %s --> %s\n", names[edge.From], names[edge.To])
+    }
+
+    // One class per status in use, in a stable order
+    var statuses []string
+    byStatus := make(map[string][]string)
+    for _, node := range g.Nodes {
+        if _, ok := statusColors[node.Status]; !ok {
+            continue
+        }
+        if byStatus[node.Status] == nil {
+            statuses = append(statuses, node.Status)
+        }
+        byStatus[node.Status] = append(byStatus[node.Status], names[node.ID])
+    }
+    sort.Strings(statuses)
+    for _, status := range statuses {
+        class := strings.ToLower(status)
+        fmt.Fprintf(&b, "  classDef %s fill:%s\n", class, statusColors[status])
+        fmt.Fprintf(&b, "  class %s %s\n", strings.Join(byStatus[status], ","), class)
+    }
+    return b.String()
+}
+
+// mermaidEscape replaces the characters that would end a quoted Mermaid label.
+func mermaidEscape(s string) string {
+    return strings.ReplaceAll(s, `"`, "#quot;")
+}
@@ -0,0 +1,100 @@
+package dagengine
+
+import (
+    "context"
+    "encoding/json"
+    "strings"
+    "testing"
+)
+
+func newExportEngine() *DAGEngine {
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("extract", nil, &LuaExecutor{Code: `return {files = {"a", "b"}}`}))
+    engine.AddNode(NewNode("transform", []string{"extract"}, &LuaExecutor{Code: "return {}"}))
+    engine.AddNode(NewNode("load", []string{"extract", "transform"}, &recordingExecutor{}))
+    engine.Nodes["transform"].Map = &MapConfig{Over: "extract.files"}
+    return engine
+}
+
+func TestGraphExport(t *testing.T) {
+    graph := newExportEngine().Graph()
+    graph.Name = "nightly"
+    graph.SetStatuses(map[string]string{"extract": StatusCompleted, "transform": StatusFailed, "load": StatusUpstreamFailed})
+
+    dot := graph.DOT()
+    for _, want := range []string{
+        `digraph "nightly" {`,
+        `"extract" [label="extract\nlua\nCOMPLETED", fillcolor="#a6e3a1"];`,
+        `"transform" [label="transform\nlua\nmap over extract.files\nFAILED", fillcolor="#f38ba8"];`,
+        `"load" [label="load\nUPSTREAM_FAILED", fillcolor="#cdd6f4"];`,
+        `"extract" -> "load";`,
+        `"transform" -> "load";`,
+        `"extract" -> "transform";`,
+    } {
+        if !strings.Contains(dot, want) {
+            t.Errorf("Expected DOT output to contain %q, got:\n%s", want, dot)
+        }
+    }
+
+    mermaid := graph.Mermaid()
+    for _, want := range []string{
+        "flowchart LR",
+        `n0["extract<br/>lua<br/>COMPLETED"]`,
+        `n1["load<br/>UPSTREAM_FAILED"]`,
+        "n0 --> n1",
+        "n2 --> n1",
+        "n0 --> n2",
+        "class n0 completed",
+        "classDef failed fill:#f38ba8",
+    } {
+        if !strings.Contains(mermaid, want) {
+            t.Errorf("Expected Mermaid output to contain %q, got:\n%s", want, mermaid)
+        }
+    }
+
+    data, err := graph.Export(FormatJSON)
+    if err != nil {
+        t.Fatalf("Export failed: %v", err)
+    }
+    var decoded Graph
+    if err := json.Unmarshal(data, &decoded); err != nil {
+        t.Fatalf("Failed to decode JSON export: %v", err)
+    }
+    if len(decoded.Nodes) != 3 || len(decoded.Edges) != 3 || decoded.Nodes[2].MapOver != "extract.files" {
+        t.Errorf("Expected 3 nodes and 3 edges, got %+v", decoded)
+    }
+
+    if _, err := graph.Export("png"); err == nil {
+        t.Error("Expected an error for an unsupported format")
+    }
+}
+
+func TestGraphStatusesFromRun(t *testing.T) {
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("A", nil, &recordingExecutor{}))
+    engine.AddNode(NewNode("B", []string{"A"}, &recordingExecutor{}))
+
+    result, err := engine.Run(context.Background(), nil)
+    if err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+
+    graph := engine.Graph()
+    graph.SetStatuses(result.NodeStatuses)
+    for _, node := range graph.Nodes {
+        if node.Status != StatusCompleted {
+            t.Errorf("Expected %s to be COMPLETED, got %s", node.ID, node.Status)
+        }
+    }
+}
+
+func TestGraphEscapesLabels(t *testing.T) {
+    graph := NewGraph(`say "hi"`, []GraphNode{{ID: `a"b`}}, nil)
+
+    if dot := graph.DOT(); !strings.Contains(dot, `"a\"b" [label="a\"b"]`) {
+        t.Errorf("Expected quotes to be escaped in DOT, got:\n%s", dot)
+    }
+    if mermaid := graph.Mermaid(); !strings.Contains(mermaid, `n0["a#quot;b"]`) {
+        t.Errorf("Expected quotes to be escaped in Mermaid, got:\n%s", mermaid)
+    }
+}
@@ -0,0 +1,207 @@
+package dagengine
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+)
+
+// GraphError reports every structural problem found while validating a DAG.
+type GraphError struct {
+    MissingDependencies   map[string][]string // node ID -> dependency IDs that do not exist
+    SelfDependencies      []string            // nodes that depend on themselves
+    DuplicateDependencies map[string][]string // node ID -> dependency IDs listed more than once
+    Cycles                [][]string          // each cycle as a path, e.g. [A B C A]
+    Unreachable           []string            // nodes that can never run because an ancestor is in a cycle
+}
+
+// Error renders all problems as a single message.
+func (g *GraphError) Error() string {
+    var problems []string
+
+    for _, cycle := range g.Cycles {
+        problems = append(problems, fmt.Sprintf("cycle detected: %s", strings.Join(cycle, " -> ")))
+    }
+    for _, id := range g.SelfDependencies {
+        problems = append(problems, fmt.Sprintf("self-dependency: node '%s' depends on itself", id))
+    }
+    for _, id := range sortedKeys(g.DuplicateDependencies) {
+        for _, dep := range g.DuplicateDependencies[id] {
+            problems = append(problems, fmt.Sprintf("duplicate dependency: node '%s' lists '%s' more than once", id, dep))
+        }
+    }
+    for _, id := range sortedKeys(g.MissingDependencies) {
+        for _, dep := range g.MissingDependencies[id] {
+            problems = append(problems, fmt.Sprintf("dependency error: node '%s' depends on non-existent node '%s'", id, dep))
+        }
+    }
+    if len(g.Unreachable) > 0 {
+        problems = append(problems, fmt.Sprintf("unreachable nodes: %s", strings.Join(g.Unreachable, ", ")))
+    }
+
+    return fmt.Sprintf("invalid DAG: %s", strings.Join(problems, "; "))
+}
+
+// hasProblems reports whether any problem was recorded.
+func (g *GraphError) hasProblems() bool {
+    return len(g.MissingDependencies) > 0 || len(g.SelfDependencies) > 0 ||
+        len(g.DuplicateDependencies) > 0 || len(g.Cycles) > 0 || len(g.Unreachable) > 0
+}
+
+// ValidateGraph runs a topological validation pass over a dependency graph.
+// ids lists the nodes in a stable order (used for deterministic reporting) and
+// deps maps each node ID to the IDs it depends on. It returns a *GraphError
+// describing every problem found, or nil if the graph is a valid DAG.
+func ValidateGraph(ids []string, deps map[string][]string) error {
+    graphErr := &GraphError{
+        MissingDependencies:   make(map[string][]string),
+        DuplicateDependencies: make(map[string][]string),
+    }
+
+    exists := make(map[string]bool, len(ids))
+    for _, id := range ids {
+        exists[id] = true
+    }
+
+    // 1. Per-node checks and construction of the adjacency list
+    children := make(map[string][]string, len(ids))
+    pending := make(map[string]int, len(ids))
+    for _, id := range ids {
+        seen := make(map[string]bool)
+        for _, dep := range deps[id] {
+            switch {
+            case dep == id:
+                if !seen[dep] {
+                    graphErr.SelfDependencies = append(graphErr.SelfDependencies, id)
+                }
+            case !exists[dep]:
+                if !seen[dep] {
+                    graphErr.MissingDependencies[id] = append(graphErr.MissingDependencies[id], dep)
+                }
+            case seen[dep]:
+                if !containsString(graphErr.DuplicateDependencies[id], dep) {
+                    graphErr.DuplicateDependencies[id] = append(graphErr.DuplicateDependencies[id], dep)
+                }
+            default:
+                children[dep] = append(children[dep], id)
+                pending[id]++
+            }
+            seen[dep] = true
+        }
+    }
+
+    // A self-dependency can never be fulfilled, so it blocks the node like a cycle would
+    blocked := make(map[string]bool)
+    for _, id := range graphErr.SelfDependencies {
+        blocked[id] = true
+    }
+
+    // 2. Kahn's algorithm: anything left unvisited is in, or downstream of, a cycle
+    visited := make(map[string]bool, len(ids))
+    var queue []string
+    for _, id := range ids {
+        if pending[id] == 0 && !blocked[id] {
+            queue = append(queue, id)
+        }
+    }
+    for len(queue) > 0 {
+        id := queue[0]
+        queue = queue[1:]
+        visited[id] = true
+        for _, childID := range children[id] {
+            pending[childID]--
+            if pending[childID] == 0 && !blocked[childID] {
+                queue = append(queue, childID)
+            }
+        }
+    }
+
+    var stuck []string
+    for _, id := range ids {
+        if !visited[id] {
+            stuck = append(stuck, id)
+        }
+    }
+
+    // 3. Extract the exact cycle paths among the stuck nodes
+    if len(stuck) > 0 {
+        graphErr.Cycles = findCycles(stuck, children, visited)
+
+        inCycle := make(map[string]bool)
+        for _, cycle := range graphErr.Cycles {
+            for _, id := range cycle {
+                inCycle[id] = true
+            }
+        }
+        for _, id := range stuck {
+            if !inCycle[id] && !blocked[id] {
+                graphErr.Unreachable = append(graphErr.Unreachable, id)
+            }
+        }
+    }
+
+    if graphErr.hasProblems() {
+        return graphErr
+    }
+    return nil
+}
+
+// findCycles performs a depth-first search over the stuck nodes and returns
+// one cycle path per back edge found.
+func findCycles(stuck []string, children map[string][]string, visited map[string]bool) [][]string {
+    const (
+        white = iota
+        grey
+        black
+    )
+
+    color := make(map[string]int, len(stuck))
+    var path []string
+    var cycles [][]string
+
+    var visit func(id string)
+    visit = func(id string) {
+        color[id] = grey
+        path = append(path, id)
+
+        for _, childID := range children[id] {
+            if visited[childID] {
+                continue
+            }
+            switch color[childID] {
+            case white:
+                visit(childID)
+            case grey:
+                // Back edge: the cycle is the path from childID to here
+                for i := len(path) - 1; i >= 0; i-- {
+                    if path[i] == childID {
+                        cycle := append([]string{}, path[i:]...)
+                        cycles = append(cycles, append(cycle, childID))
+                        break
+                    }
+                }
+            }
+        }
+
+        path = path[:len(path)-1]
+        color[id] = black
+    }
+
+    for _, id := range stuck {
+        if color[id] == white {
+            visit(id)
+        }
+    }
+
+    return cycles
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string][]string) []string {
+    keys := make([]string, 0, len(m))
+    for k := range m {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    return keys
+}
@@ -0,0 +1,98 @@
+package dagengine
+
+import (
+    "errors"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestValidateGraph(t *testing.T) {
+    tests := []struct {
+        name string
+        ids  []string
+        deps map[string][]string
+        want *GraphError
+    }{
+        {
+            name: "valid diamond",
+            ids:  []string{"A", "B", "C", "D"},
+            deps: map[string][]string{"B": {"A"}, "C": {"A"}, "D": {"B", "C"}},
+            want: nil,
+        },
+        {
+            name: "cycle with downstream node",
+            ids:  []string{"A", "B", "C", "D", "E"},
+            deps: map[string][]string{"B": {"A", "D"}, "C": {"B"}, "D": {"C"}, "E": {"D"}},
+            want: &GraphError{
+                Cycles:      [][]string{{"B", "C", "D", "B"}},
+                Unreachable: []string{"E"},
+            },
+        },
+        {
+            name: "self dependency blocks descendants",
+            ids:  []string{"A", "B"},
+            deps: map[string][]string{"A": {"A"}, "B": {"A"}},
+            want: &GraphError{
+                SelfDependencies: []string{"A"},
+                Unreachable:      []string{"B"},
+            },
+        },
+        {
+            name: "duplicate and missing dependencies",
+            ids:  []string{"A", "B"},
+            deps: map[string][]string{"B": {"A", "A", "Z"}},
+            want: &GraphError{
+                DuplicateDependencies: map[string][]string{"B": {"A"}},
+                MissingDependencies:   map[string][]string{"B": {"Z"}},
+            },
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := ValidateGraph(tt.ids, tt.deps)
+            if tt.want == nil {
+                if err != nil {
+                    t.Fatalf("Unexpected error: %v", err)
+                }
+                return
+            }
+
+            var graphErr *GraphError
+            if !errors.As(err, &graphErr) {
+                t.Fatalf("Expected *GraphError, got %v", err)
+            }
+            if !reflect.DeepEqual(graphErr.Cycles, tt.want.Cycles) {
+                t.Errorf("Expected cycles %v, got %v", tt.want.Cycles, graphErr.Cycles)
+            }
+            if !reflect.DeepEqual(graphErr.Unreachable, tt.want.Unreachable) {
+                t.Errorf("Expected unreachable %v, got %v", tt.want.Unreachable, graphErr.Unreachable)
+            }
+            if !reflect.DeepEqual(graphErr.SelfDependencies, tt.want.SelfDependencies) {
+                t.Errorf("Expected self-dependencies %v, got %v", tt.want.SelfDependencies, graphErr.SelfDependencies)
+            }
+            if len(tt.want.DuplicateDependencies) > 0 && !reflect.DeepEqual(graphErr.DuplicateDependencies, tt.want.DuplicateDependencies) {
+                t.Errorf("Expected duplicates %v, got %v", tt.want.DuplicateDependencies, graphErr.DuplicateDependencies)
+            }
+            if len(tt.want.MissingDependencies) > 0 && !reflect.DeepEqual(graphErr.MissingDependencies, tt.want.MissingDependencies) {
+                t.Errorf("Expected missing %v, got %v", tt.want.MissingDependencies, graphErr.MissingDependencies)
+            }
+        })
+    }
+}
+
+func TestPreprocessDAGReportsCyclePath(t *testing.T) {
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("A", []string{"C"}, &recordingExecutor{}))
+    engine.AddNode(NewNode("B", []string{"A"}, &recordingExecutor{}))
+    engine.AddNode(NewNode("C", []string{"B"}, &recordingExecutor{}))
+
+    err := engine.PreprocessDAG()
+    if err == nil {
+        t.Fatal("Expected cycle error, got none")
+    }
+    if !strings.Contains(err.Error(), "cycle detected: A -> B -> C -> A") {
+        t.Errorf("Expected cycle path in error, got: %v", err)
+    }
+}
@@ -0,0 +1,515 @@
+package dagengine
+
+import (
+    "context"
+    "crypto/tls"
+    "encoding/json"
+    "fmt"
+    "os"
+    "strings"
+    "sync"
+    "time"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/credentials"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/keepalive"
+    "google.golang.org/grpc/metadata"
+    reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/encoding/protojson"
+    "google.golang.org/protobuf/proto"
+    "google.golang.org/protobuf/reflect/protodesc"
+    "google.golang.org/protobuf/reflect/protoreflect"
+    "google.golang.org/protobuf/types/descriptorpb"
+    "google.golang.org/protobuf/types/dynamicpb"
+)
+
+// DefaultGRPCTimeout bounds the calls of GRPCExecutors without a Timeout.
+const DefaultGRPCTimeout = 30 * time.Second
+
+// GRPCExecutor implements the Executor interface for calls to unary gRPC
+// methods of services whose Go code is not compiled in. The method's
+// descriptors come from a FileDescriptorSet file (protoc --include_imports
+// --descriptor_set_out) or, without one, from the server's reflection
+// service.
+//
+// The request is built from JSON: the node's inputs, whose fields that are
+// not in the request message are ignored (use input mappings to shape them),
+// or the Request template (see HTTPExecutor for the placeholders). The
+// response becomes the node's result, with the field names of the .proto.
+type GRPCExecutor struct {
+    Address       string            // host:port of the server
+    Method        string            // Full method name: package.Service/Method
+    DescriptorSet string            // FileDescriptorSet file; empty uses server reflection
+    Request       interface{}       // Template of the request; default: the node's inputs
+    Metadata      map[string]string // Request metadata; values are templates
+    TLS           bool              // Use TLS with the system's roots instead of plaintext
+    Timeout       time.Duration     // Bound on the call (default: DefaultGRPCTimeout)
+    DialOptions   []grpc.DialOption // Replace the options derived from TLS; see Close
+
+    // Secret resolves the secret placeholders; without it they fail.
+    Secret func(ctx context.Context, name, key string) (string, error)
+
+    mu     sync.Mutex
+    conn   *grpc.ClientConn              // Own connection, with DialOptions
+    method protoreflect.MethodDescriptor // Resolved on the first call
+}
+
+// grpcConnKey identifies the connections that executors without DialOptions
+// share.
+type grpcConnKey struct {
+    address string
+    tls     bool
+}
+
+// grpcConns holds the process's shared connections. They live as long as
+// the process, like the executors' servers, and release their transports
+// when idle.
+var grpcConns = struct {
+    mu    sync.Mutex
+    conns map[grpcConnKey]*grpc.ClientConn
+}{conns: make(map[grpcConnKey]*grpc.ClientConn)}
+
+// NewGRPCExecutor creates a GRPCExecutor from a node's executor
+// configuration:
+//
+//	config:
+//	  address: users.internal:9090
+//	  method: users.v1.UserService/GetUser
+//	  descriptor_set: /etc/protos/users.pb  # optional: server reflection
+//	  request:
+//	    id: "{{ workflow.user_id }}"
+//	  metadata:
+//	    authorization: "Bearer {{ secret:api-credentials/token }}"
+//	  tls: true
+//	  timeout_seconds: 5
+func NewGRPCExecutor(config map[string]interface{}) (*GRPCExecutor, error) {
+    executor := &GRPCExecutor{Request: config["request"]}
+
+    var err error
+    if executor.Address, err = configString("grpc", config, "address"); err != nil {
+        return nil, err
+    }
+    if executor.Method, err = configString("grpc", config, "method"); err != nil {
+        return nil, err
+    }
+    if executor.DescriptorSet, err = configString("grpc", config, "descriptor_set"); err != nil {
+        return nil, err
+    }
+    if executor.Metadata, err = configStringMap("grpc", config, "metadata"); err != nil {
+        return nil, err
+    }
+    if useTLS, ok := config["tls"]; ok {
+        if executor.TLS, ok = useTLS.(bool); !ok {
+            return nil, fmt.Errorf("grpc tls must be a boolean, got %T", useTLS)
+        }
+    }
+    if executor.Timeout, err = configSeconds("grpc", config, "timeout_seconds"); err != nil {
+        return nil, err
+    }
+
+    if err := executor.Validate(); err != nil {
+        return nil, err
+    }
+    return executor, nil
+}
+
+// grpcExecutorType registers GRPCExecutor as the "grpc" type. Its templates
+// read the workflow's secrets.
+func grpcExecutorType() ExecutorType {
+    return ExecutorType{
+        Name: "grpc",
+        New: func(spec ExecutorSpec, host *ExecutorHost) (Executor, error) {
+            executor, err := NewGRPCExecutor(spec.Config)
+            if err != nil {
+                return nil, err
+            }
+            executor.Secret = host.Secret
+            return executor, nil
+        },
+        Schema: ExecutorSchema{
+            Config: map[string]ConfigField{
+                "address":         {Kind: ConfigString, Required: true, Description: "host:port of the server"},
+                "method":          {Kind: ConfigString, Required: true, Description: "Full method name: package.Service/Method"},
+                "descriptor_set":  {Kind: ConfigString, Description: "FileDescriptorSet file (default: server reflection)"},
+                "request":         {Kind: ConfigAny, Description: "Request template (default: the node's inputs)"},
+                "metadata":        {Kind: ConfigMap, Description: "Request metadata templates"},
+                "tls":             {Kind: ConfigBool, Description: "Use TLS instead of plaintext"},
+                "timeout_seconds": {Kind: ConfigNumber, Description: "Bound on the call (default: 30)"},
+            },
+        },
+        Validate: func(spec ExecutorSpec) error {
+            _, err := NewGRPCExecutor(spec.Config)
+            return err
+        },
+    }
+}
+
+// Validate checks the executor's configuration.
+func (g *GRPCExecutor) Validate() error {
+    if g.Address == "" {
+        return fmt.Errorf("grpc address is required")
+    }
+    if _, _, err := splitGRPCMethod(g.Method); err != nil {
+        return err
+    }
+    if g.Timeout < 0 {
+        return fmt.Errorf("grpc timeout must not be negative")
+    }
+    return nil
+}
+
+// splitGRPCMethod splits "package.Service/Method" (or "package.Service.Method")
+// into the full service name and the method name.
+func splitGRPCMethod(method string) (protoreflect.FullName, protoreflect.Name, error) {
+    method = strings.TrimPrefix(method, "/")
+    i := strings.LastIndex(method, "/")
+    if i < 0 {
+        i = strings.LastIndex(method, ".")
+    }
+    if i <= 0 || i == len(method)-1 {
+        return "", "", fmt.Errorf("grpc method must be package.Service/Method, got '%s'", method)
+    }
+    service, name := protoreflect.FullName(method[:i]), protoreflect.Name(method[i+1:])
+    if !service.IsValid() || !name.IsValid() {
+        return "", "", fmt.Errorf("invalid grpc method '%s'", method)
+    }
+    return service, name, nil
+}
+
+// Describe identifies the call for result caching.
+func (g *GRPCExecutor) Describe() ExecutorDescription {
+    return ExecutorDescription{
+        Type: "grpc",
+        Config: map[string]interface{}{
+            "address":        g.Address,
+            "method":         g.Method,
+            "descriptor_set": g.DescriptorSet,
+            "request":        g.Request,
+            "metadata":       g.Metadata,
+        },
+    }
+}
+
+// dialOptions returns the options used to connect to the server: plaintext
+// (or TLS) with the keepalive settings of the engines' transport.
+func (g *GRPCExecutor) dialOptions() []grpc.DialOption {
+    if g.DialOptions != nil {
+        return g.DialOptions
+    }
+    creds := insecure.NewCredentials()
+    if g.TLS {
+        creds = credentials.NewTLS(&tls.Config{})
+    }
+    return []grpc.DialOption{
+        grpc.WithTransportCredentials(creds),
+        grpc.WithKeepaliveParams(keepalive.ClientParameters{
+            Time:                10 * time.Second,
+            Timeout:             3 * time.Second,
+            PermitWithoutStream: true,
+        }),
+    }
+}
+
+// Execute calls the method, over a connection that the executor's calls
+// share.
+//
+// Errors with the codes Unavailable, ResourceExhausted, Aborted and
+// DeadlineExceeded are retried according to the node's retry policy; other
+// failures are permanent.
+func (g *GRPCExecutor) Execute(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+    conn, err := g.connect()
+    if err != nil {
+        return nil, err
+    }
+
+    timeout := g.Timeout
+    if timeout == 0 {
+        timeout = DefaultGRPCTimeout
+    }
+    callCtx, cancel := context.WithTimeout(ctx, timeout)
+    defer cancel()
+
+    method, err := g.resolve(callCtx, conn)
+    if err != nil {
+        return nil, grpcError("grpc method", err)
+    }
+
+    t := &inputTemplate{ctx: ctx, inputs: inputs, secret: g.Secret}
+    request, err := g.request(t, method.Input())
+    if err != nil {
+        return nil, Permanent(fmt.Errorf("grpc request: %w", err))
+    }
+    for key, value := range g.Metadata {
+        expanded, err := t.expand(value, nil)
+        if err != nil {
+            return nil, Permanent(fmt.Errorf("grpc metadata %s: %w", key, err))
+        }
+        callCtx = metadata.AppendToOutgoingContext(callCtx, key, expanded)
+    }
+
+    response := dynamicpb.NewMessage(method.Output())
+    fullMethod := fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+    if err := conn.Invoke(callCtx, fullMethod, request, response); err != nil {
+        if ctx.Err() != nil {
+            return nil, fmt.Errorf("grpc call interrupted: %w", ctx.Err())
+        }
+        return nil, grpcError("grpc call "+fullMethod, err)
+    }
+    return grpcResult(response)
+}
+
+// connect returns the connection to the server: the process's shared one
+// for the address and TLS setting or, with DialOptions, the executor's own,
+// created on the first call. Connections do not block on the server: they
+// connect in the background, reconnect as needed and go idle between bursts
+// of calls.
+func (g *GRPCExecutor) connect() (*grpc.ClientConn, error) {
+    if g.DialOptions != nil {
+        g.mu.Lock()
+        defer g.mu.Unlock()
+        if g.conn == nil {
+            conn, err := g.dial()
+            if err != nil {
+                return nil, err
+            }
+            g.conn = conn
+        }
+        return g.conn, nil
+    }
+
+    grpcConns.mu.Lock()
+    defer grpcConns.mu.Unlock()
+    key := grpcConnKey{address: g.Address, tls: g.TLS}
+    conn, ok := grpcConns.conns[key]
+    if !ok {
+        var err error
+        if conn, err = g.dial(); err != nil {
+            return nil, err
+        }
+        grpcConns.conns[key] = conn
+    }
+    return conn, nil
+}
+
+// dial creates a connection to the server.
+func (g *GRPCExecutor) dial() (*grpc.ClientConn, error) {
+    conn, err := grpc.Dial(g.Address, g.dialOptions()...)
+    if err != nil {
+        return nil, Permanent(fmt.Errorf("grpc dial %s: %w", g.Address, err))
+    }
+    return conn, nil
+}
+
+// Close closes the connection of an executor with DialOptions, which is not
+// shared. Executors without DialOptions use shared connections and need not
+// be closed.
+func (g *GRPCExecutor) Close() error {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    if g.conn == nil {
+        return nil
+    }
+    err := g.conn.Close()
+    g.conn = nil
+    return err
+}
+
+// resolve returns the descriptor of the method, loading it on the first
+// call. Loading happens outside the lock, so that calls are not serialized
+// behind a slow reflection request; concurrent first calls may each load it.
+func (g *GRPCExecutor) resolve(ctx context.Context, conn *grpc.ClientConn) (protoreflect.MethodDescriptor, error) {
+    g.mu.Lock()
+    method := g.method
+    g.mu.Unlock()
+    if method != nil {
+        return method, nil
+    }
+
+    method, err := g.loadMethod(ctx, conn)
+    if err != nil {
+        return nil, err
+    }
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    if g.method == nil {
+        g.method = method
+    }
+    return g.method, nil
+}
+
+// loadMethod reads the descriptor of the method from DescriptorSet or the
+// server's reflection service.
+func (g *GRPCExecutor) loadMethod(ctx context.Context, conn *grpc.ClientConn) (protoreflect.MethodDescriptor, error) {
+    serviceName, methodName, err := splitGRPCMethod(g.Method)
+    if err != nil {
+        return nil, Permanent(err)
+    }
+    var files *descriptorpb.FileDescriptorSet
+    if g.DescriptorSet != "" {
+        files, err = readDescriptorSet(g.DescriptorSet)
+    } else {
+        files, err = reflectDescriptors(ctx, conn, string(serviceName))
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    registry, err := protodesc.NewFiles(files)
+    if err != nil {
+        return nil, Permanent(fmt.Errorf("invalid descriptors: %w", err))
+    }
+    descriptor, err := registry.FindDescriptorByName(serviceName)
+    if err != nil {
+        return nil, Permanent(fmt.Errorf("service %s: %w", serviceName, err))
+    }
+    service, ok := descriptor.(protoreflect.ServiceDescriptor)
+    if !ok {
+        return nil, Permanent(fmt.Errorf("%s is not a service", serviceName))
+    }
+    method := service.Methods().ByName(methodName)
+    if method == nil {
+        return nil, Permanent(fmt.Errorf("service %s has no method %s", serviceName, methodName))
+    }
+    if method.IsStreamingClient() || method.IsStreamingServer() {
+        return nil, Permanent(fmt.Errorf("method %s is not unary", method.FullName()))
+    }
+    return method, nil
+}
+
+// request builds the request message from the Request template, or from the
+// inputs when there is none.
+func (g *GRPCExecutor) request(t *inputTemplate, descriptor protoreflect.MessageDescriptor) (*dynamicpb.Message, error) {
+    var value interface{} = t.inputs
+    if g.Request != nil {
+        expanded, err := t.expandValue(g.Request)
+        if err != nil {
+            return nil, err
+        }
+        value = expanded
+    }
+    if value == nil {
+        value = map[string]interface{}{}
+    }
+    data, err := json.Marshal(value)
+    if err != nil {
+        return nil, err
+    }
+
+    request := dynamicpb.NewMessage(descriptor)
+    if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, request); err != nil {
+        return nil, err
+    }
+    return request, nil
+}
+
+// grpcResult converts a response message to a node result.
+func grpcResult(response proto.Message) (map[string]interface{}, error) {
+    data, err := (protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}).Marshal(response)
+    if err != nil {
+        return nil, fmt.Errorf("grpc response: %w", err)
+    }
+    var result map[string]interface{}
+    if err := json.Unmarshal(data, &result); err != nil {
+        return nil, fmt.Errorf("grpc response: %w", err)
+    }
+    return result, nil
+}
+
+// grpcError wraps err, marking it permanent unless its status code means the
+// call may succeed later.
+func grpcError(prefix string, err error) error {
+    if IsPermanent(err) {
+        return fmt.Errorf("%s: %w", prefix, err)
+    }
+    switch status.Code(err) {
+    case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.DeadlineExceeded:
+        return fmt.Errorf("%s: %w", prefix, err)
+    }
+    return Permanent(fmt.Errorf("%s: %w", prefix, err))
+}
+
+// readDescriptorSet reads a FileDescriptorSet file.
+func readDescriptorSet(path string) (*descriptorpb.FileDescriptorSet, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, Permanent(fmt.Errorf("descriptor set: %w", err))
+    }
+    files := &descriptorpb.FileDescriptorSet{}
+    if err := proto.Unmarshal(data, files); err != nil {
+        return nil, Permanent(fmt.Errorf("descriptor set %s: %w", path, err))
+    }
+    return files, nil
+}
+
+// reflectDescriptors asks the server's reflection service for the file that
+// defines symbol and the files it depends on.
+func reflectDescriptors(ctx context.Context, conn *grpc.ClientConn, symbol string) (*descriptorpb.FileDescriptorSet, error) {
+    stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("server reflection: %w", err)
+    }
+    defer stream.CloseSend()
+
+    files := &descriptorpb.FileDescriptorSet{}
+    seen := make(map[string]bool)
+    // add records the files of a response and returns the dependencies not
+    // seen yet
+    add := func(resp *reflectionpb.ServerReflectionResponse) ([]string, error) {
+        if errResp := resp.GetErrorResponse(); errResp != nil {
+            return nil, status.Error(codes.Code(errResp.ErrorCode), errResp.ErrorMessage)
+        }
+        var missing []string
+        for _, data := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+            file := &descriptorpb.FileDescriptorProto{}
+            if err := proto.Unmarshal(data, file); err != nil {
+                return nil, err
+            }
+            if seen[file.GetName()] {
+                continue
+            }
+            seen[file.GetName()] = true
+            files.File = append(files.File, file)
+            missing = append(missing, file.GetDependency()...)
+        }
+        return missing, nil
+    }
+    send := func(req *reflectionpb.ServerReflectionRequest) (*reflectionpb.ServerReflectionResponse, error) {
+        if err := stream.Send(req); err != nil {
+            return nil, err
+        }
+        return stream.Recv()
+    }
+
+    resp, err := send(&reflectionpb.ServerReflectionRequest{
+        MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+    })
+    if err != nil {
+        return nil, fmt.Errorf("server reflection: %w", err)
+    }
+    pending, err := add(resp)
+    if err != nil {
+        return nil, fmt.Errorf("server reflection of %s: %w", symbol, err)
+    }
+    for len(pending) > 0 {
+        name := pending[0]
+        pending = pending[1:]
+        if seen[name] {
+            continue
+        }
+        resp, err := send(&reflectionpb.ServerReflectionRequest{
+            MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+        })
+        if err != nil {
+            return nil, fmt.Errorf("server reflection: %w", err)
+        }
+        missing, err := add(resp)
+        if err != nil {
+            return nil, fmt.Errorf("server reflection of %s: %w", name, err)
+        }
+        pending = append(pending, missing...)
+    }
+    return files, nil
+}
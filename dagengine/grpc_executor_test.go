@@ -0,0 +1,221 @@
+package dagengine
+
+import (
+    "context"
+    "net"
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/health"
+    healthpb "google.golang.org/grpc/health/grpc_health_v1"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/reflection"
+    "google.golang.org/protobuf/proto"
+    "google.golang.org/protobuf/reflect/protodesc"
+    "google.golang.org/protobuf/types/descriptorpb"
+)
+
+// healthServer serves the gRPC health service on a local port, with server
+// reflection if withReflection is set. It records the x-trace metadata it receives.
+type healthServer struct {
+    address string
+    mu      sync.Mutex
+    traces  []string
+}
+
+func startHealthServer(t *testing.T, withReflection bool) *healthServer {
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatalf("Listen failed: %v", err)
+    }
+    hs := &healthServer{address: listener.Addr().String()}
+    server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+        md, _ := metadata.FromIncomingContext(ctx)
+        hs.mu.Lock()
+        hs.traces = append(hs.traces, md.Get("x-trace")...)
+        hs.mu.Unlock()
+        return handler(ctx, req)
+    }))
+    checker := health.NewServer()
+    checker.SetServingStatus("users", healthpb.HealthCheckResponse_NOT_SERVING)
+    healthpb.RegisterHealthServer(server, checker)
+    if withReflection {
+        reflection.Register(server)
+    }
+    go server.Serve(listener)
+    t.Cleanup(server.Stop)
+    return hs
+}
+
+func TestGRPCExecutor(t *testing.T) {
+    reflecting := startHealthServer(t, true)
+    plain := startHealthServer(t, false)
+
+    // A descriptor set with the health service, as protoc would write it
+    set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+        protodesc.ToFileDescriptorProto(healthpb.File_grpc_health_v1_health_proto),
+    }}
+    data, err := proto.Marshal(set)
+    if err != nil {
+        t.Fatalf("Marshal failed: %v", err)
+    }
+    descriptorSet := filepath.Join(t.TempDir(), "health.pb")
+    if err := os.WriteFile(descriptorSet, data, 0644); err != nil {
+        t.Fatalf("WriteFile failed: %v", err)
+    }
+
+    inputs := map[string]interface{}{
+        WorkflowInputsKey: map[string]interface{}{"service": "users", "trace": "t-1"},
+        "service":         "",
+    }
+
+    tests := []struct {
+        name     string
+        executor *GRPCExecutor
+        want     map[string]interface{}
+    }{
+        {
+            name:     "server reflection with inputs as request",
+            executor: &GRPCExecutor{Address: reflecting.address, Method: "grpc.health.v1.Health/Check"},
+            want:     map[string]interface{}{"status": "SERVING"},
+        },
+        {
+            name: "request template and metadata",
+            executor: &GRPCExecutor{
+                Address:  reflecting.address,
+                Method:   "/grpc.health.v1.Health/Check",
+                Request:  map[string]interface{}{"service": "{{ workflow.service }}"},
+                Metadata: map[string]string{"x-trace": "{{ workflow.trace }}"},
+            },
+            want: map[string]interface{}{"status": "NOT_SERVING"},
+        },
+        {
+            name:     "descriptor set",
+            executor: &GRPCExecutor{Address: plain.address, Method: "grpc.health.v1.Health.Check", DescriptorSet: descriptorSet},
+            want:     map[string]interface{}{"status": "SERVING"},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            // Twice, the second time with the cached descriptors
+            for i := 0; i < 2; i++ {
+                got, err := tt.executor.Execute(context.Background(), inputs)
+                if err != nil {
+                    t.Fatalf("Execute failed: %v", err)
+                }
+                if !reflect.DeepEqual(got, tt.want) {
+                    t.Errorf("Expected %v, got %v", tt.want, got)
+                }
+            }
+        })
+    }
+
+    reflecting.mu.Lock()
+    defer reflecting.mu.Unlock()
+    if !reflect.DeepEqual(reflecting.traces, []string{"t-1", "t-1"}) {
+        t.Errorf("Expected the x-trace metadata twice, got %v", reflecting.traces)
+    }
+}
+
+func TestGRPCExecutorConnections(t *testing.T) {
+    server := startHealthServer(t, true)
+
+    // Executors of the same server share a connection
+    shared, err := (&GRPCExecutor{Address: server.address}).connect()
+    if err != nil {
+        t.Fatalf("connect failed: %v", err)
+    }
+    if conn, _ := (&GRPCExecutor{Address: server.address}).connect(); conn != shared {
+        t.Error("Expected the executors to share a connection")
+    }
+
+    // With DialOptions, an executor has its own connection until it is closed
+    executor := &GRPCExecutor{
+        Address:     server.address,
+        Method:      "grpc.health.v1.Health/Check",
+        DialOptions: []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+    }
+    if _, err := executor.Execute(context.Background(), map[string]interface{}{}); err != nil {
+        t.Fatalf("Execute failed: %v", err)
+    }
+    if executor.conn == nil || executor.conn == shared {
+        t.Error("Expected the executor to have its own connection")
+    }
+    if err := executor.Close(); err != nil || executor.conn != nil {
+        t.Errorf("Expected Close to release the connection, got %v", err)
+    }
+}
+
+func TestGRPCExecutorErrors(t *testing.T) {
+    reflecting := startHealthServer(t, true)
+    plain := startHealthServer(t, false)
+
+    tests := []struct {
+        name      string
+        executor  *GRPCExecutor
+        want      string
+        permanent bool
+    }{
+        {"error status", &GRPCExecutor{Address: reflecting.address, Method: "grpc.health.v1.Health/Check", Request: map[string]interface{}{"service": "orders"}}, "NotFound", true},
+        {"unknown service", &GRPCExecutor{Address: reflecting.address, Method: "users.v1.Users/Get"}, "users.v1.Users", true},
+        {"unknown method", &GRPCExecutor{Address: reflecting.address, Method: "grpc.health.v1.Health/Probe"}, "has no method Probe", true},
+        {"streaming method", &GRPCExecutor{Address: reflecting.address, Method: "grpc.health.v1.Health/Watch"}, "not unary", true},
+        {"bad request", &GRPCExecutor{Address: reflecting.address, Method: "grpc.health.v1.Health/Check", Request: map[string]interface{}{"service": 1}}, "grpc request", true},
+        {"no reflection", &GRPCExecutor{Address: plain.address, Method: "grpc.health.v1.Health/Check"}, "server reflection", true},
+        {"missing descriptor set", &GRPCExecutor{Address: plain.address, Method: "grpc.health.v1.Health/Check", DescriptorSet: "/nonexistent.pb"}, "descriptor set", true},
+        {"unreachable server", &GRPCExecutor{Address: "127.0.0.1:1", Method: "grpc.health.v1.Health/Check", Timeout: time.Second}, "Unavailable", false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := tt.executor.Execute(context.Background(), nil)
+            if err == nil || !strings.Contains(err.Error(), tt.want) {
+                t.Fatalf("Expected an error containing %q, got %v", tt.want, err)
+            }
+            if IsPermanent(err) != tt.permanent {
+                t.Errorf("Expected permanent to be %v, got %v (%v)", tt.permanent, IsPermanent(err), err)
+            }
+        })
+    }
+}
+
+func TestNewGRPCExecutor(t *testing.T) {
+    executor, err := NewGRPCExecutor(map[string]interface{}{
+        "address":         "users:9090",
+        "method":          "users.v1.UserService/GetUser",
+        "request":         map[string]interface{}{"id": "{{ workflow.id }}"},
+        "metadata":        map[string]interface{}{"x-tenant": "acme"},
+        "tls":             true,
+        "timeout_seconds": 2,
+    })
+    if err != nil {
+        t.Fatalf("NewGRPCExecutor failed: %v", err)
+    }
+    if executor.Address != "users:9090" || !executor.TLS || executor.Timeout != 2*time.Second || executor.Metadata["x-tenant"] != "acme" {
+        t.Errorf("Unexpected executor: %+v", executor)
+    }
+
+    tests := []struct {
+        name   string
+        config map[string]interface{}
+    }{
+        {"missing address", map[string]interface{}{"method": "a.B/C"}},
+        {"missing method", map[string]interface{}{"address": "x:1"}},
+        {"bad method", map[string]interface{}{"address": "x:1", "method": "Check"}},
+        {"bad tls", map[string]interface{}{"address": "x:1", "method": "a.B/C", "tls": "yes"}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if _, err := NewGRPCExecutor(tt.config); err == nil {
+                t.Error("Expected an error")
+            }
+        })
+    }
+}
@@ -0,0 +1,424 @@
+package dagengine
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// DefaultHTTPTimeout bounds the requests of HTTPExecutors without a Timeout.
+const DefaultHTTPTimeout = 30 * time.Second
+
+// DefaultHTTPRetry is the retry policy of http nodes that declare none. It
+// retries 5xx and 429 responses and network errors; the other failures are
+// permanent.
+var DefaultHTTPRetry = RetryPolicy{
+    MaxAttempts:  3,
+    Backoff:      BackoffExponential,
+    InitialDelay: 500 * time.Millisecond,
+    MaxDelay:     5 * time.Second,
+    Jitter:       0.2,
+}
+
+// maxHTTPBody bounds the size of HTTP responses; larger ones fail the node.
+const maxHTTPBody = 10 << 20
+
+// HTTP authentication types, for HTTPAuth.Type.
+const (
+    HTTPAuthBasic  = "basic"
+    HTTPAuthBearer = "bearer"
+)
+
+// HTTPExecutor implements the Executor interface for calls to HTTP services.
+//
+// The URL, headers, body and credentials are templates: "{{ path }}" is
+// replaced by the value at the dot-separated path in the node's inputs
+// (e.g. "{{ workflow.user_id }}" or "{{ extract.id }}") and
+// "{{ secret:name/key }}" by a key of a secret read with Secret. Values are
+// escaped in the URL, as path segments or query components. In a structured
+// body, a string made of a single
+// placeholder is replaced by the value itself, keeping its type.
+type HTTPExecutor struct {
+    Method         string        // HTTP method (default: GET)
+    URL            string
+    Headers        map[string]string
+    Body           interface{}   // A string sent as is, or a structure sent as JSON
+    Auth           *HTTPAuth
+    ExpectedStatus []string      // Accepted statuses, such as "200" or "2xx" (default: 2xx)
+    Timeout        time.Duration // Bound on the request (default: DefaultHTTPTimeout)
+    Client         *http.Client  // Default: http.DefaultClient
+
+    // Secret resolves the secret placeholders; without it they fail.
+    Secret func(ctx context.Context, name, key string) (string, error)
+}
+
+// HTTPAuth authenticates the requests of an HTTPExecutor.
+type HTTPAuth struct {
+    Type     string // HTTPAuthBasic or HTTPAuthBearer
+    Username string // For basic authentication
+    Password string // For basic authentication
+    Token    string // For bearer authentication
+}
+
+// HTTPStatusError is returned when a response has an unexpected status.
+// Server errors (5xx) and 429 Too Many Requests are retried according to
+// the node's retry policy (DefaultHTTPRetry unless the node declares one);
+// other statuses are permanent failures.
+type HTTPStatusError struct {
+    StatusCode int
+    Body       string // Start of the response body
+}
+
+func (e *HTTPStatusError) Error() string {
+    return fmt.Sprintf("unexpected HTTP status %d: %s", e.StatusCode, e.Body)
+}
+
+// retryable reports whether a request that got this status may succeed later.
+func (e *HTTPStatusError) retryable() bool {
+    return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// NewHTTPExecutor creates an HTTPExecutor from a node's executor
+// configuration:
+//
+//	config:
+//	  method: POST
+//	  url: "https://users.internal/api/users/{{ workflow.user_id }}/events"
+//	  headers:
+//	    X-Request-Source: workflow
+//	  body:
+//	    event: signup
+//	    count: "{{ extract.count }}"
+//	  auth:
+//	    type: bearer
+//	    token: "{{ secret:api-credentials/token }}"
+//	  expected_status: [200, 201]
+//	  timeout_seconds: 10
+func NewHTTPExecutor(config map[string]interface{}) (*HTTPExecutor, error) {
+    executor := &HTTPExecutor{Body: config["body"]}
+
+    var err error
+    if executor.Method, err = configString("http", config, "method"); err != nil {
+        return nil, err
+    }
+    executor.Method = strings.ToUpper(executor.Method)
+    if executor.URL, err = configString("http", config, "url"); err != nil {
+        return nil, err
+    }
+    if executor.Headers, err = configStringMap("http", config, "headers"); err != nil {
+        return nil, err
+    }
+
+    if auth, ok := config["auth"]; ok {
+        authMap, err := configStringMap("http", map[string]interface{}{"auth": auth}, "auth")
+        if err != nil {
+            return nil, err
+        }
+        executor.Auth = &HTTPAuth{
+            Type:     authMap["type"],
+            Username: authMap["username"],
+            Password: authMap["password"],
+            Token:    authMap["token"],
+        }
+    }
+
+    switch statuses := config["expected_status"].(type) {
+    case nil:
+    case []string:
+        executor.ExpectedStatus = append(executor.ExpectedStatus, statuses...)
+    case []interface{}:
+        for _, status := range statuses {
+            executor.ExpectedStatus = append(executor.ExpectedStatus, fmt.Sprintf("%v", status))
+        }
+    default:
+        return nil, fmt.Errorf("http expected_status must be a list, got %T", statuses)
+    }
+
+    if executor.Timeout, err = configSeconds("http", config, "timeout_seconds"); err != nil {
+        return nil, err
+    }
+
+    if err := executor.Validate(); err != nil {
+        return nil, err
+    }
+    return executor, nil
+}
+
+// httpExecutorType registers HTTPExecutor as the "http" type. Its
+// templates read the workflow's secrets, and its nodes retry with
+// DefaultHTTPRetry unless they declare a policy.
+func httpExecutorType() ExecutorType {
+    return ExecutorType{
+        Name: "http",
+        New: func(spec ExecutorSpec, host *ExecutorHost) (Executor, error) {
+            executor, err := NewHTTPExecutor(spec.Config)
+            if err != nil {
+                return nil, err
+            }
+            executor.Secret = host.Secret
+            return executor, nil
+        },
+        Retry: &DefaultHTTPRetry,
+        Schema: ExecutorSchema{
+            Config: map[string]ConfigField{
+                "method":          {Kind: ConfigString, Description: "HTTP method (default: GET)"},
+                "url":             {Kind: ConfigString, Required: true, Description: "URL template"},
+                "headers":         {Kind: ConfigMap, Description: "Header templates"},
+                "body":            {Kind: ConfigAny, Description: "A string sent as is, or a structure sent as JSON"},
+                "auth":            {Kind: ConfigMap, Description: "Basic or bearer credentials"},
+                "expected_status": {Kind: ConfigList, Description: "Accepted statuses (default: 2xx)"},
+                "timeout_seconds": {Kind: ConfigNumber, Description: "Bound on the request (default: 30)"},
+            },
+        },
+        Validate: func(spec ExecutorSpec) error {
+            _, err := NewHTTPExecutor(spec.Config)
+            return err
+        },
+    }
+}
+
+var (
+    // statusPattern matches an expected status: a code, or a class such as 2xx.
+    statusPattern = regexp.MustCompile(`^[1-5]([0-9]{2}|xx)$`)
+    // methodPattern matches HTTP methods.
+    methodPattern = regexp.MustCompile(`^[A-Z]+$`)
+)
+
+// Validate checks the executor's configuration.
+func (h *HTTPExecutor) Validate() error {
+    if h.URL == "" {
+        return fmt.Errorf("http url is required")
+    }
+    if h.Method != "" && !methodPattern.MatchString(h.Method) {
+        return fmt.Errorf("invalid http method '%s'", h.Method)
+    }
+    if h.Auth != nil {
+        switch h.Auth.Type {
+        case HTTPAuthBasic, HTTPAuthBearer:
+        default:
+            return fmt.Errorf("unsupported http auth type '%s' (supported: %s, %s)", h.Auth.Type, HTTPAuthBasic, HTTPAuthBearer)
+        }
+    }
+    for _, status := range h.ExpectedStatus {
+        if !statusPattern.MatchString(status) {
+            return fmt.Errorf("invalid expected http status '%s'", status)
+        }
+    }
+    if h.Timeout < 0 {
+        return fmt.Errorf("http timeout must not be negative")
+    }
+    return nil
+}
+
+// Describe identifies the request for result caching.
+func (h *HTTPExecutor) Describe() ExecutorDescription {
+    config := map[string]interface{}{
+        "method":          h.method(),
+        "url":             h.URL,
+        "headers":         h.Headers,
+        "body":            h.Body,
+        "expected_status": h.ExpectedStatus,
+    }
+    if h.Auth != nil {
+        config["auth"] = *h.Auth
+    }
+    return ExecutorDescription{Type: "http", Config: config}
+}
+
+// method returns the request's method.
+func (h *HTTPExecutor) method() string {
+    if h.Method == "" {
+        return http.MethodGet
+    }
+    return h.Method
+}
+
+// Execute sends the request.
+//
+// The node's result holds the response's "status", its "headers" (with
+// lowercase names) and its "body": decoded when the response is JSON, a
+// string otherwise. A response with an unexpected status fails with an
+// *HTTPStatusError, and a body larger than 10 MiB fails permanently.
+//
+// The executor does not retry on its own: the node's retry policy retries
+// 5xx and 429 responses and network errors. Nodes created from an "http"
+// spec without a policy get DefaultHTTPRetry.
+func (h *HTTPExecutor) Execute(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+    t := &inputTemplate{ctx: ctx, inputs: inputs, secret: h.Secret}
+
+    rawURL, err := h.url(t)
+    if err != nil {
+        return nil, Permanent(fmt.Errorf("http url: %w", err))
+    }
+    body, contentType, err := h.body(t)
+    if err != nil {
+        return nil, Permanent(fmt.Errorf("http body: %w", err))
+    }
+
+    timeout := h.Timeout
+    if timeout == 0 {
+        timeout = DefaultHTTPTimeout
+    }
+    reqCtx, cancel := context.WithTimeout(ctx, timeout)
+    defer cancel()
+
+    req, err := http.NewRequestWithContext(reqCtx, h.method(), rawURL, bytes.NewReader(body))
+    if err != nil {
+        return nil, Permanent(fmt.Errorf("http request: %w", err))
+    }
+    if contentType != "" {
+        req.Header.Set("Content-Type", contentType)
+    }
+    for name, value := range h.Headers {
+        expanded, err := t.expand(value, nil)
+        if err != nil {
+            return nil, Permanent(fmt.Errorf("http header %s: %w", name, err))
+        }
+        req.Header.Set(name, expanded)
+    }
+    if err := h.authenticate(req, t); err != nil {
+        return nil, Permanent(fmt.Errorf("http auth: %w", err))
+    }
+
+    client := h.Client
+    if client == nil {
+        client = http.DefaultClient
+    }
+    resp, err := client.Do(req)
+    if err != nil {
+        if ctx.Err() != nil {
+            return nil, fmt.Errorf("http request interrupted: %w", ctx.Err())
+        }
+        return nil, fmt.Errorf("http request failed: %w", err)
+    }
+    defer resp.Body.Close()
+    data, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBody+1))
+    if err != nil {
+        return nil, fmt.Errorf("http response: %w", err)
+    }
+
+    if !h.expected(resp.StatusCode) {
+        statusErr := &HTTPStatusError{StatusCode: resp.StatusCode, Body: firstBytes(string(data), 512)}
+        if statusErr.retryable() {
+            return nil, statusErr
+        }
+        return nil, Permanent(statusErr)
+    }
+    if len(data) > maxHTTPBody {
+        return nil, Permanent(fmt.Errorf("http response exceeds %d bytes", maxHTTPBody))
+    }
+    return httpResult(resp, data)
+}
+
+// url returns the expanded URL. Values are escaped as path segments before
+// the query and as query components after it.
+func (h *HTTPExecutor) url(t *inputTemplate) (string, error) {
+    path, query, hasQuery := strings.Cut(h.URL, "?")
+    expanded, err := t.expand(path, url.PathEscape)
+    if err != nil || !hasQuery {
+        return expanded, err
+    }
+    query, err = t.expand(query, func(value string) string {
+        // QueryEscape encodes spaces as '+', which some servers keep as is
+        return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+    })
+    return expanded + "?" + query, err
+}
+
+// body returns the expanded request body and its content type.
+func (h *HTTPExecutor) body(t *inputTemplate) ([]byte, string, error) {
+    switch body := h.Body.(type) {
+    case nil:
+        return nil, "", nil
+    case string:
+        expanded, err := t.expand(body, nil)
+        return []byte(expanded), "", err
+    default:
+        expanded, err := t.expandValue(body)
+        if err != nil {
+            return nil, "", err
+        }
+        data, err := json.Marshal(expanded)
+        return data, "application/json", err
+    }
+}
+
+// authenticate adds the executor's credentials to req.
+func (h *HTTPExecutor) authenticate(req *http.Request, t *inputTemplate) error {
+    if h.Auth == nil {
+        return nil
+    }
+    switch h.Auth.Type {
+    case HTTPAuthBasic:
+        username, err := t.expand(h.Auth.Username, nil)
+        if err != nil {
+            return err
+        }
+        password, err := t.expand(h.Auth.Password, nil)
+        if err != nil {
+            return err
+        }
+        req.SetBasicAuth(username, password)
+    case HTTPAuthBearer:
+        token, err := t.expand(h.Auth.Token, nil)
+        if err != nil {
+            return err
+        }
+        req.Header.Set("Authorization", "Bearer "+token)
+    default:
+        return fmt.Errorf("unsupported auth type '%s'", h.Auth.Type)
+    }
+    return nil
+}
+
+// expected reports whether a response status is accepted.
+func (h *HTTPExecutor) expected(status int) bool {
+    if len(h.ExpectedStatus) == 0 {
+        return status >= 200 && status < 300
+    }
+    code := strconv.Itoa(status)
+    for _, pattern := range h.ExpectedStatus {
+        if pattern == code || (strings.HasSuffix(pattern, "xx") && pattern[0] == code[0]) {
+            return true
+        }
+    }
+    return false
+}
+
+// httpResult converts a response to a node result.
+func httpResult(resp *http.Response, data []byte) (map[string]interface{}, error) {
+    headers := make(map[string]interface{}, len(resp.Header))
+    for name := range resp.Header {
+        headers[strings.ToLower(name)] = resp.Header.Get(name)
+    }
+
+    var body interface{} = string(data)
+    if strings.Contains(resp.Header.Get("Content-Type"), "json") && len(bytes.TrimSpace(data)) > 0 {
+        if err := json.Unmarshal(data, &body); err != nil {
+            return nil, fmt.Errorf("http response is not valid JSON: %w", err)
+        }
+    }
+
+    return map[string]interface{}{
+        "status":  float64(resp.StatusCode),
+        "headers": headers,
+        "body":    body,
+    }, nil
+}
+
+// firstBytes returns at most the first n bytes of s.
+func firstBytes(s string, n int) string {
+    s = strings.TrimSpace(s)
+    if len(s) <= n {
+        return s
+    }
+    return s[:n] + "..."
+}
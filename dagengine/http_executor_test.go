@@ -0,0 +1,309 @@
+package dagengine
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "reflect"
+    "strings"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// echoServer responds with a JSON description of the request it received.
+func echoServer() *httptest.Server {
+    return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ := io.ReadAll(r.Body)
+        user, password, _ := r.BasicAuth()
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(map[string]interface{}{
+            "method":       r.Method,
+            "path":         r.URL.EscapedPath(),
+            "query":        r.URL.RawQuery,
+            "auth":         r.Header.Get("Authorization"),
+            "user":         user + ":" + password,
+            "trace":        r.Header.Get("X-Trace"),
+            "content_type": r.Header.Get("Content-Type"),
+            "body":         string(body),
+        })
+    }))
+}
+
+func TestHTTPExecutor(t *testing.T) {
+    server := echoServer()
+    defer server.Close()
+
+    inputs := map[string]interface{}{
+        WorkflowInputsKey: map[string]interface{}{"user": "ada lovelace", "trace": "t-1", "file": "a/b+c d"},
+        "extract":         map[string]interface{}{"count": float64(3), "tags": []interface{}{"a"}},
+    }
+    secret := func(ctx context.Context, name, key string) (string, error) {
+        return name + "-" + key, nil
+    }
+
+    tests := []struct {
+        name     string
+        executor *HTTPExecutor
+        want     map[string]interface{}
+    }{
+        {
+            name: "get with templates",
+            executor: &HTTPExecutor{
+                URL:     server.URL + "/users/{{ workflow.user }}?count={{extract.count}}",
+                Headers: map[string]string{"X-Trace": "{{ workflow.trace }}"},
+                Auth:    &HTTPAuth{Type: HTTPAuthBearer, Token: "{{ secret:api/token }}"},
+                Secret:  secret,
+            },
+            want: map[string]interface{}{
+                "method": "GET", "path": "/users/ada%20lovelace", "query": "count=3",
+                "auth": "Bearer api-token", "trace": "t-1",
+            },
+        },
+        {
+            name:     "path and query escaping",
+            executor: &HTTPExecutor{URL: server.URL + "/files/{{ workflow.file }}?name={{ workflow.file }}"},
+            want: map[string]interface{}{
+                "path": "/files/a%2Fb+c%20d", "query": "name=a%2Fb%2Bc%20d",
+            },
+        },
+        {
+            name: "post structured body",
+            executor: &HTTPExecutor{
+                Method: http.MethodPost,
+                URL:    server.URL,
+                Body:   map[string]interface{}{"count": "{{ extract.count }}", "tags": "{{ extract.tags }}", "label": "n={{ extract.count }}"},
+                Auth:   &HTTPAuth{Type: HTTPAuthBasic, Username: "svc", Password: "{{ secret:db/password }}"},
+                Secret: secret,
+            },
+            want: map[string]interface{}{
+                "method": "POST", "user": "svc:db-password", "content_type": "application/json",
+                "body": `{"count":3,"label":"n=3","tags":["a"]}`,
+            },
+        },
+        {
+            name:     "string body",
+            executor: &HTTPExecutor{Method: http.MethodPut, URL: server.URL, Body: "user={{ workflow.user }}"},
+            want:     map[string]interface{}{"method": "PUT", "body": "user=ada lovelace", "content_type": ""},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := tt.executor.Execute(context.Background(), inputs)
+            if err != nil {
+                t.Fatalf("Execute failed: %v", err)
+            }
+            if result["status"] != float64(200) {
+                t.Errorf("Expected status 200, got %v", result["status"])
+            }
+            if got := result["headers"].(map[string]interface{})["content-type"]; got != "application/json" {
+                t.Errorf("Expected a content-type header, got %v", got)
+            }
+            body := result["body"].(map[string]interface{})
+            for key, want := range tt.want {
+                if body[key] != want {
+                    t.Errorf("Expected %s to be %v, got %v", key, want, body[key])
+                }
+            }
+        })
+    }
+}
+
+func TestHTTPExecutorStatuses(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var status int
+        fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/"), "%d", &status)
+        w.WriteHeader(status)
+        fmt.Fprint(w, "plain text")
+    }))
+    defer server.Close()
+
+    tests := []struct {
+        name      string
+        status    int
+        expected  []string
+        wantErr   bool
+        permanent bool
+    }{
+        {"default accepts 2xx", 201, nil, false, false},
+        {"status class", 302, []string{"3xx"}, false, false},
+        {"exact status", 404, []string{"200", "404"}, false, false},
+        {"client error is permanent", 404, nil, true, true},
+        {"server error is retried", 503, nil, true, false},
+        {"too many requests is retried", 429, []string{"200"}, true, false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+            executor := &HTTPExecutor{URL: fmt.Sprintf("%s/%d", server.URL, tt.status), ExpectedStatus: tt.expected, Client: client}
+            result, err := executor.Execute(context.Background(), nil)
+            if !tt.wantErr {
+                if err != nil {
+                    t.Fatalf("Execute failed: %v", err)
+                }
+                if result["status"] != float64(tt.status) || result["body"] != "plain text" {
+                    t.Errorf("Expected status %d with a text body, got %v", tt.status, result)
+                }
+                return
+            }
+            var statusErr *HTTPStatusError
+            if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.status {
+                t.Fatalf("Expected an HTTPStatusError for %d, got %v", tt.status, err)
+            }
+            if IsPermanent(err) != tt.permanent {
+                t.Errorf("Expected permanent to be %v, got %v", tt.permanent, IsPermanent(err))
+            }
+        })
+    }
+}
+
+func TestHTTPExecutorRetriesServerErrors(t *testing.T) {
+    var calls int32
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if atomic.AddInt32(&calls, 1) < 3 {
+            w.WriteHeader(http.StatusBadGateway)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        fmt.Fprint(w, `{"ok": true}`)
+    }))
+    defer server.Close()
+
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("call", nil, &HTTPExecutor{URL: server.URL}))
+    engine.Nodes["call"].Retry = &RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond}
+    result, err := engine.Run(context.Background(), nil)
+    if err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+    if got := result.Outputs["call"]["body"]; !reflect.DeepEqual(got, map[string]interface{}{"ok": true}) {
+        t.Errorf("Expected the third attempt's body, got %v", got)
+    }
+    if calls != 3 {
+        t.Errorf("Expected 3 calls, got %d", calls)
+    }
+}
+
+func TestHTTPExecutorDefaultRetry(t *testing.T) {
+    var calls int32
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if atomic.AddInt32(&calls, 1) == 1 {
+            w.WriteHeader(http.StatusServiceUnavailable)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        fmt.Fprint(w, `{"ok": true}`)
+    }))
+    defer server.Close()
+
+    engine := NewDAGEngine()
+    node, err := engine.NodeFromSpec("call", nil, ExecutorSpec{Type: "http", Config: map[string]interface{}{"url": server.URL}}, nil)
+    if err != nil {
+        t.Fatalf("NodeFromSpec failed: %v", err)
+    }
+    if node.Retry == nil || node.Retry == &DefaultHTTPRetry || !reflect.DeepEqual(*node.Retry, DefaultHTTPRetry) {
+        t.Fatalf("Expected a copy of DefaultHTTPRetry, got %+v", node.Retry)
+    }
+    engine.AddNode(node)
+    result, err := engine.Run(context.Background(), nil)
+    if err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+    if got := result.Outputs["call"]["body"]; !reflect.DeepEqual(got, map[string]interface{}{"ok": true}) {
+        t.Errorf("Expected the second attempt's body, got %v", got)
+    }
+    if calls != 2 {
+        t.Errorf("Expected 2 calls, got %d", calls)
+    }
+}
+
+func TestHTTPExecutorLargeResponse(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write(bytes.Repeat([]byte("x"), maxHTTPBody+1))
+    }))
+    defer server.Close()
+
+    _, err := (&HTTPExecutor{URL: server.URL}).Execute(context.Background(), nil)
+    if err == nil || !strings.Contains(err.Error(), "exceeds") {
+        t.Fatalf("Expected a size error, got %v", err)
+    }
+    if !IsPermanent(err) {
+        t.Errorf("Expected a permanent error, got %v", err)
+    }
+}
+
+func TestHTTPExecutorErrors(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        time.Sleep(200 * time.Millisecond)
+        w.Header().Set("Content-Type", "application/json")
+        fmt.Fprint(w, `{"truncated":`)
+    }))
+    defer server.Close()
+
+    tests := []struct {
+        name      string
+        executor  *HTTPExecutor
+        want      string
+        permanent bool
+    }{
+        {"timeout", &HTTPExecutor{URL: server.URL, Timeout: 50 * time.Millisecond}, "deadline exceeded", false},
+        {"invalid JSON", &HTTPExecutor{URL: server.URL}, "not valid JSON", false},
+        {"missing input", &HTTPExecutor{URL: server.URL + "/{{ extract.id }}"}, "'extract.id' not found", true},
+        {"no secrets", &HTTPExecutor{URL: server.URL, Auth: &HTTPAuth{Type: HTTPAuthBearer, Token: "{{ secret:api/token }}"}}, "no secrets", true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := tt.executor.Execute(context.Background(), nil)
+            if err == nil || !strings.Contains(err.Error(), tt.want) {
+                t.Fatalf("Expected an error containing %q, got %v", tt.want, err)
+            }
+            if IsPermanent(err) != tt.permanent {
+                t.Errorf("Expected permanent to be %v, got %v", tt.permanent, IsPermanent(err))
+            }
+        })
+    }
+}
+
+func TestNewHTTPExecutor(t *testing.T) {
+    executor, err := NewHTTPExecutor(map[string]interface{}{
+        "method":          "post",
+        "url":             "http://users/{{ workflow.id }}",
+        "headers":         map[string]interface{}{"X-Retries": 2},
+        "auth":            map[string]interface{}{"type": "bearer", "token": "t"},
+        "expected_status": []interface{}{200, "2xx"},
+        "timeout_seconds": 1.5,
+    })
+    if err != nil {
+        t.Fatalf("NewHTTPExecutor failed: %v", err)
+    }
+    if executor.Method != "POST" || executor.Headers["X-Retries"] != "2" || executor.Auth.Token != "t" ||
+        !reflect.DeepEqual(executor.ExpectedStatus, []string{"200", "2xx"}) || executor.Timeout != 1500*time.Millisecond {
+        t.Errorf("Unexpected executor: %+v", executor)
+    }
+
+    tests := []struct {
+        name   string
+        config map[string]interface{}
+    }{
+        {"missing url", map[string]interface{}{}},
+        {"bad method", map[string]interface{}{"url": "http://x", "method": "GE T"}},
+        {"bad auth", map[string]interface{}{"url": "http://x", "auth": map[string]interface{}{"type": "digest"}}},
+        {"bad status", map[string]interface{}{"url": "http://x", "expected_status": []interface{}{"600"}}},
+        {"bad timeout", map[string]interface{}{"url": "http://x", "timeout_seconds": "1s"}},
+        {"bad headers", map[string]interface{}{"url": "http://x", "headers": []interface{}{"X-A"}}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if _, err := NewHTTPExecutor(tt.config); err == nil {
+                t.Error("Expected an error")
+            }
+        })
+    }
+}
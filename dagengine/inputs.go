@@ -0,0 +1,126 @@
+package dagengine
+
+import (
+    "fmt"
+    "strings"
+)
+
+// WorkflowInputsKey is the key under which the workflow-level inputs are
+// exposed in every node's inputs map. It is reserved and cannot be used as a node ID.
+const WorkflowInputsKey = "workflow"
+
+// InputMapping selects a value from an upstream node's result (or from the
+// workflow-level inputs) and exposes it under a new name in the node's inputs.
+type InputMapping struct {
+    From    string      // Source node ID, or WorkflowInputsKey for workflow-level inputs
+    Field   string      // Dot-separated path into the source result; empty selects the whole result
+    As      string      // Input name; defaults to the last segment of Field (or From)
+    Default interface{} // Value used when the source field is missing
+}
+
+// name returns the key the mapped value is stored under.
+func (m InputMapping) name() string {
+    if m.As != "" {
+        return m.As
+    }
+    if m.Field != "" {
+        parts := strings.Split(m.Field, ".")
+        return parts[len(parts)-1]
+    }
+    return m.From
+}
+
+// validateInputs checks that a node's input mappings read from its
+// dependencies or the workflow inputs, and that each stores its value under
+// its own name: not under another mapping's, another source's or a map
+// node's element names.
+func validateInputs(n *Node) error {
+    names := make(map[string]bool, len(n.Inputs))
+    for _, mapping := range n.Inputs {
+        if mapping.From != WorkflowInputsKey && !containsString(n.Dependencies, mapping.From) {
+            return fmt.Errorf("input mapping error: node '%s' maps from '%s', which is not one of its dependencies", n.ID, mapping.From)
+        }
+
+        name := mapping.name()
+        switch {
+        case names[name]:
+            return fmt.Errorf("input mapping error: node '%s' maps more than one value to '%s'", n.ID, name)
+        case name != mapping.From && (name == WorkflowInputsKey || containsString(n.Dependencies, name)):
+            return fmt.Errorf("input mapping error: node '%s' maps a value to '%s', which already names one of its sources", n.ID, name)
+        case n.Map != nil && (name == n.Map.itemName() || name == MapIndexKey):
+            return fmt.Errorf("input mapping error: node '%s' maps a value to '%s', which holds its map elements", n.ID, name)
+        }
+        names[name] = true
+    }
+    return nil
+}
+
+// buildInputs assembles the inputs map for a node. Every parent's result is
+// available under the parent's ID, the workflow-level inputs are available
+// under WorkflowInputsKey, and explicit mappings are applied on top. The
+// node gets its own copy of the workflow inputs, so that an executor that
+// changes them does not affect the other nodes.
+func buildInputs(n *Node, parents map[string]map[string]interface{}, workflowInputs map[string]interface{}) (map[string]interface{}, error) {
+    inputs := baseInputs(parents, workflowInputs)
+
+    for _, mapping := range n.Inputs {
+        var source map[string]interface{}
+        if mapping.From == WorkflowInputsKey {
+            source = inputs[WorkflowInputsKey].(map[string]interface{})
+        } else {
+            result, ok := parents[mapping.From]
+            if !ok {
+                return nil, fmt.Errorf("input mapping for node '%s': '%s' is not a dependency", n.ID, mapping.From)
+            }
+            source = result
+        }
+
+        value, found := lookupPath(source, mapping.Field)
+        if !found {
+            if mapping.Default == nil {
+                return nil, fmt.Errorf("input mapping for node '%s': field '%s' not found in result of '%s'", n.ID, mapping.Field, mapping.From)
+            }
+            value = mapping.Default
+        }
+        inputs[mapping.name()] = value
+    }
+
+    return inputs, nil
+}
+
+// baseInputs returns the parents' results keyed by parent ID together with
+// a shallow copy of the workflow-level inputs under WorkflowInputsKey.
+func baseInputs(parents map[string]map[string]interface{}, workflowInputs map[string]interface{}) map[string]interface{} {
+    inputs := make(map[string]interface{}, len(parents)+1)
+
+    workflow := make(map[string]interface{}, len(workflowInputs))
+    for k, v := range workflowInputs {
+        workflow[k] = v
+    }
+    inputs[WorkflowInputsKey] = workflow
+
+    for parentID, result := range parents {
+        inputs[parentID] = result
+    }
+    return inputs
+}
+
+// lookupPath resolves a dot-separated path (e.g. "data.count") inside a result map.
+func lookupPath(source map[string]interface{}, path string) (interface{}, bool) {
+    if path == "" {
+        return source, source != nil
+    }
+
+    var current interface{} = source
+    for _, part := range strings.Split(path, ".") {
+        m, ok := current.(map[string]interface{})
+        if !ok {
+            return nil, false
+        }
+        current, ok = m[part]
+        if !ok {
+            return nil, false
+        }
+    }
+    return current, true
+}
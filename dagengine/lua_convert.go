@@ -0,0 +1,195 @@
+package dagengine
+
+import (
+    "fmt"
+    "reflect"
+    "strconv"
+
+    lua "github.com/yuin/gopher-lua"
+)
+
+// toLuaValue converts a Go value to a Lua value. Maps with string keys become
+// tables, slices and arrays become 1-based array tables, all numeric types
+// become numbers and nil becomes nil.
+func toLuaValue(L *lua.LState, value interface{}) (lua.LValue, error) {
+    switch v := value.(type) {
+    case nil:
+        return lua.LNil, nil
+    case lua.LValue:
+        return v, nil
+    case bool:
+        return lua.LBool(v), nil
+    case string:
+        return lua.LString(v), nil
+    case float64:
+        return lua.LNumber(v), nil
+    case int:
+        return lua.LNumber(v), nil
+    case map[string]interface{}:
+        table := L.CreateTable(0, len(v))
+        for key, item := range v {
+            converted, err := toLuaValue(L, item)
+            if err != nil {
+                return nil, fmt.Errorf("%s: %w", key, err)
+            }
+            table.RawSetString(key, converted)
+        }
+        return table, nil
+    case []interface{}:
+        table := L.CreateTable(len(v), 0)
+        for i, item := range v {
+            converted, err := toLuaValue(L, item)
+            if err != nil {
+                return nil, fmt.Errorf("[%d]: %w", i, err)
+            }
+            table.RawSetInt(i+1, converted)
+        }
+        return table, nil
+    }
+
+    // Other numeric, slice and map types
+    rv := reflect.ValueOf(value)
+    switch rv.Kind() {
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        return lua.LNumber(rv.Int()), nil
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        return lua.LNumber(rv.Uint()), nil
+    case reflect.Float32, reflect.Float64:
+        return lua.LNumber(rv.Float()), nil
+    case reflect.Bool:
+        return lua.LBool(rv.Bool()), nil
+    case reflect.String:
+        return lua.LString(rv.String()), nil
+    case reflect.Slice, reflect.Array:
+        if rv.Kind() == reflect.Slice && rv.IsNil() {
+            return lua.LNil, nil
+        }
+        table := L.CreateTable(rv.Len(), 0)
+        for i := 0; i < rv.Len(); i++ {
+            converted, err := toLuaValue(L, rv.Index(i).Interface())
+            if err != nil {
+                return nil, fmt.Errorf("[%d]: %w", i, err)
+            }
+            table.RawSetInt(i+1, converted)
+        }
+        return table, nil
+    case reflect.Map:
+        if rv.Type().Key().Kind() != reflect.String {
+            return nil, fmt.Errorf("cannot convert %T to lua: map keys must be strings", value)
+        }
+        if rv.IsNil() {
+            return lua.LNil, nil
+        }
+        table := L.CreateTable(0, rv.Len())
+        iter := rv.MapRange()
+        for iter.Next() {
+            key := iter.Key().String()
+            converted, err := toLuaValue(L, iter.Value().Interface())
+            if err != nil {
+                return nil, fmt.Errorf("%s: %w", key, err)
+            }
+            table.RawSetString(key, converted)
+        }
+        return table, nil
+    case reflect.Pointer, reflect.Interface:
+        if rv.IsNil() {
+            return lua.LNil, nil
+        }
+        return toLuaValue(L, rv.Elem().Interface())
+    }
+    return nil, fmt.Errorf("cannot convert %T to lua", value)
+}
+
+// fromLuaValue converts a Lua value to a Go value. Numbers become float64,
+// like results decoded from JSON, so that a result looks the same whether
+// it was just computed or restored from a checkpoint or the cache.
+//
+// A table whose keys are all positive integers, and which is not too sparse
+// (the largest key is at most twice the number of keys), becomes a
+// []interface{} with nil in the holes. Any other table, including an empty
+// one, becomes a map[string]interface{}; number keys are formatted as strings.
+func fromLuaValue(value lua.LValue) (interface{}, error) {
+    return fromLuaValueSeen(value, make(map[*lua.LTable]bool))
+}
+
+// fromLuaValueSeen converts value, failing on tables already in seen, i.e.
+// tables that contain themselves.
+func fromLuaValueSeen(value lua.LValue, seen map[*lua.LTable]bool) (interface{}, error) {
+    switch v := value.(type) {
+    case *lua.LNilType:
+        return nil, nil
+    case lua.LBool:
+        return bool(v), nil
+    case lua.LNumber:
+        return float64(v), nil
+    case lua.LString:
+        return string(v), nil
+    case *lua.LTable:
+        if seen[v] {
+            return nil, fmt.Errorf("cannot convert a table that contains itself")
+        }
+        seen[v] = true
+        defer delete(seen, v)
+        return fromLuaTable(v, seen)
+    }
+    return nil, fmt.Errorf("cannot convert lua %s to a Go value", value.Type())
+}
+
+// fromLuaTable converts a table to a slice or a map (see fromLuaValue).
+func fromLuaTable(table *lua.LTable, seen map[*lua.LTable]bool) (interface{}, error) {
+    count, maxIndex, isArray := 0, 0, true
+    var badKey lua.LValue
+    table.ForEach(func(key, _ lua.LValue) {
+        count++
+        switch k := key.(type) {
+        case lua.LNumber:
+            if index := int(k); float64(index) == float64(k) && index > 0 {
+                if index > maxIndex {
+                    maxIndex = index
+                }
+                return
+            }
+        case lua.LString:
+        default:
+            badKey = key
+        }
+        isArray = false
+    })
+    if badKey != nil {
+        return nil, fmt.Errorf("cannot convert a table with a %s key", badKey.Type())
+    }
+
+    if count > 0 && isArray && maxIndex <= 2*count {
+        list := make([]interface{}, maxIndex)
+        for i := range list {
+            item, err := fromLuaValueSeen(table.RawGetInt(i+1), seen)
+            if err != nil {
+                return nil, fmt.Errorf("[%d]: %w", i+1, err)
+            }
+            list[i] = item
+        }
+        return list, nil
+    }
+
+    result := make(map[string]interface{}, count)
+    var err error
+    table.ForEach(func(key, item lua.LValue) {
+        if err != nil {
+            return
+        }
+        name := key.String()
+        if number, ok := key.(lua.LNumber); ok {
+            name = strconv.FormatFloat(float64(number), 'f', -1, 64)
+        }
+        var converted interface{}
+        if converted, err = fromLuaValueSeen(item, seen); err != nil {
+            err = fmt.Errorf("%s: %w", name, err)
+            return
+        }
+        result[name] = converted
+    })
+    if err != nil {
+        return nil, err
+    }
+    return result, nil
+}
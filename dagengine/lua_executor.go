@@ -8,28 +8,175 @@ import (
 
 // LuaExecutor implements the Executor interface for Lua scripts.
 type LuaExecutor struct {
-    Code string
+    Code    string
+    Sandbox *LuaSandbox   // Restrictions on the script (default: DefaultLuaSandbox)
+    Pool    *LuaStatePool // Reused states to run the script in; its sandbox replaces Sandbox
+    Modules []string      // Go modules preloaded as globals, e.g. LuaModuleJSON
+    Host    *LuaHost      // Services used by the secret, env and http modules
+}
+
+// NewLuaExecutor creates a LuaExecutor from a node's executor configuration.
+// The "modules" key lists the modules the script uses:
+//
+//	config:
+//	  modules: [json, log, secret]
+func NewLuaExecutor(code string, config map[string]interface{}) (*LuaExecutor, error) {
+    executor := &LuaExecutor{Code: code}
+    switch modules := config["modules"].(type) {
+    case nil:
+    case []string:
+        executor.Modules = append(executor.Modules, modules...)
+    case []interface{}:
+        for _, module := range modules {
+            name, ok := module.(string)
+            if !ok {
+                return nil, fmt.Errorf("lua modules must be strings, got %T", module)
+            }
+            executor.Modules = append(executor.Modules, name)
+        }
+    default:
+        return nil, fmt.Errorf("lua modules must be a list, got %T", modules)
+    }
+    if err := validateLuaModules(executor.Modules); err != nil {
+        return nil, err
+    }
+    return executor, nil
+}
+
+// luaExecutorType registers LuaExecutor as the "lua" type. Its executors
+// share the default state pool and read the workflow's secrets and variables.
+func luaExecutorType() ExecutorType {
+    return ExecutorType{
+        Name: "lua",
+        New: func(spec ExecutorSpec, host *ExecutorHost) (Executor, error) {
+            executor, err := NewLuaExecutor(spec.Code, spec.Config)
+            if err != nil {
+                return nil, err
+            }
+            executor.Host = &LuaHost{Secret: host.Secret, Env: host.Env}
+            executor.Pool = DefaultLuaStatePool()
+            return executor, nil
+        },
+        Schema: ExecutorSchema{
+            Code: CodeRequired,
+            Config: map[string]ConfigField{
+                "modules": {Kind: ConfigList, Description: "Go modules the script uses, e.g. [json, log]"},
+            },
+        },
+        Validate: func(spec ExecutorSpec) error {
+            _, err := NewLuaExecutor(spec.Code, spec.Config)
+            return err
+        },
+    }
+}
+
+// Describe identifies the script for result caching.
+func (l *LuaExecutor) Describe() ExecutorDescription {
+    description := ExecutorDescription{Type: "lua", Code: l.Code}
+    if len(l.Modules) > 0 {
+        description.Config = map[string]interface{}{"modules": l.Modules}
+    }
+    return description
 }
 
 // Execute runs the embedded Lua script.
+//
+// The node's inputs are available to the script as the global table
+// 'inputs' (see fromLuaValue and toLuaValue for how values are converted).
+// The node's result is the table returned by the script or, if it returns
+// nothing, the global table 'output'. A script that produces neither has an
+// empty result.
+//
+// The script runs in the executor's sandbox, with the modules it opted in
+// to, and is interrupted as soon as ctx is done. It is compiled once per
+// process; with a Pool, the state it runs in is reused by later runs.
 func (l *LuaExecutor) Execute(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
-    L := lua.NewState()
-    defer L.Close()
+    proto, err := compileLua(l.Code)
+    if err != nil {
+        return nil, fmt.Errorf("lua execution error: %w", err)
+    }
+
+    L, stepCtx, release, err := l.state(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("lua sandbox: %w", err)
+    }
+    reuse := false
+    defer func() { release(reuse) }()
+    if err := loadLuaModules(ctx, L, l.Modules, l.Host); err != nil {
+        return nil, fmt.Errorf("lua modules: %w", err)
+    }
+
+    luaInputs, err := toLuaValue(L, inputs)
+    if err != nil {
+        return nil, fmt.Errorf("lua inputs: %w", err)
+    }
+    if luaInputs == lua.LNil {
+        luaInputs = L.NewTable()
+    }
+    L.SetGlobal("inputs", luaInputs)
 
-    // 1. Expose custom Go functions (e.g., the 'log' object) here
-    // ...
+    L.Push(L.NewFunctionFromProto(proto))
+    if err := L.PCall(0, lua.MultRet, nil); err != nil {
+        return nil, luaError(err, stepCtx)
+    }
 
-    // 2. Inject inputs into the Lua state (e.g., as a global table 'inputs')
-    // ...
+    // PCall leaves the script's return values on the stack
+    result := lua.LValue(lua.LNil)
+    if L.GetTop() > 0 {
+        result = L.Get(1)
+    }
+    source := "return value"
+    if result == lua.LNil {
+        result = L.GetGlobal("output")
+        source = "'output'"
+    }
+    output, err := luaResult(result, source)
+    reuse = err == nil
+    return output, err
+}
 
-    // 3. Run the script code
-    if err := L.DoString(l.Code); err != nil {
-        return nil, fmt.Errorf("lua execution error: %w", err)
+// state returns the state to run the script in and the function to call
+// once the script is done, with whether the state can be reused.
+func (l *LuaExecutor) state(ctx context.Context) (*lua.LState, *luaStepContext, func(reuse bool), error) {
+    if l.Pool != nil {
+        state, stepCtx := l.Pool.get(ctx)
+        release := func(reuse bool) {
+            if reuse {
+                l.Pool.put(state)
+            } else {
+                state.L.Close()
+            }
+        }
+        return state.L, stepCtx, release, nil
+    }
+
+    sandbox := l.Sandbox
+    if sandbox == nil {
+        sandbox = DefaultLuaSandbox()
+    }
+    L, stepCtx, err := sandbox.newState(ctx)
+    if err != nil {
+        return nil, nil, nil, err
     }
+    return L, stepCtx, func(bool) { L.Close() }, nil
+}
 
-    // 4. Extract results (e.g., read from a global Lua variable 'output')
-    // ...
+// luaResult converts the value produced by a script to a node result.
+func luaResult(value lua.LValue, source string) (map[string]interface{}, error) {
+    if value == lua.LNil {
+        return map[string]interface{}{}, nil
+    }
+    if _, ok := value.(*lua.LTable); !ok {
+        return nil, fmt.Errorf("lua %s must be a table, got %s", source, value.Type())
+    }
 
-    // Placeholder result
-    return map[string]interface{}{"success": true}, nil 
-}
\ No newline at end of file
+    converted, err := fromLuaValue(value)
+    if err != nil {
+        return nil, fmt.Errorf("lua %s: %w", source, err)
+    }
+    result, ok := converted.(map[string]interface{})
+    if !ok {
+        return nil, fmt.Errorf("lua %s must be a table with string keys, got a list", source)
+    }
+    return result, nil
+}
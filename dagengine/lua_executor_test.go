@@ -0,0 +1,176 @@
+package dagengine
+
+import (
+    "context"
+    "reflect"
+    "strings"
+    "testing"
+
+    lua "github.com/yuin/gopher-lua"
+)
+
+func TestLuaValueRoundTrip(t *testing.T) {
+    tests := []struct {
+        name  string
+        value interface{}
+        want  interface{}
+    }{
+        {"nil", nil, nil},
+        {"bool", true, true},
+        {"string", "hello", "hello"},
+        {"int", 42, float64(42)},
+        {"int64", int64(-7), float64(-7)},
+        {"uint8", uint8(200), float64(200)},
+        {"float", 2.5, 2.5},
+        {"list", []interface{}{1, "two", false}, []interface{}{float64(1), "two", false}},
+        {"typed list", []string{"a", "b"}, []interface{}{"a", "b"}},
+        {"list with hole", []interface{}{1, nil, 3}, []interface{}{float64(1), nil, float64(3)}},
+        {"empty list", []interface{}{}, map[string]interface{}{}},
+        {"map", map[string]interface{}{"a": 1, "b": "x"}, map[string]interface{}{"a": float64(1), "b": "x"}},
+        {"typed map", map[string]int{"a": 1}, map[string]interface{}{"a": float64(1)}},
+        {
+            "nested",
+            map[string]interface{}{
+                "rows":  []interface{}{map[string]interface{}{"id": 1, "tags": []string{"x"}}},
+                "stats": map[string]interface{}{"count": 1000, "ok": true},
+            },
+            map[string]interface{}{
+                "rows":  []interface{}{map[string]interface{}{"id": float64(1), "tags": []interface{}{"x"}}},
+                "stats": map[string]interface{}{"count": float64(1000), "ok": true},
+            },
+        },
+    }
+
+    L := lua.NewState()
+    defer L.Close()
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            value, err := toLuaValue(L, tt.value)
+            if err != nil {
+                t.Fatalf("toLuaValue failed: %v", err)
+            }
+            got, err := fromLuaValue(value)
+            if err != nil {
+                t.Fatalf("fromLuaValue failed: %v", err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("Expected %#v, got %#v", tt.want, got)
+            }
+        })
+    }
+
+    if _, err := toLuaValue(L, make(chan int)); err == nil {
+        t.Error("Expected an error for a channel")
+    }
+    if _, err := toLuaValue(L, map[int]string{1: "a"}); err == nil {
+        t.Error("Expected an error for a map with non-string keys")
+    }
+}
+
+func TestLuaExecutorResults(t *testing.T) {
+    inputs := map[string]interface{}{
+        WorkflowInputsKey: map[string]interface{}{"multiplier": 3},
+        "extract":         map[string]interface{}{"rows": []interface{}{1, 2, 3}, "source": "db"},
+    }
+
+    tests := []struct {
+        name string
+        code string
+        want map[string]interface{}
+    }{
+        {
+            name: "return value",
+            code: `return {data = "extracted", count = 1000}`,
+            want: map[string]interface{}{"data": "extracted", "count": float64(1000)},
+        },
+        {
+            name: "output global",
+            code: `output = {status = "loaded"}`,
+            want: map[string]interface{}{"status": "loaded"},
+        },
+        {
+            name: "return value wins over output",
+            code: `output = {from = "output"}; return {from = "return"}`,
+            want: map[string]interface{}{"from": "return"},
+        },
+        {
+            name: "no result",
+            code: `local x = 1`,
+            want: map[string]interface{}{},
+        },
+        {
+            name: "inputs",
+            code: `
+                local total = 0
+                for _, v in ipairs(inputs.extract.rows) do total = total + v end
+                return {
+                    total = total * inputs.workflow.multiplier,
+                    source = inputs.extract.source,
+                    rows = inputs.extract.rows,
+                    missing = inputs.nothing == nil,
+                }`,
+            want: map[string]interface{}{
+                "total":   float64(18),
+                "source":  "db",
+                "rows":    []interface{}{float64(1), float64(2), float64(3)},
+                "missing": true,
+            },
+        },
+        {
+            name: "mixed keys",
+            code: `return {[1] = "a", [2.5] = "b", name = "c"}`,
+            want: map[string]interface{}{"1": "a", "2.5": "b", "name": "c"},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := (&LuaExecutor{Code: tt.code}).Execute(context.Background(), inputs)
+            if err != nil {
+                t.Fatalf("Execute failed: %v", err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("Expected %#v, got %#v", tt.want, got)
+            }
+        })
+    }
+}
+
+func TestLuaExecutorErrors(t *testing.T) {
+    tests := []struct {
+        name string
+        code string
+        want string
+    }{
+        {"syntax error", `return {`, "lua execution error"},
+        {"runtime error", `error("boom")`, "boom"},
+        {"non-table return", `return 5`, "must be a table, got number"},
+        {"list return", `return {1, 2}`, "got a list"},
+        {"function value", `return {f = function() end}`, "cannot convert lua function"},
+        {"table key", `return {[{}] = 1}`, "table key"},
+        {"cyclic table", `local t = {}; t.self = t; output = t`, "contains itself"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := (&LuaExecutor{Code: tt.code}).Execute(context.Background(), nil)
+            if err == nil || !strings.Contains(err.Error(), tt.want) {
+                t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+            }
+        })
+    }
+}
+
+func TestLuaNodesPassResultsDownstream(t *testing.T) {
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("extract", nil, &LuaExecutor{Code: `return {count = 1000}`}))
+    engine.AddNode(NewNode("transform", []string{"extract"}, &LuaExecutor{Code: `return {count = inputs.extract.count / 10}`}))
+
+    result, err := engine.Run(context.Background(), nil)
+    if err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+    if got := result.Outputs["transform"]["count"]; got != float64(100) {
+        t.Errorf("Expected transform to compute 100, got %v", got)
+    }
+}
@@ -0,0 +1,343 @@
+package dagengine
+
+import (
+    "bytes"
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "sort"
+    "strings"
+    "time"
+
+    lua "github.com/yuin/gopher-lua"
+)
+
+// Lua module names, for LuaExecutor.Modules.
+const (
+    LuaModuleJSON   = "json"   // json.encode(value), json.decode(text)
+    LuaModuleLog    = "log"    // log.info/warn/error(...), reported to the run's observers
+    LuaModuleHTTP   = "http"   // http.get(url, opts), http.post(url, body, opts)
+    LuaModuleTime   = "time"   // time.now(), time.format(t, layout), time.parse(text, layout), time.sleep(seconds)
+    LuaModuleBase64 = "base64" // base64.encode(text), base64.decode(text)
+    LuaModuleCrypto = "crypto" // crypto.sha256(text), crypto.hmac_sha256(key, text)
+    LuaModuleSecret = "secret" // secret.get(name, key), through LuaHost.Secret
+    LuaModuleEnv    = "env"    // env.get(name, default), from LuaHost.Env
+)
+
+// DefaultLuaHTTPTimeout bounds the requests made with the http module when
+// the script does not pass a timeout.
+const DefaultLuaHTTPTimeout = 30 * time.Second
+
+// LuaHost provides the services behind the modules that reach outside the
+// script. A nil LuaHost, or a nil field, makes the corresponding module
+// functions fail.
+type LuaHost struct {
+    Secret     func(ctx context.Context, name, key string) (string, error) // Backs secret.get
+    Env        map[string]string                                           // Variables read by env.get
+    HTTPClient *http.Client                                                // Used by the http module (default: http.DefaultClient)
+}
+
+// luaModules maps module names to the functions they provide. The functions
+// are built for each state: they use the context of the node and the host of
+// the executor.
+var luaModules = map[string]func(ctx context.Context, host *LuaHost) map[string]lua.LGFunction{
+    LuaModuleJSON:   luaJSONModule,
+    LuaModuleLog:    luaLogModule,
+    LuaModuleHTTP:   luaHTTPModule,
+    LuaModuleTime:   luaTimeModule,
+    LuaModuleBase64: luaBase64Module,
+    LuaModuleCrypto: luaCryptoModule,
+    LuaModuleSecret: luaSecretModule,
+    LuaModuleEnv:    luaEnvModule,
+}
+
+// validateLuaModules checks that every name is a known module.
+func validateLuaModules(names []string) error {
+    for _, name := range names {
+        if _, ok := luaModules[name]; !ok {
+            return fmt.Errorf("unknown lua module '%s' (known: %s)", name, strings.Join(sortedLuaModules(), ", "))
+        }
+    }
+    return nil
+}
+
+// sortedLuaModules returns the names of all Lua modules.
+func sortedLuaModules() []string {
+    names := make([]string, 0, len(luaModules))
+    for name := range luaModules {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// loadLuaModules makes the named modules available to the script as global
+// tables. When the package library is open they can also be loaded with
+// require.
+func loadLuaModules(ctx context.Context, L *lua.LState, names []string, host *LuaHost) error {
+    if err := validateLuaModules(names); err != nil {
+        return err
+    }
+    if host == nil {
+        host = &LuaHost{}
+    }
+    for _, name := range names {
+        module := L.SetFuncs(L.NewTable(), luaModules[name](ctx, host))
+        L.SetGlobal(name, module)
+        if pkg, ok := L.GetGlobal("package").(*lua.LTable); ok && pkg.RawGetString("preload") != lua.LNil {
+            L.PreloadModule(name, func(L *lua.LState) int {
+                L.Push(module)
+                return 1
+            })
+        }
+    }
+    return nil
+}
+
+// luaFail returns nil and the error message to the script, the Lua
+// convention for functions that can fail.
+func luaFail(L *lua.LState, format string, args ...interface{}) int {
+    L.Push(lua.LNil)
+    L.Push(lua.LString(fmt.Sprintf(format, args...)))
+    return 2
+}
+
+func luaJSONModule(ctx context.Context, host *LuaHost) map[string]lua.LGFunction {
+    return map[string]lua.LGFunction{
+        "encode": func(L *lua.LState) int {
+            value, err := fromLuaValue(L.CheckAny(1))
+            if err != nil {
+                return luaFail(L, "json.encode: %v", err)
+            }
+            data, err := json.Marshal(value)
+            if err != nil {
+                return luaFail(L, "json.encode: %v", err)
+            }
+            L.Push(lua.LString(data))
+            return 1
+        },
+        "decode": func(L *lua.LState) int {
+            var value interface{}
+            if err := json.Unmarshal([]byte(L.CheckString(1)), &value); err != nil {
+                return luaFail(L, "json.decode: %v", err)
+            }
+            converted, err := toLuaValue(L, value)
+            if err != nil {
+                return luaFail(L, "json.decode: %v", err)
+            }
+            L.Push(converted)
+            return 1
+        },
+    }
+}
+
+func luaLogModule(ctx context.Context, host *LuaHost) map[string]lua.LGFunction {
+    logAt := func(level string) lua.LGFunction {
+        return func(L *lua.LState) int {
+            parts := make([]string, L.GetTop())
+            for i := range parts {
+                parts[i] = L.ToStringMeta(L.Get(i + 1)).String()
+            }
+            Log(ctx, level, strings.Join(parts, " "))
+            return 0
+        }
+    }
+    return map[string]lua.LGFunction{
+        "info":  logAt(LogInfo),
+        "warn":  logAt(LogWarn),
+        "error": logAt(LogError),
+    }
+}
+
+func luaHTTPModule(ctx context.Context, host *LuaHost) map[string]lua.LGFunction {
+    client := host.HTTPClient
+    if client == nil {
+        client = http.DefaultClient
+    }
+
+    // do sends a request and returns a table with the status, body and
+    // headers of the response. opts may set 'headers' and 'timeout' (seconds).
+    do := func(L *lua.LState, method, url string, body []byte, contentType string, opts *lua.LTable) int {
+        timeout := DefaultLuaHTTPTimeout
+        if opts != nil {
+            if seconds, ok := opts.RawGetString("timeout").(lua.LNumber); ok && seconds > 0 {
+                timeout = time.Duration(float64(seconds) * float64(time.Second))
+            }
+        }
+        reqCtx, cancel := context.WithTimeout(ctx, timeout)
+        defer cancel()
+
+        req, err := http.NewRequestWithContext(reqCtx, method, url, bytes.NewReader(body))
+        if err != nil {
+            return luaFail(L, "http.%s: %v", strings.ToLower(method), err)
+        }
+        if contentType != "" {
+            req.Header.Set("Content-Type", contentType)
+        }
+        if opts != nil {
+            if headers, ok := opts.RawGetString("headers").(*lua.LTable); ok {
+                headers.ForEach(func(key, value lua.LValue) {
+                    req.Header.Set(key.String(), value.String())
+                })
+            }
+        }
+
+        resp, err := client.Do(req)
+        if err != nil {
+            return luaFail(L, "http.%s: %v", strings.ToLower(method), err)
+        }
+        defer resp.Body.Close()
+        data, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBody))
+        if err != nil {
+            return luaFail(L, "http.%s: reading response: %v", strings.ToLower(method), err)
+        }
+
+        headers := L.NewTable()
+        for key := range resp.Header {
+            headers.RawSetString(strings.ToLower(key), lua.LString(resp.Header.Get(key)))
+        }
+        result := L.NewTable()
+        result.RawSetString("status", lua.LNumber(resp.StatusCode))
+        result.RawSetString("body", lua.LString(data))
+        result.RawSetString("headers", headers)
+        L.Push(result)
+        return 1
+    }
+
+    return map[string]lua.LGFunction{
+        "get": func(L *lua.LState) int {
+            return do(L, http.MethodGet, L.CheckString(1), nil, "", L.OptTable(2, nil))
+        },
+        // post sends a string body as is and a table encoded as JSON
+        "post": func(L *lua.LState) int {
+            url, opts := L.CheckString(1), L.OptTable(3, nil)
+            switch body := L.Get(2).(type) {
+            case lua.LString:
+                return do(L, http.MethodPost, url, []byte(body), "", opts)
+            case *lua.LTable:
+                value, err := fromLuaValue(body)
+                if err != nil {
+                    return luaFail(L, "http.post: %v", err)
+                }
+                data, err := json.Marshal(value)
+                if err != nil {
+                    return luaFail(L, "http.post: %v", err)
+                }
+                return do(L, http.MethodPost, url, data, "application/json", opts)
+            case *lua.LNilType:
+                return do(L, http.MethodPost, url, nil, "", opts)
+            default:
+                L.ArgError(2, "string or table expected")
+                return 0
+            }
+        },
+    }
+}
+
+func luaTimeModule(ctx context.Context, host *LuaHost) map[string]lua.LGFunction {
+    // Times are seconds since the Unix epoch; layouts default to RFC 3339
+    return map[string]lua.LGFunction{
+        "now": func(L *lua.LState) int {
+            L.Push(lua.LNumber(float64(time.Now().UnixNano()) / float64(time.Second)))
+            return 1
+        },
+        "format": func(L *lua.LState) int {
+            seconds := float64(L.CheckNumber(1))
+            layout := L.OptString(2, time.RFC3339)
+            t := time.Unix(0, int64(seconds*float64(time.Second))).UTC()
+            L.Push(lua.LString(t.Format(layout)))
+            return 1
+        },
+        "parse": func(L *lua.LState) int {
+            t, err := time.Parse(L.OptString(2, time.RFC3339), L.CheckString(1))
+            if err != nil {
+                return luaFail(L, "time.parse: %v", err)
+            }
+            L.Push(lua.LNumber(float64(t.UnixNano()) / float64(time.Second)))
+            return 1
+        },
+        // sleep returns early, with an error, if the node is cancelled
+        "sleep": func(L *lua.LState) int {
+            timer := time.NewTimer(time.Duration(float64(L.CheckNumber(1)) * float64(time.Second)))
+            defer timer.Stop()
+            select {
+            case <-timer.C:
+                return 0
+            case <-ctx.Done():
+                L.RaiseError("time.sleep: %v", ctx.Err())
+                return 0
+            }
+        },
+    }
+}
+
+func luaBase64Module(ctx context.Context, host *LuaHost) map[string]lua.LGFunction {
+    return map[string]lua.LGFunction{
+        "encode": func(L *lua.LState) int {
+            L.Push(lua.LString(base64.StdEncoding.EncodeToString([]byte(L.CheckString(1)))))
+            return 1
+        },
+        "decode": func(L *lua.LState) int {
+            data, err := base64.StdEncoding.DecodeString(L.CheckString(1))
+            if err != nil {
+                return luaFail(L, "base64.decode: %v", err)
+            }
+            L.Push(lua.LString(data))
+            return 1
+        },
+    }
+}
+
+func luaCryptoModule(ctx context.Context, host *LuaHost) map[string]lua.LGFunction {
+    // Digests are returned as lowercase hex strings
+    return map[string]lua.LGFunction{
+        "sha256": func(L *lua.LState) int {
+            sum := sha256.Sum256([]byte(L.CheckString(1)))
+            L.Push(lua.LString(hex.EncodeToString(sum[:])))
+            return 1
+        },
+        "hmac_sha256": func(L *lua.LState) int {
+            mac := hmac.New(sha256.New, []byte(L.CheckString(1)))
+            mac.Write([]byte(L.CheckString(2)))
+            L.Push(lua.LString(hex.EncodeToString(mac.Sum(nil))))
+            return 1
+        },
+    }
+}
+
+func luaSecretModule(ctx context.Context, host *LuaHost) map[string]lua.LGFunction {
+    return map[string]lua.LGFunction{
+        "get": func(L *lua.LState) int {
+            name, key := L.CheckString(1), L.CheckString(2)
+            if host.Secret == nil {
+                return luaFail(L, "secret.get: no secrets are available")
+            }
+            value, err := host.Secret(ctx, name, key)
+            if err != nil {
+                return luaFail(L, "secret.get: %v", err)
+            }
+            L.Push(lua.LString(value))
+            return 1
+        },
+    }
+}
+
+func luaEnvModule(ctx context.Context, host *LuaHost) map[string]lua.LGFunction {
+    return map[string]lua.LGFunction{
+        // get returns the default (or nil) for variables that are not set
+        "get": func(L *lua.LState) int {
+            if value, ok := host.Env[L.CheckString(1)]; ok {
+                L.Push(lua.LString(value))
+            } else {
+                L.Push(L.Get(2))
+            }
+            return 1
+        },
+    }
+}
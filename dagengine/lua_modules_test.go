@@ -0,0 +1,198 @@
+package dagengine
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "reflect"
+    "strings"
+    "sync"
+    "testing"
+)
+
+// logRecorder records the messages logged by node tasks.
+type logRecorder struct {
+    NopObserver
+    mu   sync.Mutex
+    logs []LogEvent
+}
+
+func (r *logRecorder) OnNodeLog(e LogEvent) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.logs = append(r.logs, e)
+}
+
+func TestLuaModules(t *testing.T) {
+    host := &LuaHost{
+        Env: map[string]string{"REGION": "eu-west-1"},
+        Secret: func(ctx context.Context, name, key string) (string, error) {
+            if name == "db" && key == "password" {
+                return "s3cret", nil
+            }
+            return "", fmt.Errorf("secret %s has no key %s", name, key)
+        },
+    }
+
+    tests := []struct {
+        name    string
+        modules []string
+        code    string
+        want    map[string]interface{}
+    }{
+        {
+            name:    "modules are opt-in",
+            modules: []string{LuaModuleJSON},
+            code:    `return {json = json ~= nil, http = http == nil, secret = secret == nil}`,
+            want:    map[string]interface{}{"json": true, "http": true, "secret": true},
+        },
+        {
+            name:    "json",
+            modules: []string{LuaModuleJSON},
+            code: `
+                local decoded = json.decode('{"rows": [1, 2], "name": "x", "none": null}')
+                local _, err = json.decode("{")
+                return {
+                    encoded = json.encode({a = 1, b = {true, "s"}}),
+                    rows = #decoded.rows, name = decoded.name, none = decoded.none == nil,
+                    failed = err ~= nil,
+                }`,
+            want: map[string]interface{}{
+                "encoded": `{"a":1,"b":[true,"s"]}`,
+                "rows":    float64(2), "name": "x", "none": true, "failed": true,
+            },
+        },
+        {
+            name:    "base64 and crypto",
+            modules: []string{LuaModuleBase64, LuaModuleCrypto},
+            code: `return {
+                encoded = base64.encode("hello"), decoded = base64.decode("aGVsbG8="),
+                sha = crypto.sha256("abc"), hmac = crypto.hmac_sha256("key", "abc"),
+            }`,
+            want: map[string]interface{}{
+                "encoded": "aGVsbG8=", "decoded": "hello",
+                "sha":  "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+                "hmac": "9c196e32dc0175f86f4b1cb89289d6619de6bee699e4c378e68309ed97a1a6ab",
+            },
+        },
+        {
+            name:    "time",
+            modules: []string{LuaModuleTime},
+            code: `
+                local t = time.parse("2024-03-01T12:30:00Z")
+                time.sleep(0.001)
+                return {t = t, formatted = time.format(t, "2006-01-02"), now = time.now() > t}`,
+            want: map[string]interface{}{"t": float64(1709296200), "formatted": "2024-03-01", "now": true},
+        },
+        {
+            name:    "env and secret",
+            modules: []string{LuaModuleEnv, LuaModuleSecret},
+            code: `
+                local _, err = secret.get("db", "user")
+                return {
+                    region = env.get("REGION"), fallback = env.get("MISSING", "none"),
+                    password = secret.get("db", "password"), err = err,
+                }`,
+            want: map[string]interface{}{
+                "region": "eu-west-1", "fallback": "none",
+                "password": "s3cret", "err": "secret.get: secret db has no key user",
+            },
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            executor := &LuaExecutor{Code: tt.code, Modules: tt.modules, Host: host}
+            got, err := executor.Execute(context.Background(), nil)
+            if err != nil {
+                t.Fatalf("Execute failed: %v", err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("Expected %#v, got %#v", tt.want, got)
+            }
+        })
+    }
+}
+
+func TestLuaHTTPModule(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ := io.ReadAll(r.Body)
+        w.Header().Set("X-Method", r.Method)
+        fmt.Fprintf(w, "%s|%s|%s", r.Header.Get("Authorization"), r.Header.Get("Content-Type"), body)
+    }))
+    defer server.Close()
+
+    code := `
+        local get = http.get(url .. "/items", {headers = {Authorization = "Bearer t"}})
+        local post = http.post(url, {n = 1})
+        local _, err = http.get("http://127.0.0.1:0/", {timeout = 1})
+        return {
+            status = get.status, get = get.body, method = post.headers["x-method"],
+            post = post.body, failed = err ~= nil,
+        }`
+    executor := &LuaExecutor{Code: "url = '" + server.URL + "'\n" + code, Modules: []string{LuaModuleHTTP}}
+    got, err := executor.Execute(context.Background(), nil)
+    if err != nil {
+        t.Fatalf("Execute failed: %v", err)
+    }
+    want := map[string]interface{}{
+        "status": float64(200), "get": "Bearer t||", "method": "POST",
+        "post": `|application/json|{"n":1}`, "failed": true,
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("Expected %#v, got %#v", want, got)
+    }
+}
+
+func TestLuaLogModule(t *testing.T) {
+    recorder := &logRecorder{}
+    engine := NewDAGEngine()
+    engine.AddObserver(recorder)
+    engine.AddNode(NewNode("report", nil, &LuaExecutor{
+        Code:    `log.info("rows", 3); log.warn("slow"); log.error("failed", true); return {}`,
+        Modules: []string{LuaModuleLog},
+    }))
+    if _, err := engine.Run(context.Background(), nil); err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+
+    want := []string{"info:rows 3", "warn:slow", "error:failed true"}
+    if len(recorder.logs) != len(want) {
+        t.Fatalf("Expected %d log events, got %d", len(want), len(recorder.logs))
+    }
+    for i, event := range recorder.logs {
+        if got := event.Level + ":" + event.Message; got != want[i] || event.NodeID != "report" {
+            t.Errorf("Expected %s from report, got %s from %s", want[i], got, event.NodeID)
+        }
+    }
+}
+
+func TestNewLuaExecutor(t *testing.T) {
+    executor, err := NewLuaExecutor("return {}", map[string]interface{}{"modules": []interface{}{"json", "log"}})
+    if err != nil {
+        t.Fatalf("NewLuaExecutor failed: %v", err)
+    }
+    if !reflect.DeepEqual(executor.Modules, []string{"json", "log"}) {
+        t.Errorf("Expected modules [json log], got %v", executor.Modules)
+    }
+
+    tests := []struct {
+        name   string
+        config map[string]interface{}
+        want   string
+    }{
+        {"unknown module", map[string]interface{}{"modules": []interface{}{"socket"}}, "unknown lua module 'socket'"},
+        {"not a list", map[string]interface{}{"modules": "json"}, "must be a list"},
+        {"not a string", map[string]interface{}{"modules": []interface{}{1}}, "must be strings"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := NewLuaExecutor("return {}", tt.config)
+            if err == nil || !strings.Contains(err.Error(), tt.want) {
+                t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+            }
+        })
+    }
+}
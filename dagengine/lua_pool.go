@@ -0,0 +1,235 @@
+package dagengine
+
+import (
+    "context"
+    "crypto/sha256"
+    "fmt"
+    "runtime"
+    "strings"
+    "sync"
+
+    lua "github.com/yuin/gopher-lua"
+    "github.com/yuin/gopher-lua/parse"
+)
+
+// maxLuaProtos bounds the number of compiled scripts kept in memory.
+const maxLuaProtos = 1024
+
+// luaProtos caches compiled scripts by the SHA-256 of their source.
+// Compiled code is immutable and can be shared by any number of states.
+var luaProtos = struct {
+    sync.RWMutex
+    protos map[[sha256.Size]byte]*lua.FunctionProto
+}{protos: make(map[[sha256.Size]byte]*lua.FunctionProto)}
+
+// compileLua returns the compiled form of code, compiling it only the first
+// time it is seen.
+func compileLua(code string) (*lua.FunctionProto, error) {
+    key := sha256.Sum256([]byte(code))
+    luaProtos.RLock()
+    proto, ok := luaProtos.protos[key]
+    luaProtos.RUnlock()
+    if ok {
+        return proto, nil
+    }
+
+    proto, err := compileLuaSource(code)
+    if err != nil {
+        return nil, err
+    }
+
+    luaProtos.Lock()
+    defer luaProtos.Unlock()
+    if len(luaProtos.protos) >= maxLuaProtos {
+        // Evict an arbitrary script rather than track recency: workflows
+        // rarely have enough distinct scripts to fill the cache
+        for old := range luaProtos.protos {
+            delete(luaProtos.protos, old)
+            break
+        }
+    }
+    luaProtos.protos[key] = proto
+    return proto, nil
+}
+
+// compileLuaSource parses and compiles code.
+func compileLuaSource(code string) (*lua.FunctionProto, error) {
+    chunk, err := parse.Parse(strings.NewReader(code), "<string>")
+    if err != nil {
+        return nil, err
+    }
+    return lua.Compile(chunk, "<string>")
+}
+
+// LuaStatePool keeps Lua states opened with a sandbox so that executors do
+// not pay for creating one on every run. A state is reset to the snapshot
+// taken when it was opened before it is reused: globals and changes to the
+// standard libraries made by a script are not seen by the next one. States
+// whose script failed are not reused.
+//
+// A LuaStatePool is safe for concurrent use.
+type LuaStatePool struct {
+    sandbox *LuaSandbox
+    size    int
+
+    mu     sync.Mutex
+    idle   []*pooledLuaState
+    closed bool
+}
+
+// NewLuaStatePool creates a pool of states opened with sandbox (default:
+// DefaultLuaSandbox) that keeps up to size idle states (0: four per CPU).
+func NewLuaStatePool(sandbox *LuaSandbox, size int) (*LuaStatePool, error) {
+    if sandbox == nil {
+        sandbox = DefaultLuaSandbox()
+    }
+    if err := sandbox.Validate(); err != nil {
+        return nil, err
+    }
+    if size < 0 {
+        return nil, fmt.Errorf("lua state pool size must not be negative: %d", size)
+    }
+    if size == 0 {
+        size = 4 * runtime.GOMAXPROCS(0)
+    }
+    return &LuaStatePool{sandbox: sandbox, size: size}, nil
+}
+
+var (
+    defaultLuaStatePool     *LuaStatePool
+    defaultLuaStatePoolOnce sync.Once
+)
+
+// DefaultLuaStatePool returns the process-wide pool of states opened with
+// DefaultLuaSandbox.
+func DefaultLuaStatePool() *LuaStatePool {
+    defaultLuaStatePoolOnce.Do(func() {
+        defaultLuaStatePool, _ = NewLuaStatePool(DefaultLuaSandbox(), 0) // Cannot fail
+    })
+    return defaultLuaStatePool
+}
+
+// Sandbox returns the sandbox of the pool's states.
+func (p *LuaStatePool) Sandbox() *LuaSandbox {
+    return p.sandbox
+}
+
+// Close closes the idle states. States in use are closed when released.
+func (p *LuaStatePool) Close() {
+    p.mu.Lock()
+    idle := p.idle
+    p.idle, p.closed = nil, true
+    p.mu.Unlock()
+
+    for _, state := range idle {
+        state.L.Close()
+    }
+}
+
+// get returns an idle state, or a new one, interrupted when ctx is done.
+func (p *LuaStatePool) get(ctx context.Context) (*pooledLuaState, *luaStepContext) {
+    p.mu.Lock()
+    var state *pooledLuaState
+    if n := len(p.idle); n > 0 {
+        state = p.idle[n-1]
+        p.idle = p.idle[:n-1]
+    }
+    p.mu.Unlock()
+
+    if state == nil {
+        state = newPooledLuaState(p.sandbox.open())
+    }
+    return state, p.sandbox.limit(ctx, state.L)
+}
+
+// put resets a state and keeps it for later use, or closes it if the pool
+// is full or closed.
+func (p *LuaStatePool) put(state *pooledLuaState) {
+    state.reset()
+
+    p.mu.Lock()
+    if !p.closed && len(p.idle) < p.size {
+        p.idle = append(p.idle, state)
+        state = nil
+    }
+    p.mu.Unlock()
+
+    if state != nil {
+        state.L.Close()
+    }
+}
+
+// pooledLuaState is a state with a snapshot of the tables reachable from
+// its globals and registry when it was opened.
+type pooledLuaState struct {
+    L        *lua.LState
+    env      *lua.LTable
+    stringMT lua.LValue
+    tables   map[*lua.LTable]luaTableSnapshot
+}
+
+// luaTableSnapshot holds the contents of a table.
+type luaTableSnapshot struct {
+    fields    map[lua.LValue]lua.LValue
+    metatable lua.LValue
+}
+
+// newPooledLuaState snapshots a freshly opened state.
+func newPooledLuaState(L *lua.LState) *pooledLuaState {
+    state := &pooledLuaState{
+        L:        L,
+        env:      L.Env,
+        stringMT: L.GetMetatable(lua.LString("")),
+        tables:   make(map[*lua.LTable]luaTableSnapshot),
+    }
+    state.snapshot(L.G.Global)
+    state.snapshot(L.G.Registry)
+    if mt, ok := state.stringMT.(*lua.LTable); ok {
+        state.snapshot(mt)
+    }
+    return state
+}
+
+// snapshot records table and the tables it references.
+func (s *pooledLuaState) snapshot(table *lua.LTable) {
+    if _, ok := s.tables[table]; ok {
+        return
+    }
+    snap := luaTableSnapshot{fields: make(map[lua.LValue]lua.LValue), metatable: table.Metatable}
+    s.tables[table] = snap
+    table.ForEach(func(key, value lua.LValue) {
+        snap.fields[key] = value
+        if nested, ok := value.(*lua.LTable); ok {
+            s.snapshot(nested)
+        }
+    })
+    if mt, ok := table.Metatable.(*lua.LTable); ok {
+        s.snapshot(mt)
+    }
+}
+
+// reset restores the snapshot, dropping everything the last script left in
+// the state.
+func (s *pooledLuaState) reset() {
+    L := s.L
+    L.RemoveContext()
+    L.SetTop(0)
+    L.Env = s.env
+    L.SetMetatable(lua.LString(""), s.stringMT)
+
+    for table, snap := range s.tables {
+        var added []lua.LValue
+        table.ForEach(func(key, _ lua.LValue) {
+            if _, ok := snap.fields[key]; !ok {
+                added = append(added, key)
+            }
+        })
+        for _, key := range added {
+            table.RawSet(key, lua.LNil)
+        }
+        for key, value := range snap.fields {
+            table.RawSet(key, value)
+        }
+        table.Metatable = snap.metatable
+    }
+}
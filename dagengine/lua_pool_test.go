@@ -0,0 +1,172 @@
+package dagengine
+
+import (
+    "context"
+    "fmt"
+    "testing"
+)
+
+func TestLuaStatePoolResetsState(t *testing.T) {
+    pool, err := NewLuaStatePool(nil, 1)
+    if err != nil {
+        t.Fatalf("NewLuaStatePool failed: %v", err)
+    }
+    defer pool.Close()
+
+    dirty := &LuaExecutor{Pool: pool, Modules: []string{LuaModuleJSON}, Code: `
+        leak = 1
+        string.upper = nil
+        table.extra = 1
+        getmetatable("").__index = {}
+        setmetatable(_G, {__index = function() return "ghost" end})
+        return {}`}
+    if _, err := dirty.Execute(context.Background(), map[string]interface{}{"a": 1}); err != nil {
+        t.Fatalf("Execute failed: %v", err)
+    }
+    if len(pool.idle) != 1 {
+        t.Fatalf("Expected the state to be kept, got %d idle states", len(pool.idle))
+    }
+    first := pool.idle[0].L
+
+    check := &LuaExecutor{Pool: pool, Code: `return {
+        leak = leak == nil, upper = string.upper("a"), extra = table.extra == nil,
+        method = ("b"):upper(), json = json == nil, inputs = inputs.a == nil,
+    }`}
+    got, err := check.Execute(context.Background(), nil)
+    if err != nil {
+        t.Fatalf("Execute failed: %v", err)
+    }
+    want := map[string]interface{}{"leak": true, "upper": "A", "extra": true, "method": "B", "json": true, "inputs": true}
+    for key, value := range want {
+        if got[key] != value {
+            t.Errorf("Expected %s to be %v, got %v", key, value, got[key])
+        }
+    }
+    if len(pool.idle) != 1 || pool.idle[0].L != first {
+        t.Error("Expected the second run to reuse the state")
+    }
+
+    // A state whose script failed is not reused
+    if _, err := (&LuaExecutor{Pool: pool, Code: `error("boom")`}).Execute(context.Background(), nil); err == nil {
+        t.Fatal("Expected the script to fail")
+    }
+    if len(pool.idle) != 0 {
+        t.Errorf("Expected the failed state to be discarded, got %d idle states", len(pool.idle))
+    }
+}
+
+func TestLuaStatePoolLimits(t *testing.T) {
+    pool, err := NewLuaStatePool(&LuaSandbox{MaxSteps: 10000}, 2)
+    if err != nil {
+        t.Fatalf("NewLuaStatePool failed: %v", err)
+    }
+    defer pool.Close()
+
+    // The step budget is per run, not per state
+    executor := &LuaExecutor{Pool: pool, Code: `local n = 0; for i = 1, 1000 do n = n + i end; return {n = n}`}
+    for i := 0; i < 5; i++ {
+        if _, err := executor.Execute(context.Background(), nil); err != nil {
+            t.Fatalf("Run %d failed: %v", i, err)
+        }
+    }
+    if _, err := (&LuaExecutor{Pool: pool, Code: `while true do end`}).Execute(context.Background(), nil); err == nil {
+        t.Error("Expected the pool's sandbox to limit steps")
+    }
+
+    if _, err := NewLuaStatePool(&LuaSandbox{Libraries: []string{"socket"}}, 0); err == nil {
+        t.Error("Expected an error for an invalid sandbox")
+    }
+}
+
+func TestLuaStatePoolConcurrentNodes(t *testing.T) {
+    pool, err := NewLuaStatePool(nil, 4)
+    if err != nil {
+        t.Fatalf("NewLuaStatePool failed: %v", err)
+    }
+    defer pool.Close()
+
+    engine := NewDAGEngine()
+    for i := 0; i < 20; i++ {
+        engine.AddNode(NewNode(fmt.Sprintf("n%d", i), nil, &LuaExecutor{
+            Pool: pool,
+            Code: fmt.Sprintf(`assert(seen == nil); seen = true; return {id = %d}`, i),
+        }))
+    }
+    result, err := engine.Run(context.Background(), nil)
+    if err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+    for i := 0; i < 20; i++ {
+        id := fmt.Sprintf("n%d", i)
+        if got := result.Outputs[id]["id"]; got != float64(i) {
+            t.Errorf("Expected %s to return %d, got %v", id, i, got)
+        }
+    }
+}
+
+func TestCompileLuaCachesProtos(t *testing.T) {
+    first, err := compileLua(`return {cached = true}`)
+    if err != nil {
+        t.Fatalf("compileLua failed: %v", err)
+    }
+    second, _ := compileLua(`return {cached = true}`)
+    if first != second {
+        t.Error("Expected the same code to be compiled once")
+    }
+    if _, err := compileLua(`return {`); err == nil {
+        t.Error("Expected a syntax error")
+    }
+}
+
+const benchmarkLuaCode = `
+    local total = 0
+    for _, v in ipairs(inputs.values) do total = total + v end
+    return {total = total, label = string.format("n=%d", #inputs.values)}`
+
+func BenchmarkLuaExecutor(b *testing.B) {
+    inputs := map[string]interface{}{"values": []interface{}{1, 2, 3, 4, 5}}
+    pool, err := NewLuaStatePool(nil, 0)
+    if err != nil {
+        b.Fatalf("NewLuaStatePool failed: %v", err)
+    }
+    defer pool.Close()
+
+    executors := []struct {
+        name     string
+        executor *LuaExecutor
+    }{
+        {"new state", &LuaExecutor{Code: benchmarkLuaCode}},
+        {"pooled", &LuaExecutor{Code: benchmarkLuaCode, Pool: pool}},
+    }
+    for _, e := range executors {
+        b.Run(e.name, func(b *testing.B) {
+            b.ReportAllocs()
+            b.RunParallel(func(pb *testing.PB) {
+                for pb.Next() {
+                    if _, err := e.executor.Execute(context.Background(), inputs); err != nil {
+                        b.Fatalf("Execute failed: %v", err)
+                    }
+                }
+            })
+        })
+    }
+}
+
+func BenchmarkCompileLua(b *testing.B) {
+    b.Run("parse", func(b *testing.B) {
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            if _, err := compileLuaSource(benchmarkLuaCode); err != nil {
+                b.Fatalf("compileLuaSource failed: %v", err)
+            }
+        }
+    })
+    b.Run("cached", func(b *testing.B) {
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            if _, err := compileLua(benchmarkLuaCode); err != nil {
+                b.Fatalf("compileLua failed: %v", err)
+            }
+        }
+    })
+}
@@ -0,0 +1,222 @@
+package dagengine
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+
+    lua "github.com/yuin/gopher-lua"
+)
+
+// ErrLuaStepLimit is wrapped by the error of a script that exceeded
+// LuaSandbox.MaxSteps.
+var ErrLuaStepLimit = errors.New("lua step limit exceeded")
+
+// Lua standard library names, for LuaSandbox.Libraries.
+const (
+    LuaLibBase      = "base" // Base functions such as print, pairs and pcall
+    LuaLibPackage   = "package"
+    LuaLibTable     = "table"
+    LuaLibString    = "string"
+    LuaLibMath      = "math"
+    LuaLibCoroutine = "coroutine"
+    LuaLibOS        = "os"
+    LuaLibIO        = "io"
+    LuaLibDebug     = "debug"
+    LuaLibChannel   = "channel"
+)
+
+// DefaultLuaLibraries are the libraries that cannot reach outside the script.
+var DefaultLuaLibraries = []string{LuaLibBase, LuaLibTable, LuaLibString, LuaLibMath, LuaLibCoroutine}
+
+// luaLibraries maps library names to their openers, in the order the
+// libraries must be opened.
+var luaLibraries = []struct {
+    name string
+    open lua.LGFunction
+}{
+    {LuaLibPackage, lua.OpenPackage},
+    {LuaLibBase, lua.OpenBase},
+    {LuaLibTable, lua.OpenTable},
+    {LuaLibIO, lua.OpenIo},
+    {LuaLibOS, lua.OpenOs},
+    {LuaLibString, lua.OpenString},
+    {LuaLibMath, lua.OpenMath},
+    {LuaLibDebug, lua.OpenDebug},
+    {LuaLibChannel, lua.OpenChannel},
+    {LuaLibCoroutine, lua.OpenCoroutine},
+}
+
+// luaLoaders are the base functions that load code from files or strings,
+// removed unless LuaSandbox.AllowLoad is set.
+var luaLoaders = []string{"dofile", "loadfile", "load", "loadstring", "require", "module"}
+
+// LuaSandbox restricts what a Lua script can do and how many resources it
+// can use. Whatever the sandbox, a script stops when the context of its
+// node is cancelled or times out.
+type LuaSandbox struct {
+    Libraries       []string // Standard libraries opened (default: DefaultLuaLibraries)
+    AllowLoad       bool     // Keep dofile, loadfile, load, loadstring, require and module
+    MaxSteps        int      // VM instructions the script may execute; 0 means no limit. Calls into Go functions count as one step.
+    CallStackSize   int      // Maximum depth of nested calls (0: lua.CallStackSize)
+    RegistrySize    int      // Initial size of the value stack (0: lua.RegistrySize)
+    RegistryMaxSize int      // Size the value stack may grow to; below RegistrySize means no growth
+}
+
+// DefaultLuaSandbox returns the sandbox used by LuaExecutors without one:
+// only the libraries in DefaultLuaLibraries, no loading of other code and
+// a budget of 100 million steps.
+func DefaultLuaSandbox() *LuaSandbox {
+    return &LuaSandbox{
+        Libraries:       append([]string(nil), DefaultLuaLibraries...),
+        MaxSteps:        100_000_000,
+        CallStackSize:   200,
+        RegistrySize:    lua.RegistrySize,
+        RegistryMaxSize: 4 * lua.RegistrySize,
+    }
+}
+
+// Validate checks that the sandbox only names known libraries and has no
+// negative limits.
+func (s *LuaSandbox) Validate() error {
+    for _, name := range s.Libraries {
+        if !knownLuaLibrary(name) {
+            return fmt.Errorf("unknown lua library '%s' (known: %s)", name, strings.Join(sortedLuaLibraries(), ", "))
+        }
+    }
+    if s.MaxSteps < 0 || s.CallStackSize < 0 || s.RegistrySize < 0 || s.RegistryMaxSize < 0 {
+        return fmt.Errorf("lua sandbox limits must not be negative")
+    }
+    return nil
+}
+
+// knownLuaLibrary reports whether name is a Lua standard library.
+func knownLuaLibrary(name string) bool {
+    for _, lib := range luaLibraries {
+        if lib.name == name {
+            return true
+        }
+    }
+    return false
+}
+
+// newState creates a Lua state with the sandbox's libraries and limits,
+// interrupted when ctx is done. The returned context reports whether the
+// step budget ran out.
+func (s *LuaSandbox) newState(ctx context.Context) (*lua.LState, *luaStepContext, error) {
+    if err := s.Validate(); err != nil {
+        return nil, nil, err
+    }
+    L := s.open()
+    return L, s.limit(ctx, L), nil
+}
+
+// open creates a Lua state with the sandbox's libraries and limits. The
+// sandbox must be valid.
+func (s *LuaSandbox) open() *lua.LState {
+    L := lua.NewState(lua.Options{
+        CallStackSize:   s.CallStackSize,
+        RegistrySize:    s.RegistrySize,
+        RegistryMaxSize: s.RegistryMaxSize,
+        SkipOpenLibs:    true,
+    })
+
+    libraries := s.Libraries
+    if libraries == nil {
+        libraries = DefaultLuaLibraries
+    }
+    enabled := make(map[string]bool, len(libraries))
+    for _, name := range libraries {
+        enabled[name] = true
+    }
+    for _, lib := range luaLibraries {
+        if !enabled[lib.name] {
+            continue
+        }
+        name := lib.name
+        if name == LuaLibBase {
+            name = lua.BaseLibName
+        }
+        L.Push(L.NewFunction(lib.open))
+        L.Push(lua.LString(name))
+        L.Call(1, 0)
+    }
+    if !s.AllowLoad {
+        for _, name := range luaLoaders {
+            L.SetGlobal(name, lua.LNil)
+        }
+    }
+
+    return L
+}
+
+// limit makes L stop when ctx is done or the sandbox's step budget runs out.
+func (s *LuaSandbox) limit(ctx context.Context, L *lua.LState) *luaStepContext {
+    stepCtx := &luaStepContext{Context: ctx, limit: s.MaxSteps}
+    L.SetContext(stepCtx)
+    return stepCtx
+}
+
+// luaStepContext wraps the context of a Lua state to count executed
+// instructions: gopher-lua checks Done before every instruction when the
+// state has a context. It is only used by the goroutine running the state.
+type luaStepContext struct {
+    context.Context
+    limit int // 0 means no limit
+    steps int
+}
+
+// closedChan is returned by Done once the step budget is exhausted.
+var closedChan = func() chan struct{} {
+    ch := make(chan struct{})
+    close(ch)
+    return ch
+}()
+
+// Done counts a step and reports a closed channel once the budget is spent.
+func (c *luaStepContext) Done() <-chan struct{} {
+    c.steps++
+    if c.exceeded() {
+        return closedChan
+    }
+    return c.Context.Done()
+}
+
+// Err reports ErrLuaStepLimit once the budget is spent.
+func (c *luaStepContext) Err() error {
+    if c.exceeded() {
+        return ErrLuaStepLimit
+    }
+    return c.Context.Err()
+}
+
+// exceeded reports whether the script ran out of steps.
+func (c *luaStepContext) exceeded() bool {
+    return c.limit > 0 && c.steps > c.limit
+}
+
+// luaError converts the error of a script run under stepCtx so that running
+// out of steps wraps ErrLuaStepLimit and an interruption by the context
+// wraps the context's error.
+func luaError(err error, stepCtx *luaStepContext) error {
+    switch {
+    case stepCtx.exceeded():
+        return fmt.Errorf("lua execution error: %w after %d steps", ErrLuaStepLimit, stepCtx.limit)
+    case stepCtx.Context.Err() != nil:
+        return fmt.Errorf("lua execution interrupted: %w", stepCtx.Context.Err())
+    default:
+        return fmt.Errorf("lua execution error: %w", err)
+    }
+}
+
+// sortedLuaLibraries returns the names of all Lua standard libraries.
+func sortedLuaLibraries() []string {
+    names := make([]string, 0, len(luaLibraries))
+    for _, lib := range luaLibraries {
+        names = append(names, lib.name)
+    }
+    sort.Strings(names)
+    return names
+}
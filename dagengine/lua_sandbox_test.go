@@ -0,0 +1,133 @@
+package dagengine
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestLuaSandboxLibraries(t *testing.T) {
+    tests := []struct {
+        name    string
+        sandbox *LuaSandbox
+        code    string
+        want    map[string]interface{}
+    }{
+        {
+            name: "default sandbox hides unsafe globals",
+            code: `return {
+                os = os == nil, io = io == nil, debug = debug == nil, package = package == nil,
+                dofile = dofile == nil, loadfile = loadfile == nil, load = load == nil,
+                loadstring = loadstring == nil, require = require == nil,
+            }`,
+            want: map[string]interface{}{
+                "os": true, "io": true, "debug": true, "package": true,
+                "dofile": true, "loadfile": true, "load": true, "loadstring": true, "require": true,
+            },
+        },
+        {
+            name: "default sandbox keeps safe libraries",
+            code: `return {s = string.upper("a") .. ("b"):rep(2), m = math.max(1, 2), t = table.concat({"x", "y"}, ",")}`,
+            want: map[string]interface{}{"s": "Abb", "m": float64(2), "t": "x,y"},
+        },
+        {
+            name:    "whitelisted library",
+            sandbox: &LuaSandbox{Libraries: []string{LuaLibBase, LuaLibOS}},
+            code:    `return {clock = type(os.clock), string = string == nil}`,
+            want:    map[string]interface{}{"clock": "function", "string": true},
+        },
+        {
+            name:    "loading allowed",
+            sandbox: &LuaSandbox{Libraries: []string{LuaLibBase}, AllowLoad: true},
+            code:    `return {value = loadstring("return 42")()}`,
+            want:    map[string]interface{}{"value": float64(42)},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := (&LuaExecutor{Code: tt.code, Sandbox: tt.sandbox}).Execute(context.Background(), nil)
+            if err != nil {
+                t.Fatalf("Execute failed: %v", err)
+            }
+            for key, want := range tt.want {
+                if got[key] != want {
+                    t.Errorf("Expected %s to be %v, got %v", key, want, got[key])
+                }
+            }
+        })
+    }
+}
+
+func TestLuaSandboxLimits(t *testing.T) {
+    tests := []struct {
+        name    string
+        sandbox *LuaSandbox
+        code    string
+        want    string
+    }{
+        {
+            name:    "step budget",
+            sandbox: &LuaSandbox{MaxSteps: 10000},
+            code:    `while true do end`,
+            want:    "step limit exceeded",
+        },
+        {
+            name:    "call stack",
+            sandbox: &LuaSandbox{CallStackSize: 50},
+            code:    `local function f(n) return 1 + f(n + 1) end; return {v = f(1)}`,
+            want:    "stack overflow",
+        },
+        {
+            name:    "registry",
+            sandbox: &LuaSandbox{RegistrySize: 1024, RegistryMaxSize: 2048},
+            code:    `local t = {}; for i = 1, 10000 do t[i] = i end; return {n = select("#", unpack(t))}`,
+            want:    "registry overflow",
+        },
+        {
+            name:    "unknown library",
+            sandbox: &LuaSandbox{Libraries: []string{"socket"}},
+            code:    `return {}`,
+            want:    "unknown lua library 'socket'",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := (&LuaExecutor{Code: tt.code, Sandbox: tt.sandbox}).Execute(context.Background(), nil)
+            if err == nil || !strings.Contains(err.Error(), tt.want) {
+                t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+            }
+        })
+    }
+
+    _, err := (&LuaExecutor{Code: `while true do end`, Sandbox: &LuaSandbox{MaxSteps: 1000}}).Execute(context.Background(), nil)
+    if !errors.Is(err, ErrLuaStepLimit) {
+        t.Errorf("Expected the error to wrap ErrLuaStepLimit, got %v", err)
+    }
+}
+
+func TestLuaContextInterruptsScript(t *testing.T) {
+    ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+    defer cancel()
+
+    start := time.Now()
+    _, err := (&LuaExecutor{Code: `while true do end`, Sandbox: &LuaSandbox{}}).Execute(ctx, nil)
+    if !errors.Is(err, context.DeadlineExceeded) {
+        t.Errorf("Expected the error to wrap context.DeadlineExceeded, got %v", err)
+    }
+    if elapsed := time.Since(start); elapsed > time.Second {
+        t.Errorf("Expected the script to stop at the deadline, ran for %v", elapsed)
+    }
+
+    // A node timeout stops a runaway script
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("spin", nil, &LuaExecutor{Code: `while true do end`}))
+    engine.Nodes["spin"].Timeout = 50 * time.Millisecond
+    result, _ := engine.Run(context.Background(), nil)
+    if result.NodeStatuses["spin"] != StatusTimedOut {
+        t.Errorf("Expected spin to be TIMED_OUT, got %s", result.NodeStatuses["spin"])
+    }
+}
@@ -0,0 +1,195 @@
+package dagengine
+
+import (
+    "context"
+    "fmt"
+    "reflect"
+    "strings"
+    "sync"
+)
+
+// MapIndexKey is the input under which a map element's position in the list is exposed.
+const MapIndexKey = "index"
+
+// MapConfig turns a node into a map node: instead of running its task once,
+// the engine runs it once per element of a list found in the node's inputs.
+//
+// Every element runs as its own node, registered at run time with the ID
+// "<map node ID>[<index>]", so it gets its own status, attempts and result.
+// The map node completes with {"results": [...], "count": N}, where results
+// holds the element results in list order; it fails if any element fails.
+// Elements are bounded by Concurrency only: the map node holds its own
+// engine and pool slots while its elements run.
+type MapConfig struct {
+    Over        string // Dot path to the list in the node's inputs, e.g. "discover.files"
+    As          string // Input name each element is exposed under (default "item")
+    Concurrency int    // Maximum number of elements running at once; 0 means no limit
+}
+
+// itemName returns the input name each element is exposed under.
+func (m *MapConfig) itemName() string {
+    if m.As != "" {
+        return m.As
+    }
+    return "item"
+}
+
+// Validate checks that the list is read from one of the node's dependencies
+// or from the workflow inputs, and that the element name does not hide a
+// dependency's result.
+func (m *MapConfig) Validate(dependencies []string) error {
+    if m.Over == "" {
+        return fmt.Errorf("map node requires a list to map over")
+    }
+    if m.As == WorkflowInputsKey || m.As == MapIndexKey {
+        return fmt.Errorf("map element name '%s' is reserved", m.As)
+    }
+    if containsString(dependencies, m.itemName()) {
+        return fmt.Errorf("map element name '%s' is the name of a dependency", m.itemName())
+    }
+    if m.Concurrency < 0 {
+        return fmt.Errorf("map concurrency must not be negative: %d", m.Concurrency)
+    }
+    source := strings.SplitN(m.Over, ".", 2)[0]
+    if source != WorkflowInputsKey && !containsString(dependencies, source) {
+        return fmt.Errorf("map over '%s' does not read from one of the node's dependencies", m.Over)
+    }
+    return nil
+}
+
+// items resolves the list to map over from the node's inputs.
+func (m *MapConfig) items(inputs map[string]interface{}) ([]interface{}, error) {
+    value, found := lookupPath(inputs, m.Over)
+    if !found {
+        return nil, fmt.Errorf("map over '%s': field not found", m.Over)
+    }
+    if list, ok := value.([]interface{}); ok {
+        return list, nil
+    }
+
+    // Accept typed slices such as []string from Go executors
+    v := reflect.ValueOf(value)
+    if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+        return nil, fmt.Errorf("map over '%s': expected a list, got %T", m.Over, value)
+    }
+    list := make([]interface{}, v.Len())
+    for i := range list {
+        list[i] = v.Index(i).Interface()
+    }
+    return list, nil
+}
+
+// runMap expands a map node into one node per element, runs them with at
+// most Map.Concurrency in flight and collects their results in order.
+func (r *Run) runMap(ctx context.Context, n *Node, inputs map[string]interface{}) (map[string]interface{}, error) {
+    items, err := n.Map.items(inputs)
+    if err != nil {
+        return nil, err
+    }
+
+    // Register one node per element so that it is visible in the run result
+    elements := make([]*Node, len(items))
+    for i := range items {
+        element := NewNode(fmt.Sprintf("%s[%d]", n.ID, i), nil, n.Task)
+        element.Retry = n.Retry
+        element.Timeout = n.Timeout
+        element.Cache = n.Cache
+        element.MappedFrom = n.ID
+        if err := r.addNode(element); err != nil {
+            return nil, err
+        }
+        elements[i] = element
+    }
+
+    // Under FailFast the first failed element cancels its siblings
+    mapCtx, cancel := context.WithCancel(ctx)
+    defer cancel()
+
+    limit := n.Map.Concurrency
+    if limit <= 0 || limit > len(elements) {
+        limit = len(elements)
+    }
+    slots := make(chan struct{}, limit)
+
+    var wg sync.WaitGroup
+    for i, element := range elements {
+        elementInputs := make(map[string]interface{}, len(inputs)+2)
+        for k, v := range inputs {
+            elementInputs[k] = v
+        }
+        elementInputs[n.Map.itemName()] = items[i]
+        elementInputs[MapIndexKey] = i
+
+        wg.Add(1)
+        go func(element *Node, elementInputs map[string]interface{}) {
+            defer wg.Done()
+
+            select {
+            case slots <- struct{}{}:
+                defer func() { <-slots }()
+            case <-mapCtx.Done():
+                r.finishElement(element, StatusSkipped, nil, nil)
+                return
+            }
+            if mapCtx.Err() != nil {
+                r.finishElement(element, StatusSkipped, nil, nil)
+                return
+            }
+
+            r.startNode(element)
+            result, err := r.runTask(mapCtx, element, elementInputs)
+            if err != nil {
+                r.finishElement(element, failureStatus(mapCtx, err), nil, err)
+                if mapCtx.Err() == nil && (r.plan.policy == FailFast || r.plan.policy == "") {
+                    cancel()
+                }
+                return
+            }
+            r.finishElement(element, StatusCompleted, result, nil)
+        }(element, elementInputs)
+    }
+    wg.Wait()
+
+    results := make([]interface{}, len(elements))
+    failures := make(map[string]error)
+    for i, element := range elements {
+        state := r.state(element.ID)
+        state.mu.RLock()
+        switch state.status {
+        case StatusCompleted:
+            results[i] = state.result
+        case StatusFailed, StatusTimedOut:
+            failures[element.ID] = state.err
+        }
+        state.mu.RUnlock()
+    }
+
+    if len(failures) > 0 {
+        return nil, &RunError{Failures: failures}
+    }
+    if ctx.Err() != nil {
+        return nil, ctx.Err()
+    }
+    return map[string]interface{}{"results": results, "count": len(results)}, nil
+}
+
+// finishElement records a map element's terminal state and reports it to the
+// observers. Elements have no children, so nothing is triggered.
+func (r *Run) finishElement(n *Node, status string, result map[string]interface{}, err error) {
+    r.setStatus(n, status, result, err)
+    r.notifyNodeDone(n)
+}
+
+// addNode registers a node spawned while the run is in progress.
+// Elements have no children of their own, so they never trigger other nodes.
+func (r *Run) addNode(n *Node) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if _, exists := r.nodes[n.ID]; exists {
+        return fmt.Errorf("node with ID '%s' already exists", n.ID)
+    }
+    r.nodes[n.ID] = n
+    r.states[n.ID] = &nodeState{status: StatusPending}
+    return nil
+}
@@ -0,0 +1,162 @@
+package dagengine
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+func TestRunMapNode(t *testing.T) {
+    var mu sync.Mutex
+    running, maxRunning := 0, 0
+    process := ExecutorFunc(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+        mu.Lock()
+        running++
+        if running > maxRunning {
+            maxRunning = running
+        }
+        mu.Unlock()
+
+        time.Sleep(5 * time.Millisecond)
+
+        mu.Lock()
+        running--
+        mu.Unlock()
+        return map[string]interface{}{"file": inputs["file"], "index": inputs[MapIndexKey]}, nil
+    })
+    sink := &recordingExecutor{result: map[string]interface{}{}}
+
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("discover", nil, &recordingExecutor{result: map[string]interface{}{
+        "files": []string{"a.csv", "b.csv", "c.csv", "d.csv"},
+    }}))
+    mapNode := NewNode("process", []string{"discover"}, process)
+    mapNode.Map = &MapConfig{Over: "discover.files", As: "file", Concurrency: 2}
+    engine.AddNode(mapNode)
+    engine.AddNode(NewNode("load", []string{"process"}, sink))
+    if err := engine.PreprocessDAG(); err != nil {
+        t.Fatalf("PreprocessDAG failed: %v", err)
+    }
+
+    result, err := engine.Run(context.Background(), nil)
+    if err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+
+    if maxRunning > 2 {
+        t.Errorf("Expected at most 2 elements running at once, got %d", maxRunning)
+    }
+    for i := 0; i < 4; i++ {
+        id := fmt.Sprintf("process[%d]", i)
+        if result.NodeStatuses[id] != StatusCompleted {
+            t.Errorf("Expected element %s COMPLETED, got %s", id, result.NodeStatuses[id])
+        }
+    }
+
+    processed, ok := sink.seen()["process"].(map[string]interface{})
+    if !ok {
+        t.Fatalf("Expected load to receive the map node's result, got %v", sink.seen())
+    }
+    results, ok := processed["results"].([]interface{})
+    if !ok || len(results) != 4 || processed["count"] != 4 {
+        t.Fatalf("Expected 4 collected results, got %v", processed)
+    }
+    for i, want := range []string{"a.csv", "b.csv", "c.csv", "d.csv"} {
+        if got := results[i].(map[string]interface{})["file"]; got != want {
+            t.Errorf("Expected results[%d] to be %s, got %v", i, want, got)
+        }
+    }
+
+    // Elements belong to the run, not to the engine's graph
+    if _, exists := engine.Nodes["process[0]"]; exists {
+        t.Error("Expected map elements not to be added to the engine's nodes")
+    }
+}
+
+func TestRunMapNodeFailures(t *testing.T) {
+    tests := []struct {
+        name   string
+        items  interface{}
+        policy FailurePolicy
+        want   map[string]string
+    }{
+        {
+            name:   "element failure fails the map node",
+            items:  []interface{}{1, 2, 3},
+            policy: ContinueIndependent,
+            want: map[string]string{
+                "process": StatusFailed, "process[0]": StatusCompleted, "process[1]": StatusFailed,
+                "process[2]": StatusCompleted, "load": StatusUpstreamFailed,
+            },
+        },
+        {
+            name:   "not a list",
+            items:  "a.csv",
+            policy: FailFast,
+            want:   map[string]string{"process": StatusFailed, "load": StatusUpstreamFailed},
+        },
+        {
+            name:   "empty list",
+            items:  []interface{}{},
+            policy: FailFast,
+            want:   map[string]string{"process": StatusCompleted, "load": StatusCompleted},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            process := ExecutorFunc(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+                if inputs["item"] == 2 {
+                    return nil, errors.New("bad item")
+                }
+                return map[string]interface{}{}, nil
+            })
+
+            engine := NewDAGEngine()
+            engine.Policy = tt.policy
+            engine.AddNode(NewNode("discover", nil, &recordingExecutor{result: map[string]interface{}{"items": tt.items}}))
+            mapNode := NewNode("process", []string{"discover"}, process)
+            mapNode.Map = &MapConfig{Over: "discover.items"}
+            engine.AddNode(mapNode)
+            engine.AddNode(NewNode("load", []string{"process"}, &recordingExecutor{result: map[string]interface{}{}}))
+            if err := engine.PreprocessDAG(); err != nil {
+                t.Fatalf("PreprocessDAG failed: %v", err)
+            }
+
+            result, _ := engine.Run(context.Background(), nil)
+            for id, want := range tt.want {
+                if got := result.NodeStatuses[id]; got != want {
+                    t.Errorf("Expected node %s status %s, got %s", id, want, got)
+                }
+            }
+        })
+    }
+}
+
+func TestPreprocessRejectsMapOverNonDependency(t *testing.T) {
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("discover", nil, &recordingExecutor{}))
+    mapNode := NewNode("process", nil, &recordingExecutor{})
+    mapNode.Map = &MapConfig{Over: "discover.files"}
+    engine.AddNode(mapNode)
+
+    if err := engine.PreprocessDAG(); err == nil {
+        t.Error("Expected error for map over a non-dependency, got none")
+    }
+}
+
+func TestPreprocessRejectsMapElementNamedAfterDependency(t *testing.T) {
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("item", nil, &recordingExecutor{}))
+    mapNode := NewNode("process", []string{"item"}, &recordingExecutor{})
+    mapNode.Map = &MapConfig{Over: "item.files"}
+    engine.AddNode(mapNode)
+
+    if err := engine.PreprocessDAG(); err == nil || !strings.Contains(err.Error(), "name of a dependency") {
+        t.Errorf("Expected error for a map element named after a dependency, got %v", err)
+    }
+}
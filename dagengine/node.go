@@ -1,20 +1,28 @@
 package dagengine
 
 import (
-    "sync"
+    "time"
 )
 
-// Node represents a single step in the DAG.
+// Node represents a single step in the DAG. A Node only holds its
+// definition; the state of each execution is kept by the Run.
 type Node struct {
     ID           string
     Dependencies []string            // IDs of prerequisite nodes
     Task         Executor            // The actual logic runner (e.g., LuaExecutor)
     Children     []string            // IDs of nodes that depend on this one
-    // Internal state for the scheduler
-    Result       map[string]interface{}
-    Status       string              // "PENDING", "RUNNING", "COMPLETED", "FAILED"
-    ReadyCounter int                 // Tracks unfulfilled dependencies
-    mu           sync.RWMutex        // Lock for thread-safe state updates
+    Inputs       []InputMapping      // Optional explicit mapping of upstream outputs to inputs
+    Retry        *RetryPolicy        // Optional retry policy; nil means a single attempt
+    Timeout      time.Duration       // Deadline for each attempt; 0 means no limit
+    TriggerRule  TriggerRule         // When the node runs given its parents' outcomes (default: AllSuccess)
+    Condition    string              // Optional expression; the node is SKIPPED when it is false
+    condition    *Condition          // Compiled Condition, set by PreprocessDAG
+    Map          *MapConfig          // If set, Task runs once per element of an upstream list
+    MappedFrom   string              // ID of the map node that spawned this node at run time
+    Priority     int                 // Nodes with higher priority start first when capacity is scarce
+    Pool         string              // Optional resource pool (see DAGEngine.ResourcePools) the node claims slots of
+    PoolSlots    int                 // Slots claimed in Pool while running (default 1)
+    Cache        *CacheConfig        // If set, results are reused from DAGEngine.Cache for identical inputs
 }
 
 // NewNode is a constructor for creating a Node instance.
@@ -23,8 +31,5 @@ func NewNode(id string, deps []string, task Executor) *Node {
         ID: id,
         Dependencies: deps,
         Task: task,
-        Status: "PENDING",
-        ReadyCounter: len(deps), // Initialize counter based on dependencies
-        Result: make(map[string]interface{}),
     }
-}
\ No newline at end of file
+}
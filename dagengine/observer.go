@@ -0,0 +1,184 @@
+package dagengine
+
+import (
+    "context"
+    "time"
+)
+
+// Observer receives the lifecycle events of the runs started by an engine.
+//
+// Methods are called synchronously from the run's goroutines, so
+// implementations must be safe for concurrent use and should return quickly.
+// For a given run, OnRunStart is called before any node event and
+// OnRunComplete after all of them, before Wait returns.
+type Observer interface {
+    // OnRunStart is called when a run begins, before any node is started.
+    OnRunStart(event RunEvent)
+    // OnNodeStart is called when a node's task (or a map element) starts.
+    OnNodeStart(event NodeEvent)
+    // OnNodeRetry is called when an attempt failed and another one is scheduled.
+    OnNodeRetry(event NodeEvent)
+    // OnNodeComplete is called when a node reaches a terminal state that is
+    // not a failure: COMPLETED (reported as CACHED when the result came from
+    // the cache), SKIPPED or UPSTREAM_FAILED.
+    OnNodeComplete(event NodeEvent)
+    // OnNodeFail is called when a node ends FAILED, TIMED_OUT or CANCELLED.
+    OnNodeFail(event NodeEvent)
+    // OnRunComplete is called once every node of the run has finished.
+    OnRunComplete(event RunEvent)
+}
+
+// RunEvent describes a run in Observer callbacks.
+type RunEvent struct {
+    RunID     string
+    StartTime time.Time
+    Duration  time.Duration // Set by OnRunComplete
+    Result    *RunResult    // Set by OnRunComplete
+    Err       error         // Set by OnRunComplete if the run did not complete successfully
+}
+
+// NodeEvent describes a node in Observer callbacks.
+type NodeEvent struct {
+    RunID      string
+    NodeID     string
+    MappedFrom string // ID of the map node that spawned this node, if any
+    Status     string
+    Attempt    int       // Current (or last) attempt; 0 if the task never ran
+    StartTime  time.Time // Zero if the node never started
+    Duration   time.Duration
+    Result     map[string]interface{} // Set by OnNodeComplete for COMPLETED nodes
+    Err        error                  // Set by OnNodeRetry and OnNodeFail
+    RetryDelay time.Duration          // Set by OnNodeRetry: wait before the next attempt
+}
+
+// Log levels, for LogEvent.Level.
+const (
+    LogInfo  = "info"
+    LogWarn  = "warn"
+    LogError = "error"
+)
+
+// LogObserver is implemented by observers that also receive the messages
+// logged by node tasks with Log.
+type LogObserver interface {
+    OnNodeLog(event LogEvent)
+}
+
+// LogEvent is a message logged by a node's task.
+type LogEvent struct {
+    RunID      string
+    NodeID     string
+    MappedFrom string
+    Level      string
+    Message    string
+    Time       time.Time
+}
+
+// NopObserver implements Observer with methods that do nothing. Embed it to
+// implement only the callbacks of interest.
+type NopObserver struct{}
+
+func (NopObserver) OnRunStart(RunEvent)      {}
+func (NopObserver) OnNodeStart(NodeEvent)    {}
+func (NopObserver) OnNodeRetry(NodeEvent)    {}
+func (NopObserver) OnNodeComplete(NodeEvent) {}
+func (NopObserver) OnNodeFail(NodeEvent)     {}
+func (NopObserver) OnRunComplete(RunEvent)   {}
+
+// AddObserver registers an observer for the runs started after the call.
+func (e *DAGEngine) AddObserver(o Observer) {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    e.observers = append(e.observers, o)
+}
+
+// notify calls fn for every observer registered when the run was started.
+func (r *Run) notify(fn func(Observer)) {
+    for _, o := range r.observers {
+        fn(o)
+    }
+}
+
+// nodeEvent snapshots a node's state for an Observer callback.
+func (r *Run) nodeEvent(n *Node) NodeEvent {
+    state := r.state(n.ID)
+    state.mu.RLock()
+    defer state.mu.RUnlock()
+
+    event := NodeEvent{
+        RunID:      r.ID,
+        NodeID:     n.ID,
+        MappedFrom: n.MappedFrom,
+        Status:     state.status,
+        Attempt:    state.attempts,
+        StartTime:  state.startTime,
+        Err:        state.err,
+    }
+    if !state.startTime.IsZero() {
+        event.Duration = time.Since(state.startTime)
+    }
+    if state.status == StatusCompleted {
+        event.Result = state.result
+        if state.cached {
+            event.Status = StatusCached
+        }
+    }
+    return event
+}
+
+// notifyNodeDone reports a node that reached a terminal state.
+func (r *Run) notifyNodeDone(n *Node) {
+    event := r.nodeEvent(n)
+    switch event.Status {
+    case StatusFailed, StatusTimedOut, StatusCancelled:
+        r.notify(func(o Observer) { o.OnNodeFail(event) })
+    default:
+        r.notify(func(o Observer) { o.OnNodeComplete(event) })
+    }
+}
+
+// nodeLogKey is the context key of the node whose task is running.
+type nodeLogKey struct{}
+
+// nodeLog identifies the node a task logs for.
+type nodeLog struct {
+    run  *Run
+    node *Node
+}
+
+// withNodeLog returns a context in which Log reports messages for n.
+func (r *Run) withNodeLog(ctx context.Context, n *Node) context.Context {
+    return context.WithValue(ctx, nodeLogKey{}, &nodeLog{run: r, node: n})
+}
+
+// CurrentNode returns the IDs of the run and node whose task is running
+// with ctx. Outside a run they are empty.
+func CurrentNode(ctx context.Context) (runID, nodeID string) {
+    l, ok := ctx.Value(nodeLogKey{}).(*nodeLog)
+    if !ok {
+        return "", ""
+    }
+    return l.run.ID, l.node.ID
+}
+
+// Log reports a message from the task of the node running with ctx to the
+// run's observers that implement LogObserver. Outside a run it does nothing.
+func Log(ctx context.Context, level, message string) {
+    l, ok := ctx.Value(nodeLogKey{}).(*nodeLog)
+    if !ok {
+        return
+    }
+    event := LogEvent{
+        RunID:      l.run.ID,
+        NodeID:     l.node.ID,
+        MappedFrom: l.node.MappedFrom,
+        Level:      level,
+        Message:    message,
+        Time:       time.Now(),
+    }
+    l.run.notify(func(o Observer) {
+        if lo, ok := o.(LogObserver); ok {
+            lo.OnNodeLog(event)
+        }
+    })
+}
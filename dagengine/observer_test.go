@@ -0,0 +1,123 @@
+package dagengine
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sync"
+    "testing"
+    "time"
+)
+
+// eventRecorder records Observer callbacks as "<callback> <node> <status>" lines.
+type eventRecorder struct {
+    mu     sync.Mutex
+    events []string
+    nodes  map[string]NodeEvent // Last event per node
+}
+
+func (r *eventRecorder) record(line string, event *NodeEvent) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.events = append(r.events, line)
+    if event != nil {
+        if r.nodes == nil {
+            r.nodes = make(map[string]NodeEvent)
+        }
+        r.nodes[event.NodeID] = *event
+    }
+}
+
+func (r *eventRecorder) OnRunStart(e RunEvent) { r.record("run_start", nil) }
+func (r *eventRecorder) OnNodeStart(e NodeEvent) {
+    r.record(fmt.Sprintf("start %s %s", e.NodeID, e.Status), &e)
+}
+func (r *eventRecorder) OnNodeRetry(e NodeEvent) {
+    r.record(fmt.Sprintf("retry %s %d", e.NodeID, e.Attempt), &e)
+}
+func (r *eventRecorder) OnNodeComplete(e NodeEvent) {
+    r.record(fmt.Sprintf("complete %s %s", e.NodeID, e.Status), &e)
+}
+func (r *eventRecorder) OnNodeFail(e NodeEvent) {
+    r.record(fmt.Sprintf("fail %s %s", e.NodeID, e.Status), &e)
+}
+func (r *eventRecorder) OnRunComplete(e RunEvent) {
+    r.record(fmt.Sprintf("run_complete %s", e.Result.Status), nil)
+}
+
+func (r *eventRecorder) contains(line string) bool {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    for _, event := range r.events {
+        if event == line {
+            return true
+        }
+    }
+    return false
+}
+
+func TestObserverReceivesLifecycleEvents(t *testing.T) {
+    engine := NewDAGEngine()
+    engine.Policy = ContinueIndependent
+    engine.AddNode(NewNode("A", nil, &flakyExecutor{failures: 1, err: errors.New("flaky")}))
+    engine.Nodes["A"].Retry = &RetryPolicy{MaxAttempts: 2, InitialDelay: time.Millisecond}
+    engine.AddNode(NewNode("B", []string{"A"}, &flakyExecutor{failures: 1, err: errors.New("boom")}))
+    engine.AddNode(NewNode("C", []string{"B"}, &recordingExecutor{}))
+
+    recorder := &eventRecorder{}
+    engine.AddObserver(recorder)
+
+    if _, err := engine.Run(context.Background(), nil); err == nil {
+        t.Fatal("Expected the run to fail")
+    }
+
+    for _, line := range []string{
+        "start A RUNNING",
+        "retry A 1",
+        "complete A COMPLETED",
+        "start B RUNNING",
+        "fail B FAILED",
+        "complete C UPSTREAM_FAILED",
+    } {
+        if !recorder.contains(line) {
+            t.Errorf("Expected event %q, got %v", line, recorder.events)
+        }
+    }
+    if first := recorder.events[0]; first != "run_start" {
+        t.Errorf("Expected run_start first, got %q", first)
+    }
+    if last := recorder.events[len(recorder.events)-1]; last != "run_complete FAILED" {
+        t.Errorf("Expected run_complete FAILED last, got %q", last)
+    }
+
+    a := recorder.nodes["A"]
+    if a.Attempt != 2 || a.StartTime.IsZero() || a.Result["calls"] != 2 {
+        t.Errorf("Expected A's completion to carry 2 attempts, a start time and its result, got %+v", a)
+    }
+    if b := recorder.nodes["B"]; b.Err == nil || b.Err.Error() != "boom" {
+        t.Errorf("Expected B's failure to carry its error, got %v", b.Err)
+    }
+}
+
+func TestObserverReceivesMapElementEvents(t *testing.T) {
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("items", nil, &recordingExecutor{result: map[string]interface{}{"list": []interface{}{1, 2}}}))
+    engine.AddNode(NewNode("each", []string{"items"}, &recordingExecutor{result: map[string]interface{}{}}))
+    engine.Nodes["each"].Map = &MapConfig{Over: "items.list"}
+
+    recorder := &eventRecorder{}
+    engine.AddObserver(recorder)
+
+    if _, err := engine.Run(context.Background(), nil); err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+
+    for _, id := range []string{"each[0]", "each[1]"} {
+        if !recorder.contains("complete " + id + " COMPLETED") {
+            t.Errorf("Expected a completion event for %s, got %v", id, recorder.events)
+        }
+        if from := recorder.nodes[id].MappedFrom; from != "each" {
+            t.Errorf("Expected %s to be mapped from each, got %q", id, from)
+        }
+    }
+}
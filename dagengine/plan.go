@@ -0,0 +1,178 @@
+package dagengine
+
+import (
+    "fmt"
+    "sort"
+    "time"
+)
+
+// Plan is an immutable, validated graph compiled from a DAGEngine's nodes.
+//
+// A Plan holds only definitions: all per-execution state lives in a Run, so
+// the same Plan can be executed any number of times, concurrently.
+type Plan struct {
+    nodes          map[string]*Node // Private copies of the node definitions
+    ids            []string         // Sorted node IDs
+    roots          []string         // Sorted IDs of the nodes without dependencies
+    policy         FailurePolicy
+    timeout        time.Duration
+    maxConcurrency int
+    resourcePools  map[string]int
+}
+
+// NodeIDs returns the IDs of the plan's nodes in sorted order.
+func (p *Plan) NodeIDs() []string {
+    return append([]string(nil), p.ids...)
+}
+
+// Node returns a copy of the definition of a node in the plan.
+func (p *Plan) Node(id string) (Node, bool) {
+    n, exists := p.nodes[id]
+    if !exists {
+        return Node{}, false
+    }
+    return *n, true
+}
+
+// Policy returns the failure policy runs of this plan use.
+func (p *Plan) Policy() FailurePolicy {
+    return p.policy
+}
+
+// Downstream returns the node from and every node reachable from it through
+// Children, in sorted order.
+func (p *Plan) Downstream(from string) ([]string, error) {
+    if _, exists := p.nodes[from]; !exists {
+        return nil, fmt.Errorf("node '%s' not found in plan", from)
+    }
+
+    seen := map[string]bool{from: true}
+    queue := []string{from}
+    for len(queue) > 0 {
+        current := queue[0]
+        queue = queue[1:]
+        for _, childID := range p.nodes[current].Children {
+            if !seen[childID] {
+                seen[childID] = true
+                queue = append(queue, childID)
+            }
+        }
+    }
+
+    ids := make([]string, 0, len(seen))
+    for id := range seen {
+        ids = append(ids, id)
+    }
+    sort.Strings(ids)
+    return ids, nil
+}
+
+// Compile validates the engine's nodes and returns an immutable Plan of the
+// current graph. Later changes to the engine's nodes do not affect the Plan.
+func (e *DAGEngine) Compile() (*Plan, error) {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    return e.compileLocked()
+}
+
+// compileLocked builds a Plan from e.Nodes. The caller must hold e.mu.
+func (e *DAGEngine) compileLocked() (*Plan, error) {
+    // 1. Validate the graph structure: missing or duplicate dependencies,
+    // self-dependencies, cycles and unreachable nodes
+    ids := make([]string, 0, len(e.Nodes))
+    deps := make(map[string][]string, len(e.Nodes))
+    for id, node := range e.Nodes {
+        ids = append(ids, id)
+        deps[id] = node.Dependencies
+    }
+    sort.Strings(ids)
+
+    if err := ValidateGraph(ids, deps); err != nil {
+        return nil, err
+    }
+
+    plan := &Plan{
+        nodes:          make(map[string]*Node, len(ids)),
+        ids:            ids,
+        policy:         e.Policy,
+        timeout:        e.Timeout,
+        maxConcurrency: e.MaxConcurrency,
+        resourcePools:  make(map[string]int, len(e.ResourcePools)),
+    }
+    for name, size := range e.ResourcePools {
+        plan.resourcePools[name] = size
+    }
+
+    // 2. Copy the node definitions so that the plan cannot change under a run
+    for _, id := range ids {
+        node := *e.Nodes[id]
+        node.Dependencies = append([]string(nil), node.Dependencies...)
+        node.Inputs = append([]InputMapping(nil), node.Inputs...)
+        node.Children = nil
+        node.condition = nil
+        plan.nodes[id] = &node
+        if len(node.Dependencies) == 0 {
+            plan.roots = append(plan.roots, id)
+        }
+    }
+
+    // 3. Build the Children (Adjacency) list and validate every node
+    for _, childID := range ids {
+        childNode := plan.nodes[childID]
+        for _, parentID := range childNode.Dependencies {
+            // Append the child's ID to the parent's Children list
+            parentNode := plan.nodes[parentID]
+            parentNode.Children = append(parentNode.Children, childID)
+        }
+
+        if err := plan.validatePool(childNode); err != nil {
+            return nil, err
+        }
+
+        if err := validateCache(childNode); err != nil {
+            return nil, err
+        }
+
+        if !childNode.TriggerRule.Valid() {
+            return nil, fmt.Errorf("node '%s' has unsupported trigger rule '%s'", childID, childNode.TriggerRule)
+        }
+
+        // Compile the condition once so that syntax errors are reported before the run
+        if childNode.Condition != "" {
+            condition, err := CompileCondition(childNode.Condition)
+            if err != nil {
+                return nil, fmt.Errorf("node '%s': %w", childID, err)
+            }
+            childNode.condition = condition
+        }
+
+        // Map nodes may only iterate over a dependency's result or the workflow inputs
+        if childNode.Map != nil {
+            if err := childNode.Map.Validate(childNode.Dependencies); err != nil {
+                return nil, fmt.Errorf("node '%s': %w", childID, err)
+            }
+        }
+
+        if err := validateInputs(childNode); err != nil {
+            return nil, err
+        }
+    }
+
+    return plan, nil
+}
+
+// validatePool checks that a node claims slots of a declared pool that can
+// ever satisfy it; otherwise the node would wait forever.
+func (p *Plan) validatePool(n *Node) error {
+    if n.Pool == "" {
+        return nil
+    }
+    size, exists := p.resourcePools[n.Pool]
+    if !exists {
+        return fmt.Errorf("node '%s' uses undefined resource pool '%s'", n.ID, n.Pool)
+    }
+    if n.poolSlots() > size {
+        return fmt.Errorf("node '%s' claims %d slots of resource pool '%s', which only has %d", n.ID, n.poolSlots(), n.Pool, size)
+    }
+    return nil
+}
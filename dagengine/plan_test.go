@@ -0,0 +1,94 @@
+package dagengine
+
+import (
+    "context"
+    "fmt"
+    "sync"
+    "testing"
+)
+
+func TestPlanRunsConcurrently(t *testing.T) {
+    double := ExecutorFunc(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+        n := inputs[WorkflowInputsKey].(map[string]interface{})["n"].(int)
+        return map[string]interface{}{"n": n * 2}, nil
+    })
+    sum := ExecutorFunc(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+        b := inputs["B"].(map[string]interface{})["n"].(int)
+        c := inputs["C"].(map[string]interface{})["n"].(int)
+        return map[string]interface{}{"n": b + c}, nil
+    })
+
+    engine := newDiamond(t, map[string]Executor{"A": double, "B": double, "C": double, "D": sum})
+    plan, err := engine.Compile()
+    if err != nil {
+        t.Fatalf("Compile failed: %v", err)
+    }
+
+    var wg sync.WaitGroup
+    errs := make(chan error, 20)
+    for i := 0; i < 20; i++ {
+        wg.Add(1)
+        go func(n int) {
+            defer wg.Done()
+            result, err := engine.Execute(context.Background(), plan, map[string]interface{}{"n": n})
+            if err != nil {
+                errs <- err
+                return
+            }
+            if got := result.Outputs["D"]["n"]; got != n*4 {
+                errs <- fmt.Errorf("run with n=%d: expected D to be %d, got %v", n, n*4, got)
+            }
+        }(i)
+    }
+    wg.Wait()
+    close(errs)
+
+    for err := range errs {
+        t.Error(err)
+    }
+}
+
+func TestPlanIsIsolatedFromEngineChanges(t *testing.T) {
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("A", nil, &recordingExecutor{result: map[string]interface{}{}}))
+    plan, err := engine.Compile()
+    if err != nil {
+        t.Fatalf("Compile failed: %v", err)
+    }
+
+    engine.AddNode(NewNode("B", []string{"A"}, &recordingExecutor{}))
+    engine.Nodes["A"].Dependencies = []string{"B"}
+
+    if ids := plan.NodeIDs(); len(ids) != 1 || ids[0] != "A" {
+        t.Errorf("Expected plan to contain only A, got %v", ids)
+    }
+    if node, _ := plan.Node("A"); len(node.Dependencies) != 0 {
+        t.Errorf("Expected A to have no dependencies in the plan, got %v", node.Dependencies)
+    }
+
+    result, err := engine.Execute(context.Background(), plan, nil)
+    if err != nil {
+        t.Fatalf("Execute failed: %v", err)
+    }
+    if len(result.NodeStatuses) != 1 || result.NodeStatuses["A"] != StatusCompleted {
+        t.Errorf("Expected only A to run, got %v", result.NodeStatuses)
+    }
+}
+
+func TestRunCancel(t *testing.T) {
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("A", nil, blockingExecutor))
+    engine.AddNode(NewNode("B", []string{"A"}, &recordingExecutor{}))
+    plan, err := engine.Compile()
+    if err != nil {
+        t.Fatalf("Compile failed: %v", err)
+    }
+
+    run := engine.Start(context.Background(), plan, nil)
+    run.Cancel()
+    result, _ := run.Wait()
+
+    if result.NodeStatuses["A"] == StatusCompleted || result.NodeStatuses["B"] != StatusSkipped {
+        t.Errorf("Expected A interrupted and B SKIPPED, got %v", result.NodeStatuses)
+    }
+}
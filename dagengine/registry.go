@@ -0,0 +1,288 @@
+package dagengine
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+)
+
+// ExecutorSpec declares the executor of a node, as written in a workflow.
+type ExecutorSpec struct {
+    Type   string                 // Name of a registered ExecutorType
+    Code   string                 // Script or command, for types that run code
+    Config map[string]interface{} // Type-specific settings, described by the type's schema
+}
+
+// ExecutorHost provides the services of the workflow a node belongs to. A nil
+// field makes the executors that need it fail when they use it.
+type ExecutorHost struct {
+    // Secret reads a key of a secret the workflow declares.
+    Secret func(ctx context.Context, name, key string) (string, error)
+    // SecretEnv returns the workflow's secrets as environment variables.
+    SecretEnv func(ctx context.Context) (map[string]string, error)
+    // Env holds the workflow's environment variables.
+    Env map[string]string
+    // SubWorkflows runs the workflows that sub-workflow nodes call.
+    SubWorkflows SubWorkflowRunner
+}
+
+// ExecutorFactory creates the executor of a node from a spec that passed
+// validation. host is never nil.
+type ExecutorFactory func(spec ExecutorSpec, host *ExecutorHost) (Executor, error)
+
+// ExecutorType describes a kind of executor that workflows can use.
+type ExecutorType struct {
+    Name   string          // Value of the executor's type in workflows, e.g. "http"
+    New    ExecutorFactory // Creates executors of this type
+    Schema ExecutorSchema  // Checked before Validate and New
+    Retry  *RetryPolicy    // Policy of the nodes that declare none (default: a single attempt)
+
+    // Validate checks a spec beyond its schema, without creating an
+    // executor. If nil, only the schema is checked.
+    Validate func(spec ExecutorSpec) error
+}
+
+// ConfigKind is a set of the types a configuration value may have.
+type ConfigKind int
+
+// Kinds of configuration values. They can be combined, e.g.
+// ConfigString|ConfigList.
+const (
+    ConfigString ConfigKind = 1 << iota
+    ConfigNumber
+    ConfigBool
+    ConfigList
+    ConfigMap
+
+    ConfigAny ConfigKind = 0 // Any value, including structures with placeholders
+)
+
+// String returns the kinds' names, e.g. "string or list".
+func (k ConfigKind) String() string {
+    if k == ConfigAny {
+        return "any value"
+    }
+    var names []string
+    for _, kind := range []struct {
+        kind ConfigKind
+        name string
+    }{{ConfigString, "string"}, {ConfigNumber, "number"}, {ConfigBool, "boolean"}, {ConfigList, "list"}, {ConfigMap, "map"}} {
+        if k&kind.kind != 0 {
+            names = append(names, kind.name)
+        }
+    }
+    return strings.Join(names, " or ")
+}
+
+// matches reports whether value is of one of the kinds.
+func (k ConfigKind) matches(value interface{}) bool {
+    if k == ConfigAny {
+        return true
+    }
+    switch value.(type) {
+    case string:
+        return k&ConfigString != 0
+    case bool:
+        return k&ConfigBool != 0
+    case []interface{}, []string:
+        return k&ConfigList != 0
+    case map[string]interface{}, map[string]string:
+        return k&ConfigMap != 0
+    }
+    if _, ok := toFloat(value); ok {
+        return k&ConfigNumber != 0
+    }
+    return false
+}
+
+// ConfigField describes a key of an executor's configuration.
+type ConfigField struct {
+    Kind        ConfigKind
+    Required    bool
+    Description string
+}
+
+// CodeUsage tells whether the specs of an executor type hold code.
+type CodeUsage int
+
+// Uses of code by executor types.
+const (
+    CodeNone     CodeUsage = iota // Specs must not hold code
+    CodeRequired                  // Specs must hold code
+    CodeOptional                  // Specs may hold code, e.g. instead of a configuration key
+)
+
+// ExecutorSchema describes what a spec of an executor type holds. Keys of
+// the configuration that are not in Config are rejected.
+type ExecutorSchema struct {
+    Code   CodeUsage              // Whether specs hold code (default: CodeNone)
+    Config map[string]ConfigField // Keys of the configuration
+}
+
+// Check checks spec against the schema.
+func (s ExecutorSchema) Check(spec ExecutorSpec) error {
+    if s.Code == CodeRequired && spec.Code == "" {
+        return fmt.Errorf("code is required for %s executor", spec.Type)
+    }
+    if s.Code == CodeNone && spec.Code != "" {
+        return fmt.Errorf("%s executor does not run code", spec.Type)
+    }
+
+    keys := make([]string, 0, len(spec.Config))
+    for key := range spec.Config {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    for _, key := range keys {
+        field, ok := s.Config[key]
+        if !ok {
+            return fmt.Errorf("unknown %s config key '%s'", spec.Type, key)
+        }
+        if value := spec.Config[key]; value != nil && !field.Kind.matches(value) {
+            return fmt.Errorf("%s %s must be a %s, got %T", spec.Type, key, field.Kind, value)
+        }
+    }
+    for key, field := range s.Config {
+        if field.Required && spec.Config[key] == nil {
+            return fmt.Errorf("%s %s is required", spec.Type, key)
+        }
+    }
+    return nil
+}
+
+// ExecutorRegistry holds the executor types workflows can use. The spec
+// validator, the orchestrator and engines consult the same registry, so
+// registering a type is enough to use it everywhere.
+type ExecutorRegistry struct {
+    mu    sync.RWMutex
+    types map[string]ExecutorType
+}
+
+// NewExecutorRegistry creates a registry of the given types.
+func NewExecutorRegistry(types ...ExecutorType) (*ExecutorRegistry, error) {
+    r := &ExecutorRegistry{types: make(map[string]ExecutorType)}
+    for _, t := range types {
+        if err := r.Register(t); err != nil {
+            return nil, err
+        }
+    }
+    return r, nil
+}
+
+// BuiltinExecutorTypes returns the executor types provided by this package.
+func BuiltinExecutorTypes() []ExecutorType {
+    return []ExecutorType{luaExecutorType(), shellExecutorType(), httpExecutorType(), grpcExecutorType(), wasmExecutorType(), subworkflowExecutorType()}
+}
+
+var (
+    defaultExecutorRegistry     *ExecutorRegistry
+    defaultExecutorRegistryOnce sync.Once
+)
+
+// DefaultExecutorRegistry returns the process-wide registry, which starts
+// with the builtin types. Modules add their own types to it with
+// RegisterExecutor.
+func DefaultExecutorRegistry() *ExecutorRegistry {
+    defaultExecutorRegistryOnce.Do(func() {
+        defaultExecutorRegistry, _ = NewExecutorRegistry(BuiltinExecutorTypes()...) // Cannot fail
+    })
+    return defaultExecutorRegistry
+}
+
+// RegisterExecutor adds a type to the default registry. It panics if the
+// type is invalid or its name is taken, and is meant to be called from init
+// functions.
+func RegisterExecutor(t ExecutorType) {
+    if err := DefaultExecutorRegistry().Register(t); err != nil {
+        panic(err)
+    }
+}
+
+// Register adds a type to the registry.
+func (r *ExecutorRegistry) Register(t ExecutorType) error {
+    if t.Name == "" {
+        return fmt.Errorf("executor type name is required")
+    }
+    if t.New == nil {
+        return fmt.Errorf("executor type %s has no factory", t.Name)
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if _, exists := r.types[t.Name]; exists {
+        return fmt.Errorf("executor type %s is already registered", t.Name)
+    }
+    r.types[t.Name] = t
+    return nil
+}
+
+// Lookup returns the type registered under name.
+func (r *ExecutorRegistry) Lookup(name string) (ExecutorType, bool) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    t, ok := r.types[name]
+    return t, ok
+}
+
+// Names returns the names of the registered types, sorted.
+func (r *ExecutorRegistry) Names() []string {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    names := make([]string, 0, len(r.types))
+    for name := range r.types {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// Validate checks that spec is of a registered type, against the type's
+// schema and validator.
+func (r *ExecutorRegistry) Validate(spec ExecutorSpec) error {
+    t, err := r.lookup(spec)
+    if err != nil {
+        return err
+    }
+    if err := t.Schema.Check(spec); err != nil {
+        return err
+    }
+    if t.Validate != nil {
+        return t.Validate(spec)
+    }
+    return nil
+}
+
+// New validates spec and creates its executor. A nil host is replaced by an
+// empty one.
+func (r *ExecutorRegistry) New(spec ExecutorSpec, host *ExecutorHost) (Executor, error) {
+    t, err := r.lookup(spec)
+    if err != nil {
+        return nil, err
+    }
+    if err := t.Schema.Check(spec); err != nil {
+        return nil, err
+    }
+    if t.Validate != nil {
+        if err := t.Validate(spec); err != nil {
+            return nil, err
+        }
+    }
+    if host == nil {
+        host = &ExecutorHost{}
+    }
+    return t.New(spec, host)
+}
+
+// lookup returns the type of spec.
+func (r *ExecutorRegistry) lookup(spec ExecutorSpec) (ExecutorType, error) {
+    if spec.Type == "" {
+        return ExecutorType{}, fmt.Errorf("executor type is required")
+    }
+    t, ok := r.Lookup(spec.Type)
+    if !ok {
+        return ExecutorType{}, fmt.Errorf("unsupported executor type: %s (supported: %s)", spec.Type, strings.Join(r.Names(), ", "))
+    }
+    return t, nil
+}
@@ -0,0 +1,140 @@
+package dagengine
+
+import (
+    "context"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+// ledgerExecutorType is a custom type, as a module outside this package
+// would register it.
+func ledgerExecutorType() ExecutorType {
+    return ExecutorType{
+        Name: "ledger",
+        New: func(spec ExecutorSpec, host *ExecutorHost) (Executor, error) {
+            account := spec.Config["account"].(string)
+            return ExecutorFunc(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+                return map[string]interface{}{"account": account, "region": host.Env["REGION"]}, nil
+            }), nil
+        },
+        Schema: ExecutorSchema{
+            Config: map[string]ConfigField{
+                "account": {Kind: ConfigString, Required: true},
+                "limit":   {Kind: ConfigNumber},
+                "tags":    {Kind: ConfigString | ConfigList},
+            },
+        },
+    }
+}
+
+func TestExecutorRegistry(t *testing.T) {
+    registry, err := NewExecutorRegistry(append(BuiltinExecutorTypes(), ledgerExecutorType())...)
+    if err != nil {
+        t.Fatalf("NewExecutorRegistry failed: %v", err)
+    }
+    if want := []string{"grpc", "http", "ledger", "lua", "shell", "subworkflow", "wasm"}; !reflect.DeepEqual(registry.Names(), want) {
+        t.Errorf("Expected types %v, got %v", want, registry.Names())
+    }
+    if err := registry.Register(ledgerExecutorType()); err == nil {
+        t.Error("Expected an error registering a type twice")
+    }
+    if err := registry.Register(ExecutorType{Name: "broken"}); err == nil {
+        t.Error("Expected an error registering a type without a factory")
+    }
+    created := false
+    probe := ExecutorType{Name: "probe", New: func(spec ExecutorSpec, host *ExecutorHost) (Executor, error) {
+        created = true
+        return ExecutorFunc(nil), nil
+    }}
+    probes, err := NewExecutorRegistry(probe)
+    if err != nil {
+        t.Fatalf("NewExecutorRegistry failed: %v", err)
+    }
+    if err := probes.Validate(ExecutorSpec{Type: "probe"}); err != nil || created {
+        t.Errorf("Expected Validate to check the schema without creating an executor, got %v (created: %v)", err, created)
+    }
+
+    engine := NewDAGEngine()
+    engine.Executors = registry
+    node, err := engine.NodeFromSpec("post", nil, ExecutorSpec{Type: "ledger", Config: map[string]interface{}{"account": "acme"}},
+        &ExecutorHost{Env: map[string]string{"REGION": "eu"}})
+    if err != nil {
+        t.Fatalf("NodeFromSpec failed: %v", err)
+    }
+    engine.AddNode(node)
+    result, err := engine.Run(context.Background(), nil)
+    if err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+    if want := map[string]interface{}{"account": "acme", "region": "eu"}; !reflect.DeepEqual(result.Outputs["post"], want) {
+        t.Errorf("Expected %v, got %v", want, result.Outputs["post"])
+    }
+
+    tests := []struct {
+        name string
+        spec ExecutorSpec
+        want string
+    }{
+        {"valid", ExecutorSpec{Type: "ledger", Config: map[string]interface{}{"account": "acme", "limit": 10, "tags": []interface{}{"a"}}}, ""},
+        {"missing type", ExecutorSpec{}, "type is required"},
+        {"unknown type", ExecutorSpec{Type: "cobol"}, "supported: grpc, http, ledger, lua, shell, subworkflow, wasm"},
+        {"missing key", ExecutorSpec{Type: "ledger", Config: map[string]interface{}{"limit": 10}}, "ledger account is required"},
+        {"unknown key", ExecutorSpec{Type: "ledger", Config: map[string]interface{}{"account": "acme", "acount": "x"}}, "unknown ledger config key 'acount'"},
+        {"wrong kind", ExecutorSpec{Type: "ledger", Config: map[string]interface{}{"account": "acme", "tags": 3}}, "must be a string or list"},
+        {"unexpected code", ExecutorSpec{Type: "ledger", Code: "x", Config: map[string]interface{}{"account": "acme"}}, "does not run code"},
+        {"builtin missing code", ExecutorSpec{Type: "lua"}, "code is required for lua executor"},
+        {"builtin validator", ExecutorSpec{Type: "lua", Code: "return {}", Config: map[string]interface{}{"modules": []interface{}{"os"}}}, "os"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := registry.Validate(tt.spec)
+            if tt.want == "" {
+                if err != nil {
+                    t.Errorf("Validate failed: %v", err)
+                }
+                return
+            }
+            if err == nil || !strings.Contains(err.Error(), tt.want) {
+                t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+            }
+            if _, err := registry.New(tt.spec, nil); err == nil {
+                t.Error("Expected New to fail too")
+            }
+        })
+    }
+}
+
+func TestBuiltinExecutorTypes(t *testing.T) {
+    secret := func(ctx context.Context, name, key string) (string, error) { return "s3cret", nil }
+    host := &ExecutorHost{Secret: secret, Env: map[string]string{"REGION": "eu", "LOG_LEVEL": "info"}}
+
+    executor, err := DefaultExecutorRegistry().New(ExecutorSpec{
+        Type:   "shell",
+        Code:   "true",
+        Config: map[string]interface{}{"env": map[string]interface{}{"LOG_LEVEL": "debug"}},
+    }, host)
+    if err != nil {
+        t.Fatalf("New failed: %v", err)
+    }
+    if env := executor.(*ShellExecutor).Env; !reflect.DeepEqual(env, map[string]string{"REGION": "eu", "LOG_LEVEL": "debug"}) {
+        t.Errorf("Expected the node's variables to override the workflow's, got %v", env)
+    }
+
+    executor, err = DefaultExecutorRegistry().New(ExecutorSpec{Type: "lua", Code: "return {}"}, host)
+    if err != nil {
+        t.Fatalf("New failed: %v", err)
+    }
+    lua := executor.(*LuaExecutor)
+    if lua.Pool != DefaultLuaStatePool() || lua.Host.Env["REGION"] != "eu" || lua.Host.Secret == nil {
+        t.Errorf("Expected a pooled executor with the host's services, got %+v", lua)
+    }
+
+    executor, err = DefaultExecutorRegistry().New(ExecutorSpec{Type: "http", Config: map[string]interface{}{"url": "http://x"}}, host)
+    if err != nil {
+        t.Fatalf("New failed: %v", err)
+    }
+    if executor.(*HTTPExecutor).Secret == nil {
+        t.Error("Expected the http executor to read the host's secrets")
+    }
+}
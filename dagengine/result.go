@@ -0,0 +1,89 @@
+package dagengine
+
+import (
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+)
+
+// Node statuses.
+const (
+    StatusPending        = "PENDING"
+    StatusRunning        = "RUNNING"
+    StatusCompleted      = "COMPLETED"
+    StatusFailed         = "FAILED"
+    StatusSkipped        = "SKIPPED"         // Never started because the run was aborted or an upstream node was skipped
+    StatusUpstreamFailed = "UPSTREAM_FAILED" // Never started because an upstream node failed
+    StatusCancelled      = "CANCELLED"       // Interrupted while running
+    StatusTimedOut       = "TIMED_OUT"       // Exceeded its own or the workflow's deadline
+    StatusCached         = "CACHED"          // Reported in NodeEvents of COMPLETED nodes whose result came from the cache
+)
+
+// ErrNodeTimeout is wrapped by the error of a node whose attempt exceeded Node.Timeout.
+var ErrNodeTimeout = errors.New("node timed out")
+
+// FailurePolicy controls how the engine reacts when a node fails.
+type FailurePolicy string
+
+const (
+    // FailFast cancels every running node through the run context and skips
+    // all nodes that have not started yet, except those whose trigger rule
+    // reacts to failures (see TriggerRule).
+    FailFast FailurePolicy = "fail_fast"
+    // ContinueIndependent marks the descendants of a failed node as
+    // UPSTREAM_FAILED but keeps running branches that do not depend on it.
+    ContinueIndependent FailurePolicy = "continue"
+    // BestEffort runs every node regardless of upstream failures; failed
+    // parents simply contribute no result to their children's inputs.
+    BestEffort FailurePolicy = "best_effort"
+)
+
+// Valid reports whether p is a known failure policy.
+func (p FailurePolicy) Valid() bool {
+    switch p {
+    case FailFast, ContinueIndependent, BestEffort:
+        return true
+    }
+    return false
+}
+
+// Run statuses.
+const (
+    RunRunning   = "RUNNING" // Only seen in checkpoints of executions in progress
+    RunCompleted = "COMPLETED"
+    RunFailed    = "FAILED"
+    RunCancelled = "CANCELLED"
+    RunTimedOut  = "TIMED_OUT"
+)
+
+// RunResult describes the outcome of a DAG run.
+type RunResult struct {
+    Status       string                            // RunCompleted, RunFailed, RunCancelled or RunTimedOut
+    NodeStatuses map[string]string                 // Final status of every node
+    NodeErrors   map[string]error                  // Errors of the nodes that failed
+    NodeAttempts map[string]int                    // Number of attempts made for every node that started
+    Outputs      map[string]map[string]interface{} // Results of the nodes that completed
+    Duration     time.Duration
+}
+
+// RunError aggregates the errors of every node that failed during a run.
+type RunError struct {
+    Failures map[string]error // node ID -> error
+}
+
+// Error lists the failed nodes in a stable order.
+func (r *RunError) Error() string {
+    ids := make([]string, 0, len(r.Failures))
+    for id := range r.Failures {
+        ids = append(ids, id)
+    }
+    sort.Strings(ids)
+
+    parts := make([]string, 0, len(ids))
+    for _, id := range ids {
+        parts = append(parts, fmt.Sprintf("%s: %v", id, r.Failures[id]))
+    }
+    return fmt.Sprintf("%d node(s) failed: %s", len(ids), strings.Join(parts, "; "))
+}
@@ -0,0 +1,102 @@
+package dagengine
+
+import (
+    "context"
+    "errors"
+    "math"
+    "math/rand"
+    "strings"
+    "time"
+)
+
+// BackoffStrategy selects how the delay between retry attempts grows.
+type BackoffStrategy string
+
+const (
+    BackoffFixed       BackoffStrategy = "fixed"
+    BackoffExponential BackoffStrategy = "exponential"
+)
+
+// RetryPolicy configures how many times a node is attempted and how long
+// the engine waits between attempts.
+type RetryPolicy struct {
+    MaxAttempts  int             // Total attempts including the first one; <= 1 disables retries
+    Backoff      BackoffStrategy // BackoffFixed (default) or BackoffExponential
+    InitialDelay time.Duration   // Delay before the first retry
+    MaxDelay     time.Duration   // Upper bound for the delay (0 means no bound)
+    Multiplier   float64         // Growth factor for exponential backoff (default 2)
+    Jitter       float64         // Fraction (0-1) of the delay that is randomized
+    RetryOn      []string        // If set, only errors whose message contains one of these substrings are retried
+}
+
+// Delay returns how long to wait after the given (1-based) failed attempt.
+// Without a MaxDelay, exponential delays stop growing at the longest
+// time.Duration.
+func (p *RetryPolicy) Delay(attempt int) time.Duration {
+    delay := float64(p.InitialDelay)
+
+    if p.Backoff == BackoffExponential && delay > 0 {
+        multiplier := p.Multiplier
+        if multiplier <= 0 {
+            multiplier = 2
+        }
+        // Clamped so that the growth, which can reach +Inf, stays finite
+        delay = math.Min(delay*math.Pow(multiplier, float64(attempt-1)), math.MaxInt64)
+    }
+
+    if p.Jitter > 0 {
+        // Spread the delay uniformly over [delay*(1-jitter), delay*(1+jitter)]
+        delay += delay * p.Jitter * (2*rand.Float64() - 1)
+    }
+
+    if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+        delay = float64(p.MaxDelay)
+    }
+    // float64(math.MaxInt64) rounds up, beyond what a Duration holds
+    if delay >= math.MaxInt64 {
+        return time.Duration(math.MaxInt64)
+    }
+    return time.Duration(delay)
+}
+
+// ShouldRetry classifies an error as retryable. Errors marked with Permanent
+// and context cancellations are never retried.
+func (p *RetryPolicy) ShouldRetry(err error) bool {
+    if err == nil || IsPermanent(err) || errors.Is(err, context.Canceled) {
+        return false
+    }
+    if len(p.RetryOn) == 0 {
+        return true
+    }
+
+    msg := err.Error()
+    for _, pattern := range p.RetryOn {
+        if strings.Contains(msg, pattern) {
+            return true
+        }
+    }
+    return false
+}
+
+// permanentError marks an error as non-retryable.
+type permanentError struct {
+    err error
+}
+
+func (p *permanentError) Error() string { return p.err.Error() }
+func (p *permanentError) Unwrap() error { return p.err }
+
+// Permanent wraps err so that the engine never retries it, regardless of
+// the node's retry policy. Executors use it for errors such as invalid input.
+func Permanent(err error) error {
+    if err == nil {
+        return nil
+    }
+    return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+    var p *permanentError
+    return errors.As(err, &p)
+}
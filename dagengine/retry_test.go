@@ -0,0 +1,136 @@
+package dagengine
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "math"
+    "sync"
+    "testing"
+    "time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+    tests := []struct {
+        name    string
+        policy  RetryPolicy
+        attempt int
+        want    time.Duration
+    }{
+        {"fixed", RetryPolicy{InitialDelay: time.Second}, 3, time.Second},
+        {"exponential default multiplier", RetryPolicy{Backoff: BackoffExponential, InitialDelay: time.Second}, 3, 4 * time.Second},
+        {"exponential custom multiplier", RetryPolicy{Backoff: BackoffExponential, InitialDelay: time.Second, Multiplier: 3}, 3, 9 * time.Second},
+        {"capped by max delay", RetryPolicy{Backoff: BackoffExponential, InitialDelay: time.Second, MaxDelay: 5 * time.Second}, 10, 5 * time.Second},
+        {"exponential overflow without max delay", RetryPolicy{Backoff: BackoffExponential, InitialDelay: time.Second}, 100, time.Duration(math.MaxInt64)},
+        {"exponential overflow to infinity", RetryPolicy{Backoff: BackoffExponential, InitialDelay: time.Second}, 2000, time.Duration(math.MaxInt64)},
+        {"exponential without initial delay", RetryPolicy{Backoff: BackoffExponential}, 2000, 0},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := tt.policy.Delay(tt.attempt); got != tt.want {
+                t.Errorf("Expected delay %v, got %v", tt.want, got)
+            }
+        })
+    }
+}
+
+func TestRetryPolicyDelayJitter(t *testing.T) {
+    policy := RetryPolicy{InitialDelay: time.Second, Jitter: 0.5}
+    for i := 0; i < 100; i++ {
+        if got := policy.Delay(1); got < 500*time.Millisecond || got > 1500*time.Millisecond {
+            t.Fatalf("Expected delay within jitter bounds, got %v", got)
+        }
+    }
+
+    // Jitter around an overflowing delay must not wrap it negative
+    policy = RetryPolicy{Backoff: BackoffExponential, InitialDelay: time.Second, Jitter: 0.5}
+    for i := 0; i < 100; i++ {
+        if got := policy.Delay(100); got < 0 {
+            t.Fatalf("Expected a non-negative delay, got %v", got)
+        }
+    }
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+    tests := []struct {
+        name   string
+        policy RetryPolicy
+        err    error
+        want   bool
+    }{
+        {"any error", RetryPolicy{}, errors.New("boom"), true},
+        {"permanent error", RetryPolicy{}, Permanent(errors.New("bad input")), false},
+        {"wrapped permanent error", RetryPolicy{}, fmt.Errorf("task: %w", Permanent(errors.New("bad input"))), false},
+        {"context cancelled", RetryPolicy{}, context.Canceled, false},
+        {"matching retry_on", RetryPolicy{RetryOn: []string{"timeout"}}, errors.New("connection timeout"), true},
+        {"non-matching retry_on", RetryPolicy{RetryOn: []string{"timeout"}}, errors.New("not found"), false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := tt.policy.ShouldRetry(tt.err); got != tt.want {
+                t.Errorf("Expected ShouldRetry %v, got %v", tt.want, got)
+            }
+        })
+    }
+}
+
+// flakyExecutor fails until it has been called failures+1 times.
+type flakyExecutor struct {
+    mu       sync.Mutex
+    failures int
+    calls    int
+    err      error
+}
+
+func (f *flakyExecutor) Execute(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.calls++
+    if f.calls <= f.failures {
+        return nil, f.err
+    }
+    return map[string]interface{}{"calls": f.calls}, nil
+}
+
+func TestRunRetriesFailedNodes(t *testing.T) {
+    tests := []struct {
+        name         string
+        retry        *RetryPolicy
+        failures     int
+        err          error
+        wantStatus   string
+        wantAttempts int
+    }{
+        {"succeeds after retries", &RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond}, 2, errors.New("flaky"), StatusCompleted, 3},
+        {"exhausts attempts", &RetryPolicy{MaxAttempts: 2, InitialDelay: time.Millisecond}, 5, errors.New("flaky"), StatusFailed, 2},
+        {"no retry policy", nil, 1, errors.New("flaky"), StatusFailed, 1},
+        {"permanent error", &RetryPolicy{MaxAttempts: 3}, 5, Permanent(errors.New("bad input")), StatusFailed, 1},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            executor := &flakyExecutor{failures: tt.failures, err: tt.err}
+            node := NewNode("A", nil, executor)
+            node.Retry = tt.retry
+
+            engine := NewDAGEngine()
+            engine.AddNode(node)
+            if err := engine.PreprocessDAG(); err != nil {
+                t.Fatalf("PreprocessDAG failed: %v", err)
+            }
+
+            result, _ := engine.Run(context.Background(), nil)
+            if result.NodeStatuses["A"] != tt.wantStatus {
+                t.Errorf("Expected status %s, got %s", tt.wantStatus, result.NodeStatuses["A"])
+            }
+            if result.NodeAttempts["A"] != tt.wantAttempts {
+                t.Errorf("Expected %d attempts, got %d", tt.wantAttempts, result.NodeAttempts["A"])
+            }
+            if executor.calls != tt.wantAttempts {
+                t.Errorf("Expected executor to be called %d times, got %d", tt.wantAttempts, executor.calls)
+            }
+        })
+    }
+}
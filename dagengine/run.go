@@ -0,0 +1,503 @@
+package dagengine
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+)
+
+// nodeState is the per-execution state of a node.
+type nodeState struct {
+    mu           sync.RWMutex
+    status       string                 // One of the Status* constants
+    result       map[string]interface{} // Set when the node completed
+    err          error                  // Set when the node failed or was cancelled
+    attempts     int                    // Number of times the task has been attempted
+    startTime    time.Time              // Set when the task starts
+    cached       bool                   // The result came from the cache
+    readyCounter int                    // Tracks unfulfilled dependencies
+}
+
+// Run is a single execution of a Plan. It owns all the mutable state of the
+// execution, so any number of Runs of the same Plan can proceed concurrently.
+type Run struct {
+    ID     string
+    plan   *Plan
+    inputs map[string]interface{} // Workflow-level inputs
+    ctx    context.Context
+    cancel context.CancelFunc // Cancels the run's nodes (used by FailFast)
+
+    // runCtx is the parent of ctx that FailFast does not cancel: nodes that
+    // react to failures run under it after the other nodes were cancelled.
+    runCtx    context.Context
+    cancelRun context.CancelFunc // Cancels the whole run (used by Cancel)
+
+    mu     sync.Mutex
+    nodes  map[string]*Node      // Plan nodes plus the elements spawned by map nodes
+    states map[string]*nodeState // Keyed by node ID
+    sched  scheduler             // Ready queue and capacity of the run
+    wg     sync.WaitGroup        // Counts the nodes that are queued or running
+
+    observers []Observer
+
+    store         StateStore // Receives the run's checkpoints, if set
+    checkpointMu  sync.Mutex // Serializes checkpoint saves
+    checkpointErr error      // First failed save
+    restored      []string   // Nodes restored from a checkpoint
+    cache         ResultCache // Results of cached nodes, if set
+
+    startTime time.Time
+    done      chan struct{}
+    result    *RunResult
+    err       error
+}
+
+// newRun prepares the state of a new execution of plan.
+func newRun(id string, plan *Plan, inputs map[string]interface{}, observers []Observer) *Run {
+    r := &Run{
+        ID:        id,
+        plan:      plan,
+        inputs:    inputs,
+        nodes:     make(map[string]*Node, len(plan.nodes)),
+        states:    make(map[string]*nodeState, len(plan.nodes)),
+        observers: observers,
+        done:      make(chan struct{}),
+    }
+    for id, node := range plan.nodes {
+        r.nodes[id] = node
+        r.states[id] = &nodeState{status: StatusPending, readyCounter: len(node.Dependencies)}
+    }
+    r.sched.reset()
+    return r
+}
+
+// start queues the root nodes and waits for the run to finish in the background.
+func (r *Run) start(ctx context.Context) {
+    r.startTime = time.Now()
+    cancelTimeout := context.CancelFunc(func() {})
+    if r.plan.timeout > 0 {
+        ctx, cancelTimeout = context.WithTimeout(ctx, r.plan.timeout)
+    }
+    parentCtx := ctx
+    r.runCtx, r.cancelRun = context.WithCancel(ctx)
+    r.ctx, r.cancel = context.WithCancel(r.runCtx)
+
+    r.notify(func(o Observer) { o.OnRunStart(RunEvent{RunID: r.ID, StartTime: r.startTime}) })
+
+    r.saveCheckpoint(RunRunning)
+
+    // Queue all root nodes in a stable order, resolve the children of the
+    // nodes restored from a checkpoint, and start as many as capacity allows
+    r.mu.Lock()
+    for _, id := range r.plan.roots {
+        if r.states[id].status == StatusPending {
+            r.enqueue(r.nodes[id])
+        }
+    }
+    sort.Strings(r.restored)
+    var blocked []*Node
+    for _, id := range r.restored {
+        blocked = append(blocked, r.resolveChildren(r.nodes[id])...)
+    }
+    r.dispatch()
+    r.mu.Unlock()
+    r.notifyBlocked(blocked)
+
+    go func() {
+        defer cancelTimeout()
+        defer r.cancelRun()
+
+        // Block until all queued and running nodes are finished.
+        r.wg.Wait()
+        r.result, r.err = r.finish(parentCtx)
+        r.saveCheckpoint(r.result.Status)
+        if r.checkpointErr != nil && r.err == nil {
+            r.err = fmt.Errorf("failed to checkpoint execution '%s': %w", r.ID, r.checkpointErr)
+        }
+        event := RunEvent{
+            RunID:     r.ID,
+            StartTime: r.startTime,
+            Duration:  r.result.Duration,
+            Result:    r.result,
+            Err:       r.err,
+        }
+        r.notify(func(o Observer) { o.OnRunComplete(event) })
+        close(r.done)
+    }()
+}
+
+// finish builds the final result once every node has finished.
+func (r *Run) finish(ctx context.Context) (*RunResult, error) {
+    result := r.collectResult(time.Since(r.startTime))
+    switch {
+    case errors.Is(r.ctx.Err(), context.DeadlineExceeded):
+        result.Status = RunTimedOut
+        return result, fmt.Errorf("workflow run timed out: %w", r.ctx.Err())
+    case len(result.NodeErrors) > 0:
+        return result, &RunError{Failures: result.NodeErrors}
+    case ctx.Err() != nil:
+        result.Status = RunCancelled
+        return result, fmt.Errorf("workflow run cancelled: %w", ctx.Err())
+    }
+    return result, nil
+}
+
+// Wait blocks until the run has finished and returns its result.
+// If any node failed, the error is a *RunError describing all failures.
+func (r *Run) Wait() (*RunResult, error) {
+    <-r.done
+    return r.result, r.err
+}
+
+// Done returns a channel that is closed when the run has finished.
+func (r *Run) Done() <-chan struct{} {
+    return r.done
+}
+
+// Cancel aborts the run: running nodes are cancelled and the others skipped.
+func (r *Run) Cancel() {
+    r.cancelRun()
+}
+
+// Plan returns the plan being executed.
+func (r *Run) Plan() *Plan {
+    return r.plan
+}
+
+// collectResult snapshots the final state of every node.
+func (r *Run) collectResult(duration time.Duration) *RunResult {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    result := &RunResult{
+        Status:       RunCompleted,
+        NodeStatuses: make(map[string]string, len(r.states)),
+        NodeErrors:   make(map[string]error),
+        NodeAttempts: make(map[string]int),
+        Outputs:      make(map[string]map[string]interface{}),
+        Duration:     duration,
+    }
+    for id, state := range r.states {
+        state.mu.RLock()
+        result.NodeStatuses[id] = state.status
+        if state.attempts > 0 {
+            result.NodeAttempts[id] = state.attempts
+        }
+        switch state.status {
+        case StatusCompleted:
+            result.Outputs[id] = state.result
+        case StatusFailed, StatusTimedOut:
+            result.NodeErrors[id] = state.err
+            result.Status = RunFailed
+        }
+        state.mu.RUnlock()
+    }
+    return result
+}
+
+// state returns the state of a node of the run.
+func (r *Run) state(id string) *nodeState {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    return r.states[id]
+}
+
+// setStatus records a node's state.
+func (r *Run) setStatus(n *Node, status string, result map[string]interface{}, err error) {
+    state := r.state(n.ID)
+    state.mu.Lock()
+    defer state.mu.Unlock()
+    state.status = status
+    state.err = err
+    if result != nil {
+        state.result = result
+    }
+}
+
+// executeNode is the concurrent worker function for a single node.
+func (r *Run) executeNode(n *Node) {
+    defer r.wg.Done() // Signal completion when the goroutine exits
+    ctx := r.nodeContext(n)
+
+    // A node whose run has already been aborted is skipped, not started
+    if ctx.Err() != nil {
+        r.finishNode(n, StatusSkipped, nil, nil)
+        return
+    }
+
+    // 1. Gather Inputs from the completed parents and the workflow-level inputs
+    parents := r.gatherResults(n)
+
+    // A node whose condition does not hold is skipped
+    if n.condition != nil {
+        ok, err := n.condition.Evaluate(baseInputs(parents, r.inputs))
+        if err == nil && !ok {
+            r.finishNode(n, StatusSkipped, nil, nil)
+            return
+        }
+        if err != nil {
+            r.failNode(n, StatusFailed, err)
+            return
+        }
+    }
+
+    inputs, err := buildInputs(n, parents, r.inputs)
+
+    // 2. Execute Task
+    r.startNode(n)
+
+    var result map[string]interface{}
+    if err == nil && n.Map != nil {
+        result, err = r.runMap(ctx, n, inputs)
+    } else if err == nil {
+        result, err = r.runTask(ctx, n, inputs)
+    }
+
+    // 3. Update Status and Trigger Dependents
+    if err != nil {
+        status := failureStatus(ctx, err)
+        if ctx.Err() != nil {
+            // Interrupted because the run was cancelled or ran out of time,
+            // not a failure of its own
+            r.finishNode(n, status, nil, err)
+        } else {
+            r.failNode(n, status, err)
+        }
+        return
+    }
+
+    r.finishNode(n, StatusCompleted, result, nil)
+}
+
+// failureStatus classifies the error of a node that did not complete.
+func failureStatus(ctx context.Context, err error) string {
+    switch {
+    case errors.Is(ctx.Err(), context.DeadlineExceeded):
+        return StatusTimedOut
+    case ctx.Err() != nil:
+        return StatusCancelled
+    case errors.Is(err, ErrNodeTimeout):
+        return StatusTimedOut
+    }
+    return StatusFailed
+}
+
+// runTask executes the node's task, retrying failed attempts according to
+// the node's retry policy. Every attempt is recorded in the node's state.
+// Timed-out attempts are retried like any other failure.
+func (r *Run) runTask(ctx context.Context, n *Node, inputs map[string]interface{}) (map[string]interface{}, error) {
+    maxAttempts := 1
+    if n.Retry != nil && n.Retry.MaxAttempts > 1 {
+        maxAttempts = n.Retry.MaxAttempts
+    }
+    state := r.state(n.ID)
+    ctx = r.withNodeLog(ctx, n)
+
+    // A cached node with a valid previous result for these inputs does not run
+    result, cacheKey, hit := r.cachedResult(n, inputs)
+    if hit {
+        state.mu.Lock()
+        state.cached = true
+        state.mu.Unlock()
+        return result, nil
+    }
+
+    for attempt := 1; ; attempt++ {
+        state.mu.Lock()
+        state.attempts = attempt
+        state.mu.Unlock()
+
+        result, err := attemptTask(ctx, n, inputs)
+        if err == nil {
+            if cacheKey != "" {
+                r.cache.Put(cacheKey, &CacheEntry{Result: result, CreatedAt: time.Now()}) // Best effort
+            }
+            return result, nil
+        }
+
+        if attempt >= maxAttempts || ctx.Err() != nil || !n.Retry.ShouldRetry(err) {
+            if attempt > 1 {
+                err = fmt.Errorf("after %d attempts: %w", attempt, err)
+            }
+            return nil, err
+        }
+
+        delay := n.Retry.Delay(attempt)
+        event := r.nodeEvent(n)
+        event.Err = err
+        event.RetryDelay = delay
+        r.notify(func(o Observer) { o.OnNodeRetry(event) })
+
+        select {
+        case <-time.After(delay):
+        case <-ctx.Done():
+            return nil, err
+        }
+    }
+}
+
+// attemptTask runs the node's task once, under the node's own deadline if it has one.
+func attemptTask(ctx context.Context, n *Node, inputs map[string]interface{}) (map[string]interface{}, error) {
+    if n.Timeout <= 0 {
+        return n.Task.Execute(ctx, inputs)
+    }
+
+    attemptCtx, cancel := context.WithTimeout(ctx, n.Timeout)
+    defer cancel()
+
+    result, err := n.Task.Execute(attemptCtx, inputs)
+    if ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+        // The node's own deadline expired, regardless of what the task returned
+        return nil, fmt.Errorf("%w after %v: %v", ErrNodeTimeout, n.Timeout, err)
+    }
+    return result, err
+}
+
+// startNode marks a node as running and reports it to the observers.
+func (r *Run) startNode(n *Node) {
+    state := r.state(n.ID)
+    state.mu.Lock()
+    state.status = StatusRunning
+    state.startTime = time.Now()
+    state.mu.Unlock()
+
+    event := r.nodeEvent(n)
+    r.notify(func(o Observer) { o.OnNodeStart(event) })
+}
+
+// finishNode records a node's terminal state, reports it to the observers,
+// saves a checkpoint and triggers its children.
+func (r *Run) finishNode(n *Node, status string, result map[string]interface{}, err error) {
+    r.setStatus(n, status, result, err)
+    r.notifyNodeDone(n)
+    r.saveCheckpoint(RunRunning)
+
+    // 4. Trigger Children
+    r.triggerChildren(n)
+}
+
+// failNode records a node failure, cancelling the run under the FailFast policy.
+func (r *Run) failNode(n *Node, status string, err error) {
+    if r.plan.policy == FailFast || r.plan.policy == "" {
+        r.cancel()
+    }
+    r.finishNode(n, status, nil, err)
+}
+
+// gatherResults collects the results of a node's completed parents.
+func (r *Run) gatherResults(n *Node) map[string]map[string]interface{} {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    parents := make(map[string]map[string]interface{}, len(n.Dependencies))
+    for _, parentID := range n.Dependencies {
+        if parent, exists := r.states[parentID]; exists {
+            parent.mu.RLock()
+            if parent.status == StatusCompleted {
+                parents[parentID] = parent.result
+            }
+            parent.mu.RUnlock()
+        }
+    }
+    return parents
+}
+
+// triggerChildren releases the capacity held by a finished node, resolves
+// its children and dispatches the nodes that became ready.
+func (r *Run) triggerChildren(parentNode *Node) {
+    r.mu.Lock()
+    r.release(parentNode)
+    blocked := r.resolveChildren(parentNode)
+    r.dispatch()
+    r.mu.Unlock()
+
+    r.notifyBlocked(blocked)
+}
+
+// resolveChildren iterates over the pre-calculated direct children of a
+// finished node. Children whose dependencies are all resolved are either
+// queued or, when an upstream node did not complete, marked as not runnable;
+// the latter is propagated further down the graph without starting any
+// goroutines. It returns the nodes marked as not runnable.
+// The caller must hold r.mu and call dispatch afterwards.
+func (r *Run) resolveChildren(parentNode *Node) []*Node {
+    var blocked []*Node
+    resolved := []*Node{parentNode}
+    for len(resolved) > 0 {
+        current := resolved[0]
+        resolved = resolved[1:]
+
+        // Iterate over the cached list of direct children
+        for _, childID := range current.Children {
+            childNode, exists := r.nodes[childID]
+            if !exists {
+                // Should not happen if the plan was compiled correctly, but good for safety
+                continue
+            }
+
+            child := r.states[childID]
+            child.mu.Lock()
+
+            // This is the only logic needed for concurrency control:
+            child.readyCounter--
+
+            if child.readyCounter == 0 && child.status == StatusPending {
+                if status := r.blockedStatus(childNode); status != "" {
+                    child.status = status
+                    blocked = append(blocked, childNode)
+                    resolved = append(resolved, childNode)
+                } else {
+                    r.enqueue(childNode)
+                }
+            }
+            child.mu.Unlock()
+        }
+    }
+    return blocked
+}
+
+// notifyBlocked reports the nodes that will not run to the observers and
+// checkpoints their status.
+func (r *Run) notifyBlocked(blocked []*Node) {
+    for _, n := range blocked {
+        r.notifyNodeDone(n)
+    }
+    if len(blocked) > 0 {
+        r.saveCheckpoint(RunRunning)
+    }
+}
+
+// blockedStatus decides whether a node whose dependencies are all resolved
+// must not run, according to its trigger rule. It returns the status to
+// assign, or "" if the node can run. The caller must hold r.mu.
+func (r *Run) blockedStatus(n *Node) string {
+    parentStatuses := make([]string, 0, len(n.Dependencies))
+    for _, parentID := range n.Dependencies {
+        parent := r.states[parentID]
+        parent.mu.RLock()
+        parentStatuses = append(parentStatuses, parent.status)
+        parent.mu.RUnlock()
+    }
+
+    if status := n.TriggerRule.blockedStatus(parentStatuses, r.plan.policy); status != "" {
+        return status
+    }
+    if r.nodeContext(n).Err() != nil {
+        return StatusSkipped
+    }
+    return ""
+}
+
+// nodeContext returns the context a node runs under. Nodes whose trigger
+// rule reacts to failures are not cancelled by FailFast, so that cleanup and
+// alerting nodes still run after a failure; they only stop when the whole
+// run is cancelled or times out.
+func (r *Run) nodeContext(n *Node) context.Context {
+    if n.TriggerRule.reactsToFailure() {
+        return r.runCtx
+    }
+    return r.ctx
+}
@@ -0,0 +1,101 @@
+package dagengine
+
+import (
+    "sort"
+)
+
+// queuedNode is an entry of the ready queue.
+type queuedNode struct {
+    node *Node
+    seq  int // Order in which the node became ready, used to break priority ties
+}
+
+// readyQueue holds the nodes whose dependencies are resolved but that have
+// not started yet, ordered by descending Node.Priority and then by the order
+// in which they became ready.
+type readyQueue []queuedNode
+
+// push inserts a node at its position in the queue.
+func (q *readyQueue) push(n *Node, seq int) {
+    i := sort.Search(len(*q), func(i int) bool {
+        other := (*q)[i]
+        if other.node.Priority != n.Priority {
+            return other.node.Priority < n.Priority
+        }
+        return other.seq > seq
+    })
+    *q = append(*q, queuedNode{})
+    copy((*q)[i+1:], (*q)[i:])
+    (*q)[i] = queuedNode{node: n, seq: seq}
+}
+
+// scheduler tracks the ready queue and the capacity used by the running
+// nodes of a run. Limits come from the run's Plan.
+type scheduler struct {
+    ready     readyQueue
+    seq       int
+    running   int
+    poolUsage map[string]int // pool name -> slots in use
+}
+
+// reset clears the scheduler state at the start of a run.
+func (s *scheduler) reset() {
+    s.ready = nil
+    s.seq = 0
+    s.running = 0
+    s.poolUsage = make(map[string]int)
+}
+
+// poolSlots returns how many slots of its pool a node claims.
+func (n *Node) poolSlots() int {
+    if n.Pool == "" {
+        return 0
+    }
+    if n.PoolSlots <= 0 {
+        return 1
+    }
+    return n.PoolSlots
+}
+
+// enqueue adds a node whose dependencies are resolved to the ready queue.
+// The caller must hold r.mu and call dispatch afterwards.
+func (r *Run) enqueue(n *Node) {
+    r.wg.Add(1) // The node counts as in flight from the moment it is ready
+    r.sched.seq++
+    r.sched.ready.push(n, r.sched.seq)
+}
+
+// dispatch starts queued nodes in priority order for as long as capacity
+// allows. A node whose pool is full is passed over in favour of lower
+// priority nodes that fit. The caller must hold r.mu.
+func (r *Run) dispatch() {
+    maxConcurrency := r.plan.maxConcurrency
+    remaining := r.sched.ready[:0]
+    for i, item := range r.sched.ready {
+        if maxConcurrency > 0 && r.sched.running >= maxConcurrency {
+            remaining = append(remaining, r.sched.ready[i:]...)
+            break
+        }
+
+        n := item.node
+        if slots := n.poolSlots(); slots > 0 {
+            if r.sched.poolUsage[n.Pool]+slots > r.plan.resourcePools[n.Pool] {
+                remaining = append(remaining, item)
+                continue
+            }
+            r.sched.poolUsage[n.Pool] += slots
+        }
+        r.sched.running++
+        go r.executeNode(n)
+    }
+    r.sched.ready = remaining
+}
+
+// release returns the capacity claimed by a node that has finished.
+// The caller must hold r.mu.
+func (r *Run) release(n *Node) {
+    r.sched.running--
+    if slots := n.poolSlots(); slots > 0 {
+        r.sched.poolUsage[n.Pool] -= slots
+    }
+}
@@ -0,0 +1,148 @@
+package dagengine
+
+import (
+    "context"
+    "fmt"
+    "sync"
+    "testing"
+    "time"
+)
+
+// concurrencyTracker records how many executors run at once, overall and per key.
+type concurrencyTracker struct {
+    mu      sync.Mutex
+    running map[string]int
+    max     map[string]int
+    order   []string
+}
+
+func newConcurrencyTracker() *concurrencyTracker {
+    return &concurrencyTracker{running: make(map[string]int), max: make(map[string]int)}
+}
+
+func (c *concurrencyTracker) executor(id string, keys ...string) Executor {
+    keys = append(keys, "total")
+    return ExecutorFunc(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+        c.mu.Lock()
+        c.order = append(c.order, id)
+        for _, key := range keys {
+            c.running[key]++
+            if c.running[key] > c.max[key] {
+                c.max[key] = c.running[key]
+            }
+        }
+        c.mu.Unlock()
+
+        time.Sleep(5 * time.Millisecond)
+
+        c.mu.Lock()
+        for _, key := range keys {
+            c.running[key]--
+        }
+        c.mu.Unlock()
+        return map[string]interface{}{}, nil
+    })
+}
+
+func TestRunRespectsMaxConcurrency(t *testing.T) {
+    tracker := newConcurrencyTracker()
+    engine := NewDAGEngine()
+    engine.MaxConcurrency = 3
+    engine.AddNode(NewNode("start", nil, tracker.executor("start")))
+    for i := 0; i < 10; i++ {
+        id := fmt.Sprintf("wide-%d", i)
+        engine.AddNode(NewNode(id, []string{"start"}, tracker.executor(id)))
+    }
+    if err := engine.PreprocessDAG(); err != nil {
+        t.Fatalf("PreprocessDAG failed: %v", err)
+    }
+
+    if _, err := engine.Run(context.Background(), nil); err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+    if tracker.max["total"] > 3 {
+        t.Errorf("Expected at most 3 nodes running at once, got %d", tracker.max["total"])
+    }
+    if len(tracker.order) != 11 {
+        t.Errorf("Expected 11 nodes to run, got %d", len(tracker.order))
+    }
+}
+
+func TestRunRespectsResourcePools(t *testing.T) {
+    tracker := newConcurrencyTracker()
+    engine := NewDAGEngine()
+    engine.ResourcePools = map[string]int{"db": 2}
+    for i := 0; i < 6; i++ {
+        id := fmt.Sprintf("query-%d", i)
+        node := NewNode(id, nil, tracker.executor(id, "db"))
+        node.Pool = "db"
+        engine.AddNode(node)
+    }
+    heavy := NewNode("heavy", nil, tracker.executor("heavy", "db", "heavy"))
+    heavy.Pool = "db"
+    heavy.PoolSlots = 2
+    engine.AddNode(heavy)
+    engine.AddNode(NewNode("free", nil, tracker.executor("free")))
+    if err := engine.PreprocessDAG(); err != nil {
+        t.Fatalf("PreprocessDAG failed: %v", err)
+    }
+
+    if _, err := engine.Run(context.Background(), nil); err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+    if tracker.max["db"] > 2 {
+        t.Errorf("Expected at most 2 db nodes running at once, got %d", tracker.max["db"])
+    }
+    if len(tracker.order) != 8 {
+        t.Errorf("Expected 8 nodes to run, got %d", len(tracker.order))
+    }
+}
+
+func TestRunStartsHigherPriorityNodesFirst(t *testing.T) {
+    tracker := newConcurrencyTracker()
+    engine := NewDAGEngine()
+    engine.MaxConcurrency = 1
+    for i, priority := range []int{1, 5, 3, 5} {
+        id := fmt.Sprintf("n%d", i)
+        node := NewNode(id, nil, tracker.executor(id))
+        node.Priority = priority
+        engine.AddNode(node)
+    }
+    if err := engine.PreprocessDAG(); err != nil {
+        t.Fatalf("PreprocessDAG failed: %v", err)
+    }
+
+    if _, err := engine.Run(context.Background(), nil); err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+    want := []string{"n1", "n3", "n2", "n0"}
+    if fmt.Sprint(tracker.order) != fmt.Sprint(want) {
+        t.Errorf("Expected start order %v, got %v", want, tracker.order)
+    }
+}
+
+func TestPreprocessValidatesResourcePools(t *testing.T) {
+    tests := []struct {
+        name      string
+        pool      string
+        poolSlots int
+    }{
+        {"undefined pool", "cache", 1},
+        {"more slots than the pool has", "db", 3},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            engine := NewDAGEngine()
+            engine.ResourcePools = map[string]int{"db": 2}
+            node := NewNode("A", nil, &recordingExecutor{})
+            node.Pool = tt.pool
+            node.PoolSlots = tt.poolSlots
+            engine.AddNode(node)
+
+            if err := engine.PreprocessDAG(); err == nil {
+                t.Error("Expected error, got none")
+            }
+        })
+    }
+}
@@ -0,0 +1,331 @@
+package dagengine
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "os/exec"
+    "sort"
+    "strings"
+    "time"
+)
+
+// DefaultShellInterpreter runs the code of ShellExecutors that have no
+// interpreter.
+var DefaultShellInterpreter = []string{"/bin/sh", "-c"}
+
+// shellWaitDelay is how long a cancelled command's output is waited for once
+// it has been killed, in case a process that escaped its group holds it.
+const shellWaitDelay = 5 * time.Second
+
+// maxShellStderr is how much of the end of stderr is kept, to be logged or
+// reported in errors.
+const maxShellStderr = 4096
+
+// errOutputTooLarge is returned by writes past the limit of a cappedBuffer.
+var errOutputTooLarge = errors.New("output too large")
+
+// ShellExecutor implements the Executor interface for commands and scripts
+// run by an interpreter.
+type ShellExecutor struct {
+    Code        string            // Command or script, passed as the interpreter's last argument
+    Interpreter []string          // Program and arguments that run Code (default: DefaultShellInterpreter)
+    Dir         string            // Working directory (default: the engine's)
+    Env         map[string]string // Environment variables; PATH is inherited from the engine unless set
+
+    // SecretEnv, if set, returns variables added to Env when the command
+    // starts, so that secrets are read as late as possible.
+    SecretEnv func(ctx context.Context) (map[string]string, error)
+}
+
+// ShellExitError is returned when a command exits with a non-zero status.
+type ShellExitError struct {
+    ExitCode int
+    Stderr   string // End of the command's stderr
+}
+
+func (e *ShellExitError) Error() string {
+    if e.Stderr == "" {
+        return fmt.Sprintf("shell command exited with status %d", e.ExitCode)
+    }
+    return fmt.Sprintf("shell command exited with status %d: %s", e.ExitCode, e.Stderr)
+}
+
+// NewShellExecutor creates a ShellExecutor from a node's executor
+// configuration:
+//
+//	config:
+//	  interpreter: [python3, -c]  # or a string such as "bash -c"
+//	  working_dir: /data
+//	  env:
+//	    LOG_LEVEL: debug
+func NewShellExecutor(code string, config map[string]interface{}) (*ShellExecutor, error) {
+    executor := &ShellExecutor{Code: code}
+
+    switch interpreter := config["interpreter"].(type) {
+    case nil:
+    case string:
+        executor.Interpreter = strings.Fields(interpreter)
+    case []string:
+        executor.Interpreter = append(executor.Interpreter, interpreter...)
+    case []interface{}:
+        for _, arg := range interpreter {
+            s, ok := arg.(string)
+            if !ok {
+                return nil, fmt.Errorf("shell interpreter arguments must be strings, got %T", arg)
+            }
+            executor.Interpreter = append(executor.Interpreter, s)
+        }
+    default:
+        return nil, fmt.Errorf("shell interpreter must be a string or a list, got %T", interpreter)
+    }
+    if config["interpreter"] != nil && len(executor.Interpreter) == 0 {
+        return nil, fmt.Errorf("shell interpreter must not be empty")
+    }
+
+    if dir, ok := config["working_dir"]; ok {
+        s, ok := dir.(string)
+        if !ok {
+            return nil, fmt.Errorf("shell working_dir must be a string, got %T", dir)
+        }
+        executor.Dir = s
+    }
+
+    switch env := config["env"].(type) {
+    case nil:
+    case map[string]string:
+        executor.Env = make(map[string]string, len(env))
+        for name, value := range env {
+            executor.Env[name] = value
+        }
+    case map[string]interface{}:
+        executor.Env = make(map[string]string, len(env))
+        for name, value := range env {
+            executor.Env[name] = fmt.Sprintf("%v", value)
+        }
+    default:
+        return nil, fmt.Errorf("shell env must be a map, got %T", env)
+    }
+
+    return executor, nil
+}
+
+// shellExecutorType registers ShellExecutor as the "shell" type. Its
+// commands see the workflow's variables, overridden by the node's, and the
+// workflow's secrets.
+func shellExecutorType() ExecutorType {
+    return ExecutorType{
+        Name: "shell",
+        New: func(spec ExecutorSpec, host *ExecutorHost) (Executor, error) {
+            executor, err := NewShellExecutor(spec.Code, spec.Config)
+            if err != nil {
+                return nil, err
+            }
+            env := make(map[string]string, len(host.Env)+len(executor.Env))
+            for name, value := range host.Env {
+                env[name] = value
+            }
+            for name, value := range executor.Env {
+                env[name] = value
+            }
+            executor.Env = env
+            executor.SecretEnv = host.SecretEnv
+            return executor, nil
+        },
+        Schema: ExecutorSchema{
+            Code: CodeRequired,
+            Config: map[string]ConfigField{
+                "interpreter": {Kind: ConfigString | ConfigList, Description: "Program and arguments that run the code"},
+                "working_dir": {Kind: ConfigString, Description: "Working directory of the command"},
+                "env":         {Kind: ConfigMap, Description: "Environment variables of the command"},
+            },
+        },
+        Validate: func(spec ExecutorSpec) error {
+            _, err := NewShellExecutor(spec.Code, spec.Config)
+            return err
+        },
+    }
+}
+
+// Describe identifies the command for result caching.
+func (s *ShellExecutor) Describe() ExecutorDescription {
+    return ExecutorDescription{
+        Type: "shell",
+        Code: s.Code,
+        Config: map[string]interface{}{
+            "interpreter": s.interpreter(),
+            "working_dir": s.Dir,
+            "env":         s.Env,
+        },
+    }
+}
+
+// interpreter returns the program and arguments that run the code.
+func (s *ShellExecutor) interpreter() []string {
+    if len(s.Interpreter) == 0 {
+        return DefaultShellInterpreter
+    }
+    return s.Interpreter
+}
+
+// Execute runs the command.
+//
+// The node's inputs are written to the command's stdin as a JSON object. If
+// the command prints anything to stdout, it must be a JSON object, which
+// becomes the node's result; otherwise the result is empty. The command
+// fails with a *ShellExitError if it exits with a non-zero status, and at
+// once if it prints more than 10 MiB to stdout. The end of what it prints to
+// stderr is logged (see Log) when it succeeds.
+//
+// When ctx is done, the command and every process it started are killed.
+func (s *ShellExecutor) Execute(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+    if inputs == nil {
+        inputs = map[string]interface{}{}
+    }
+    stdin, err := json.Marshal(inputs)
+    if err != nil {
+        return nil, fmt.Errorf("shell inputs: %w", err)
+    }
+    env, err := s.environ(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    interpreter := s.interpreter()
+    args := append(append([]string(nil), interpreter[1:]...), s.Code)
+    cmd := exec.CommandContext(ctx, interpreter[0], args...)
+    cmd.Dir = s.Dir
+    cmd.Env = env
+    cmd.Stdin = bytes.NewReader(stdin)
+    stdout := &cappedBuffer{max: maxHTTPBody}
+    stderr := &tailBuffer{max: maxShellStderr}
+    cmd.Stdout = stdout
+    cmd.Stderr = stderr
+    killProcessGroup(cmd)
+    cmd.WaitDelay = shellWaitDelay
+
+    if err := cmd.Run(); err != nil {
+        if ctx.Err() != nil {
+            return nil, fmt.Errorf("shell command interrupted: %w", ctx.Err())
+        }
+        if stdout.exceeded {
+            return nil, Permanent(fmt.Errorf("shell stdout exceeds %d bytes", maxHTTPBody))
+        }
+        var exitErr *exec.ExitError
+        if errors.As(err, &exitErr) {
+            return nil, &ShellExitError{ExitCode: exitErr.ExitCode(), Stderr: lastBytes(stderr.String(), maxShellStderr)}
+        }
+        return nil, fmt.Errorf("shell command failed to run: %w", err)
+    }
+
+    if message := lastBytes(stderr.String(), maxShellStderr); message != "" {
+        Log(ctx, LogInfo, message)
+    }
+    return outputResult("shell", stdout.Bytes())
+}
+
+// environ returns the command's environment, sorted by name.
+func (s *ShellExecutor) environ(ctx context.Context) ([]string, error) {
+    vars := make(map[string]string, len(s.Env)+1)
+    if path, ok := os.LookupEnv("PATH"); ok {
+        vars["PATH"] = path
+    }
+    for name, value := range s.Env {
+        vars[name] = value
+    }
+    if s.SecretEnv != nil {
+        secrets, err := s.SecretEnv(ctx)
+        if err != nil {
+            return nil, fmt.Errorf("shell secrets: %w", err)
+        }
+        for name, value := range secrets {
+            vars[name] = value
+        }
+    }
+
+    env := make([]string, 0, len(vars))
+    for name, value := range vars {
+        env = append(env, name+"="+value)
+    }
+    sort.Strings(env)
+    return env, nil
+}
+
+// outputResult parses the stdout of a command or module of an executor of
+// type kind into a node result.
+func outputResult(kind string, stdout []byte) (map[string]interface{}, error) {
+    if len(bytes.TrimSpace(stdout)) == 0 {
+        return map[string]interface{}{}, nil
+    }
+    var result map[string]interface{}
+    if err := json.Unmarshal(stdout, &result); err != nil {
+        return nil, fmt.Errorf("%s output must be a JSON object: %w", kind, err)
+    }
+    if result == nil {
+        result = map[string]interface{}{}
+    }
+    return result, nil
+}
+
+// lastBytes returns at most the last n bytes of s.
+func lastBytes(s string, n int) string {
+    s = strings.TrimSpace(s)
+    if len(s) <= n {
+        return s
+    }
+    return "..." + s[len(s)-n:]
+}
+
+// cappedBuffer is a buffer that fails writes past max bytes, so that a
+// command or module cannot make the engine hold an unbounded output.
+type cappedBuffer struct {
+    buf      bytes.Buffer
+    max      int
+    exceeded bool // Set once a write was refused
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+    if b.buf.Len()+len(p) > b.max {
+        b.exceeded = true
+        return 0, errOutputTooLarge
+    }
+    return b.buf.Write(p)
+}
+
+// Bytes returns the bytes written.
+func (b *cappedBuffer) Bytes() []byte {
+    return b.buf.Bytes()
+}
+
+// tailBuffer keeps the last max bytes written to it.
+type tailBuffer struct {
+    data      []byte
+    max       int
+    truncated bool // Set once bytes were dropped
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+    n := len(p)
+    if len(p) > b.max {
+        p = p[len(p)-b.max:]
+        b.truncated = true
+    }
+    b.data = append(b.data, p...)
+    if len(b.data) > b.max {
+        b.data = append(b.data[:0], b.data[len(b.data)-b.max:]...)
+        b.truncated = true
+    }
+    return n, nil
+}
+
+// String returns the bytes kept, preceded by "..." if earlier ones were
+// dropped.
+func (b *tailBuffer) String() string {
+    if b.truncated {
+        return "..." + string(b.data)
+    }
+    return string(b.data)
+}
@@ -0,0 +1,155 @@
+package dagengine
+
+import (
+    "context"
+    "errors"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestShellExecutor(t *testing.T) {
+    inputs := map[string]interface{}{"extract": map[string]interface{}{"count": 3}}
+
+    tests := []struct {
+        name     string
+        executor *ShellExecutor
+        want     map[string]interface{}
+    }{
+        {
+            name:     "stdin to stdout",
+            executor: &ShellExecutor{Code: `cat`},
+            want:     map[string]interface{}{"extract": map[string]interface{}{"count": float64(3)}},
+        },
+        {
+            name:     "no output",
+            executor: &ShellExecutor{Code: `true`},
+            want:     map[string]interface{}{},
+        },
+        {
+            name: "environment and secrets",
+            executor: &ShellExecutor{
+                Code: `printf '{"region": "%s", "token": "%s", "path": %s}' "$REGION" "$TOKEN" "$([ -n "$PATH" ] && echo true || echo false)"`,
+                Env:  map[string]string{"REGION": "eu", "TOKEN": "overridden"},
+                SecretEnv: func(ctx context.Context) (map[string]string, error) {
+                    return map[string]string{"TOKEN": "s3cret"}, nil
+                },
+            },
+            want: map[string]interface{}{"region": "eu", "token": "s3cret", "path": true},
+        },
+        {
+            name:     "working directory",
+            executor: &ShellExecutor{Code: `printf '{"dir": "%s"}' "$(pwd)"`, Dir: "/"},
+            want:     map[string]interface{}{"dir": "/"},
+        },
+        {
+            name:     "interpreter",
+            executor: &ShellExecutor{Code: `echo '{"shell": "bash"}'`, Interpreter: []string{"bash", "-c"}},
+            want:     map[string]interface{}{"shell": "bash"},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := tt.executor.Execute(context.Background(), inputs)
+            if err != nil {
+                t.Fatalf("Execute failed: %v", err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("Expected %#v, got %#v", tt.want, got)
+            }
+        })
+    }
+}
+
+func TestShellExecutorErrors(t *testing.T) {
+    _, err := (&ShellExecutor{Code: `echo "disk full" >&2; exit 3`}).Execute(context.Background(), nil)
+    var exitErr *ShellExitError
+    if !errors.As(err, &exitErr) {
+        t.Fatalf("Expected a ShellExitError, got %v", err)
+    }
+    if exitErr.ExitCode != 3 || exitErr.Stderr != "disk full" {
+        t.Errorf("Expected status 3 and stderr 'disk full', got %d and %q", exitErr.ExitCode, exitErr.Stderr)
+    }
+
+    // Only the end of a long stderr is kept
+    _, err = (&ShellExecutor{Code: `i=0; while [ $i -lt 2000 ]; do i=$((i+1)); echo "line $i" >&2; done; exit 1`}).Execute(context.Background(), nil)
+    if !errors.As(err, &exitErr) {
+        t.Fatalf("Expected a ShellExitError, got %v", err)
+    }
+    if !strings.HasPrefix(exitErr.Stderr, "...") || !strings.HasSuffix(exitErr.Stderr, "line 2000") || len(exitErr.Stderr) > maxShellStderr+3 {
+        t.Errorf("Expected the last %d bytes of stderr, got %d bytes ending with %q", maxShellStderr, len(exitErr.Stderr), lastBytes(exitErr.Stderr, 20))
+    }
+
+    _, err = (&ShellExecutor{Code: `head -c 11000000 /dev/zero`}).Execute(context.Background(), nil)
+    if err == nil || !strings.Contains(err.Error(), "stdout exceeds") || !IsPermanent(err) {
+        t.Errorf("Expected a permanent error for a large stdout, got %v", err)
+    }
+
+    tests := []struct {
+        name     string
+        executor *ShellExecutor
+        want     string
+    }{
+        {"non-object output", &ShellExecutor{Code: `echo '[1, 2]'`}, "must be a JSON object"},
+        {"missing interpreter", &ShellExecutor{Code: `true`, Interpreter: []string{"/nonexistent/sh"}}, "failed to run"},
+        {"secret failure", &ShellExecutor{Code: `true`, SecretEnv: func(ctx context.Context) (map[string]string, error) {
+            return nil, errors.New("vault sealed")
+        }}, "vault sealed"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := tt.executor.Execute(context.Background(), nil)
+            if err == nil || !strings.Contains(err.Error(), tt.want) {
+                t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+            }
+        })
+    }
+}
+
+func TestShellExecutorKillsProcessGroup(t *testing.T) {
+    ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+    defer cancel()
+
+    // The background sleep keeps stdout open unless it is killed too
+    start := time.Now()
+    _, err := (&ShellExecutor{Code: `sleep 30 & sleep 30; wait`}).Execute(ctx, nil)
+    if !errors.Is(err, context.DeadlineExceeded) {
+        t.Errorf("Expected the error to wrap context.DeadlineExceeded, got %v", err)
+    }
+    if elapsed := time.Since(start); elapsed > 2*time.Second {
+        t.Errorf("Expected the command to be killed at the deadline, ran for %v", elapsed)
+    }
+}
+
+func TestNewShellExecutor(t *testing.T) {
+    executor, err := NewShellExecutor("run.py", map[string]interface{}{
+        "interpreter": "python3 -u",
+        "working_dir": "/srv",
+        "env":         map[string]interface{}{"WORKERS": 4},
+    })
+    if err != nil {
+        t.Fatalf("NewShellExecutor failed: %v", err)
+    }
+    if !reflect.DeepEqual(executor.Interpreter, []string{"python3", "-u"}) || executor.Dir != "/srv" || executor.Env["WORKERS"] != "4" {
+        t.Errorf("Unexpected executor: %+v", executor)
+    }
+
+    tests := []struct {
+        name   string
+        config map[string]interface{}
+    }{
+        {"empty interpreter", map[string]interface{}{"interpreter": ""}},
+        {"bad interpreter", map[string]interface{}{"interpreter": 1}},
+        {"bad working dir", map[string]interface{}{"working_dir": true}},
+        {"bad env", map[string]interface{}{"env": "A=1"}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if _, err := NewShellExecutor("true", tt.config); err == nil {
+                t.Error("Expected an error")
+            }
+        })
+    }
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package dagengine
+
+import "os/exec"
+
+// killProcessGroup leaves the default behaviour, killing only the command
+// itself, on systems without process groups.
+func killProcessGroup(cmd *exec.Cmd) {}
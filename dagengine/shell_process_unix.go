@@ -0,0 +1,17 @@
+//go:build unix
+
+package dagengine
+
+import (
+    "os/exec"
+    "syscall"
+)
+
+// killProcessGroup makes the command run in its own process group, killed
+// as a whole when the command's context is done.
+func killProcessGroup(cmd *exec.Cmd) {
+    cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+    cmd.Cancel = func() error {
+        return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+    }
+}
@@ -0,0 +1,295 @@
+package dagengine
+
+import (
+    "context"
+    "fmt"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// versionPattern matches workflow versions, e.g. "1.4.2" or "2024-06".
+var versionPattern = regexp.MustCompile(`^[0-9A-Za-z_+-]+(\.[0-9A-Za-z_+-]+)*$`)
+
+// SubWorkflowRunner runs the workflows that sub-workflow nodes call, usually
+// through an orchestrator.
+type SubWorkflowRunner interface {
+    // RunSubWorkflow runs a version of a workflow that matches the request's
+    // constraint and waits for it to finish. When ctx is done, the child
+    // workflow must be stopped.
+    RunSubWorkflow(ctx context.Context, req *SubWorkflowRequest) (*SubWorkflowResult, error)
+}
+
+// SubWorkflowRunnerFunc adapts a function to the SubWorkflowRunner interface.
+type SubWorkflowRunnerFunc func(ctx context.Context, req *SubWorkflowRequest) (*SubWorkflowResult, error)
+
+// RunSubWorkflow calls f(ctx, req).
+func (f SubWorkflowRunnerFunc) RunSubWorkflow(ctx context.Context, req *SubWorkflowRequest) (*SubWorkflowResult, error) {
+    return f(ctx, req)
+}
+
+// SubWorkflowRequest asks a SubWorkflowRunner to run a child workflow.
+type SubWorkflowRequest struct {
+    WorkflowID   string
+    Version      string                 // Version constraint (see VersionMatches)
+    Inputs       map[string]interface{} // Workflow-level inputs of the child
+    ParentRunID  string                 // Run of the calling node, if known
+    ParentNodeID string                 // Calling node, if known
+}
+
+// SubWorkflowResult describes a child workflow that completed.
+type SubWorkflowResult struct {
+    ExecutionID string                 // Execution of the child, as the runner identifies it
+    Version     string                 // Version of the child that ran
+    Outputs     map[string]interface{} // Results of the child's nodes, by node ID
+}
+
+// SubWorkflowExecutor implements the Executor interface for nodes that run
+// another workflow and wait for it to complete.
+//
+// Inputs is a template of the child's workflow-level inputs, with the
+// placeholders of HTTPExecutor bodies (without secrets): "{{ path }}" is
+// replaced by the value at the dot-separated path in the node's inputs.
+type SubWorkflowExecutor struct {
+    WorkflowID string
+    Version    string            // Version constraint (default: the latest version)
+    Inputs     interface{}       // Template of the child's inputs (default: the node's inputs)
+    Outputs    map[string]string // Result keys and the paths of their values in the child's outputs (default: all outputs)
+    Runner     SubWorkflowRunner // Runs the child; without it, runs fail
+}
+
+// NewSubWorkflowExecutor creates a SubWorkflowExecutor from a node's
+// executor configuration:
+//
+//	config:
+//	  workflow_id: enrich-user
+//	  version: "1.x"
+//	  inputs:
+//	    user_id: "{{ workflow.user_id }}"
+//	  outputs:
+//	    score: score.value
+func NewSubWorkflowExecutor(config map[string]interface{}) (*SubWorkflowExecutor, error) {
+    executor := &SubWorkflowExecutor{Inputs: config["inputs"]}
+    if inputs, ok := executor.Inputs.(map[string]string); ok {
+        converted := make(map[string]interface{}, len(inputs))
+        for name, value := range inputs {
+            converted[name] = value
+        }
+        executor.Inputs = converted
+    }
+
+    var err error
+    if executor.WorkflowID, err = configString("subworkflow", config, "workflow_id"); err != nil {
+        return nil, err
+    }
+    if executor.Version, err = configString("subworkflow", config, "version"); err != nil {
+        return nil, err
+    }
+    if executor.Outputs, err = configStringMap("subworkflow", config, "outputs"); err != nil {
+        return nil, err
+    }
+
+    if err := executor.Validate(); err != nil {
+        return nil, err
+    }
+    return executor, nil
+}
+
+// subworkflowExecutorType registers SubWorkflowExecutor as the "subworkflow"
+// type. Its nodes run their child workflows with the host's SubWorkflows.
+func subworkflowExecutorType() ExecutorType {
+    return ExecutorType{
+        Name: "subworkflow",
+        New: func(spec ExecutorSpec, host *ExecutorHost) (Executor, error) {
+            executor, err := NewSubWorkflowExecutor(spec.Config)
+            if err != nil {
+                return nil, err
+            }
+            executor.Runner = host.SubWorkflows
+            return executor, nil
+        },
+        Schema: ExecutorSchema{
+            Config: map[string]ConfigField{
+                "workflow_id": {Kind: ConfigString, Required: true, Description: "Workflow to run"},
+                "version":     {Kind: ConfigString, Description: "Version constraint, e.g. 1.4.2 or 1.x (default: latest)"},
+                "inputs":      {Kind: ConfigMap, Description: "Template of the child's inputs (default: the node's inputs)"},
+                "outputs":     {Kind: ConfigMap, Description: "Result keys and their paths in the child's outputs"},
+            },
+        },
+        Validate: func(spec ExecutorSpec) error {
+            _, err := NewSubWorkflowExecutor(spec.Config)
+            return err
+        },
+    }
+}
+
+// Validate checks the executor's configuration.
+func (s *SubWorkflowExecutor) Validate() error {
+    if s.WorkflowID == "" {
+        return fmt.Errorf("subworkflow workflow_id is required")
+    }
+    if err := ValidateVersionConstraint(s.Version); err != nil {
+        return fmt.Errorf("subworkflow %w", err)
+    }
+    for key, path := range s.Outputs {
+        if path == "" {
+            return fmt.Errorf("subworkflow output '%s' has no path", key)
+        }
+    }
+    return nil
+}
+
+// Describe identifies the child workflow for result caching. The version
+// that runs may change while the constraint stays the same, so nodes that
+// pin a range of versions should not be cached.
+func (s *SubWorkflowExecutor) Describe() ExecutorDescription {
+    return ExecutorDescription{
+        Type: "subworkflow",
+        Config: map[string]interface{}{
+            "workflow_id": s.WorkflowID,
+            "version":     s.Version,
+            "inputs":      s.Inputs,
+            "outputs":     s.Outputs,
+        },
+    }
+}
+
+// Execute runs the child workflow with Runner and waits for it to finish.
+// When ctx is done, for instance because the parent run was cancelled or
+// the node timed out, the child is stopped too.
+//
+// The node's result holds the values of Outputs, or the results of all the
+// child's nodes by node ID if Outputs is empty. A child that fails makes the
+// node fail, and is retried according to the node's retry policy.
+func (s *SubWorkflowExecutor) Execute(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+    if s.Runner == nil {
+        return nil, Permanent(fmt.Errorf("no sub-workflow runner is available for workflow %s", s.WorkflowID))
+    }
+
+    childInputs := inputs
+    if s.Inputs != nil {
+        t := &inputTemplate{ctx: ctx, inputs: inputs}
+        expanded, err := t.expandValue(s.Inputs)
+        if err != nil {
+            return nil, Permanent(fmt.Errorf("subworkflow inputs: %w", err))
+        }
+        var ok bool
+        if childInputs, ok = expanded.(map[string]interface{}); !ok {
+            return nil, Permanent(fmt.Errorf("subworkflow inputs must be a map, got %T", expanded))
+        }
+    }
+
+    req := &SubWorkflowRequest{WorkflowID: s.WorkflowID, Version: s.Version, Inputs: childInputs}
+    req.ParentRunID, req.ParentNodeID = CurrentNode(ctx)
+    result, err := s.Runner.RunSubWorkflow(ctx, req)
+    if err != nil {
+        if ctx.Err() != nil {
+            return nil, fmt.Errorf("sub-workflow %s interrupted: %w", s.WorkflowID, ctx.Err())
+        }
+        return nil, fmt.Errorf("sub-workflow %s failed: %w", s.WorkflowID, err)
+    }
+    if result.Version != "" && !VersionMatches(s.Version, result.Version) {
+        return nil, Permanent(fmt.Errorf("sub-workflow %s ran version %s, which does not match '%s'", s.WorkflowID, result.Version, s.Version))
+    }
+    Log(ctx, LogInfo, fmt.Sprintf("sub-workflow %s version %s completed as execution %s", s.WorkflowID, result.Version, result.ExecutionID))
+
+    if len(s.Outputs) == 0 {
+        if result.Outputs == nil {
+            return map[string]interface{}{}, nil
+        }
+        return result.Outputs, nil
+    }
+    keys := make([]string, 0, len(s.Outputs))
+    for key := range s.Outputs {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    outputs := make(map[string]interface{}, len(keys))
+    for _, key := range keys {
+        value, ok := lookupPath(result.Outputs, s.Outputs[key])
+        if !ok {
+            return nil, Permanent(fmt.Errorf("sub-workflow %s has no output '%s' for '%s'", s.WorkflowID, s.Outputs[key], key))
+        }
+        outputs[key] = value
+    }
+    return outputs, nil
+}
+
+// VersionMatches reports whether a workflow version satisfies a constraint:
+// "" and "latest" match every version, a version matches itself, and a
+// constraint ending with ".x" or ".*" matches the versions that start with
+// the rest, e.g. "1.x" matches "1.0.0" and "1.4.2" but not "10.0.0".
+func VersionMatches(constraint, version string) bool {
+    if constraint == "" || constraint == "latest" {
+        return true
+    }
+    if prefix, ok := versionWildcard(constraint); ok {
+        return strings.HasPrefix(version, prefix+".")
+    }
+    return version == constraint
+}
+
+// CompareVersions compares two workflow versions one dot-separated part at a
+// time: numerically for parts that are numbers, in string order otherwise. It
+// returns -1 if a is older than b, 1 if it is newer and 0 if they are equal,
+// so that "1.10.0" is newer than "1.9.0". A version is older than the
+// versions that extend it, e.g. "1.0" is older than "1.0.1".
+func CompareVersions(a, b string) int {
+    aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+    for i := 0; i < len(aParts) && i < len(bParts); i++ {
+        if c := compareVersionParts(aParts[i], bParts[i]); c != 0 {
+            return c
+        }
+    }
+    switch {
+    case len(aParts) < len(bParts):
+        return -1
+    case len(aParts) > len(bParts):
+        return 1
+    }
+    return 0
+}
+
+// compareVersionParts compares a part of two versions.
+func compareVersionParts(a, b string) int {
+    aNumber, aErr := strconv.ParseUint(a, 10, 64)
+    bNumber, bErr := strconv.ParseUint(b, 10, 64)
+    if aErr == nil && bErr == nil {
+        switch {
+        case aNumber < bNumber:
+            return -1
+        case aNumber > bNumber:
+            return 1
+        }
+        return 0
+    }
+    return strings.Compare(a, b)
+}
+
+// ValidateVersionConstraint checks that constraint is one VersionMatches
+// understands.
+func ValidateVersionConstraint(constraint string) error {
+    if constraint == "" || constraint == "latest" {
+        return nil
+    }
+    version := constraint
+    if prefix, ok := versionWildcard(constraint); ok {
+        version = prefix
+    }
+    if !versionPattern.MatchString(version) {
+        return fmt.Errorf("version constraint '%s' must be a version, a version ending with .x, or latest", constraint)
+    }
+    return nil
+}
+
+// versionWildcard returns the versions prefix of a constraint ending with
+// ".x" or ".*".
+func versionWildcard(constraint string) (string, bool) {
+    for _, suffix := range []string{".x", ".*"} {
+        if prefix, ok := strings.CutSuffix(constraint, suffix); ok {
+            return prefix, true
+        }
+    }
+    return "", false
+}
@@ -0,0 +1,265 @@
+package dagengine
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+)
+
+// childRunner runs "score" workflows in a child engine and records the
+// requests it receives.
+type childRunner struct {
+    requests []*SubWorkflowRequest
+}
+
+func (c *childRunner) RunSubWorkflow(ctx context.Context, req *SubWorkflowRequest) (*SubWorkflowResult, error) {
+    c.requests = append(c.requests, req)
+    if req.WorkflowID != "score" {
+        return nil, fmt.Errorf("workflow %s not found", req.WorkflowID)
+    }
+
+    child := NewDAGEngine()
+    child.AddNode(NewNode("rate", nil, ExecutorFunc(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+        user, _ := lookupPath(inputs, WorkflowInputsKey+".user")
+        return map[string]interface{}{"value": 7, "user": user}, nil
+    })))
+    if err := child.PreprocessDAG(); err != nil {
+        return nil, err
+    }
+    result, err := child.Run(ctx, req.Inputs)
+    if err != nil {
+        return nil, err
+    }
+    outputs := make(map[string]interface{}, len(result.Outputs))
+    for nodeID, output := range result.Outputs {
+        outputs[nodeID] = output
+    }
+    return &SubWorkflowResult{ExecutionID: "child-1", Version: "1.2.0", Outputs: outputs}, nil
+}
+
+func TestSubWorkflowExecutor(t *testing.T) {
+    inputs := map[string]interface{}{
+        WorkflowInputsKey: map[string]interface{}{"user": "ada"},
+    }
+
+    tests := []struct {
+        name     string
+        executor *SubWorkflowExecutor
+        want     map[string]interface{}
+    }{
+        {
+            name:     "all outputs",
+            executor: &SubWorkflowExecutor{WorkflowID: "score", Inputs: map[string]interface{}{"user": "{{ workflow.user }}"}},
+            want:     map[string]interface{}{"rate": map[string]interface{}{"value": 7, "user": "ada"}},
+        },
+        {
+            name: "mapped outputs",
+            executor: &SubWorkflowExecutor{
+                WorkflowID: "score",
+                Version:    "1.x",
+                Inputs:     map[string]interface{}{"user": "{{ workflow.user }}"},
+                Outputs:    map[string]string{"score": "rate.value", "rated": "rate.user"},
+            },
+            want: map[string]interface{}{"score": 7, "rated": "ada"},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            tt.executor.Runner = &childRunner{}
+            got, err := tt.executor.Execute(context.Background(), inputs)
+            if err != nil {
+                t.Fatalf("Execute failed: %v", err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("Expected %v, got %v", tt.want, got)
+            }
+        })
+    }
+
+    // Without a template, the child gets the node's inputs
+    runner := &childRunner{}
+    if _, err := (&SubWorkflowExecutor{WorkflowID: "score", Version: "1.2.0", Runner: runner}).Execute(context.Background(), inputs); err != nil {
+        t.Fatalf("Execute failed: %v", err)
+    }
+    if !reflect.DeepEqual(runner.requests[0].Inputs, inputs) {
+        t.Errorf("Expected the child's inputs to be %v, got %v", inputs, runner.requests[0].Inputs)
+    }
+}
+
+func TestSubWorkflowExecutorInRun(t *testing.T) {
+    runner := &childRunner{}
+    executor, err := DefaultExecutorRegistry().New(ExecutorSpec{
+        Type:   "subworkflow",
+        Config: map[string]interface{}{"workflow_id": "score", "inputs": map[string]interface{}{"user": "{{ workflow.user }}"}},
+    }, &ExecutorHost{SubWorkflows: runner})
+    if err != nil {
+        t.Fatalf("New failed: %v", err)
+    }
+
+    engine := NewDAGEngine()
+    engine.AddNode(NewNode("enrich", nil, executor))
+    plan, err := engine.Compile()
+    if err != nil {
+        t.Fatalf("Compile failed: %v", err)
+    }
+    if _, err := engine.StartExecution(context.Background(), "exec-9", plan, map[string]interface{}{"user": "ada"}).Wait(); err != nil {
+        t.Fatalf("Run failed: %v", err)
+    }
+    if len(runner.requests) != 1 || runner.requests[0].ParentRunID != "exec-9" || runner.requests[0].ParentNodeID != "enrich" {
+        t.Errorf("Expected one request from exec-9/enrich, got %+v", runner.requests)
+    }
+}
+
+func TestSubWorkflowExecutorCancellation(t *testing.T) {
+    stopped := make(chan error, 1)
+    runner := SubWorkflowRunnerFunc(func(ctx context.Context, req *SubWorkflowRequest) (*SubWorkflowResult, error) {
+        <-ctx.Done()
+        stopped <- ctx.Err()
+        return nil, ctx.Err()
+    })
+
+    engine := NewDAGEngine()
+    node := NewNode("enrich", nil, &SubWorkflowExecutor{WorkflowID: "score", Runner: runner})
+    node.Timeout = 20 * time.Millisecond
+    engine.AddNode(node)
+    if err := engine.PreprocessDAG(); err != nil {
+        t.Fatalf("PreprocessDAG failed: %v", err)
+    }
+    if _, err := engine.Run(context.Background(), nil); err == nil {
+        t.Fatal("Expected the run to fail")
+    }
+    select {
+    case err := <-stopped:
+        if !errors.Is(err, context.DeadlineExceeded) {
+            t.Errorf("Expected the child to be stopped by the node's deadline, got %v", err)
+        }
+    case <-time.After(time.Second):
+        t.Fatal("Expected the child to be stopped")
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    _, err := (&SubWorkflowExecutor{WorkflowID: "score", Runner: runner}).Execute(ctx, nil)
+    if err == nil || !strings.Contains(err.Error(), "interrupted") || !errors.Is(err, context.Canceled) {
+        t.Errorf("Expected an interrupted error, got %v", err)
+    }
+}
+
+func TestSubWorkflowExecutorErrors(t *testing.T) {
+    tests := []struct {
+        name      string
+        executor  *SubWorkflowExecutor
+        want      string
+        permanent bool
+    }{
+        {"no runner", &SubWorkflowExecutor{WorkflowID: "score"}, "no sub-workflow runner", true},
+        {"child failure", &SubWorkflowExecutor{WorkflowID: "audit", Runner: &childRunner{}}, "workflow audit not found", false},
+        {"missing input", &SubWorkflowExecutor{WorkflowID: "score", Inputs: map[string]interface{}{"user": "{{ workflow.name }}"}, Runner: &childRunner{}}, "'workflow.name' not found", true},
+        {"missing output", &SubWorkflowExecutor{WorkflowID: "score", Outputs: map[string]string{"score": "rate.total"}, Runner: &childRunner{}}, "no output 'rate.total'", true},
+        {"version mismatch", &SubWorkflowExecutor{WorkflowID: "score", Version: "2.x", Runner: &childRunner{}}, "does not match '2.x'", true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := tt.executor.Execute(context.Background(), map[string]interface{}{WorkflowInputsKey: map[string]interface{}{}})
+            if err == nil || !strings.Contains(err.Error(), tt.want) {
+                t.Fatalf("Expected an error containing %q, got %v", tt.want, err)
+            }
+            if IsPermanent(err) != tt.permanent {
+                t.Errorf("Expected permanent to be %v, got %v (%v)", tt.permanent, IsPermanent(err), err)
+            }
+        })
+    }
+}
+
+func TestVersionMatches(t *testing.T) {
+    tests := []struct {
+        constraint string
+        version    string
+        want       bool
+    }{
+        {"", "1.0.0", true},
+        {"latest", "2.3.1", true},
+        {"1.4.2", "1.4.2", true},
+        {"1.4.2", "1.4.3", false},
+        {"1.x", "1.0.0", true},
+        {"1.x", "1.4.2", true},
+        {"1.x", "10.0.0", false},
+        {"1.4.*", "1.4.9", true},
+        {"1.4.*", "1.5.0", false},
+    }
+    for _, tt := range tests {
+        if got := VersionMatches(tt.constraint, tt.version); got != tt.want {
+            t.Errorf("Expected VersionMatches(%q, %q) to be %v, got %v", tt.constraint, tt.version, tt.want, got)
+        }
+    }
+
+    for _, constraint := range []string{"", "latest", "1.4.2", "1.x", "2024-06.*"} {
+        if err := ValidateVersionConstraint(constraint); err != nil {
+            t.Errorf("Expected %q to be valid, got %v", constraint, err)
+        }
+    }
+    for _, constraint := range []string{">=1.0", "1..2", ".x", "1.x ", "*"} {
+        if err := ValidateVersionConstraint(constraint); err == nil {
+            t.Errorf("Expected %q to be invalid", constraint)
+        }
+    }
+}
+
+func TestCompareVersions(t *testing.T) {
+    tests := []struct {
+        a, b string
+        want int
+    }{
+        {"1.10.0", "1.9.0", 1},
+        {"1.9.0", "1.10.0", -1},
+        {"2.0.0", "10.0.0", -1},
+        {"1.4.2", "1.4.2", 0},
+        {"1.0", "1.0.1", -1},
+        {"1.0.0-rc2", "1.0.0-rc1", 1},
+        {"2024-06", "2024-05", 1},
+    }
+    for _, tt := range tests {
+        if got := CompareVersions(tt.a, tt.b); got != tt.want {
+            t.Errorf("Expected CompareVersions(%q, %q) to be %d, got %d", tt.a, tt.b, tt.want, got)
+        }
+    }
+}
+
+func TestNewSubWorkflowExecutor(t *testing.T) {
+    executor, err := NewSubWorkflowExecutor(map[string]interface{}{
+        "workflow_id": "score",
+        "version":     "1.x",
+        "inputs":      map[string]string{"user": "{{ workflow.user }}"},
+        "outputs":     map[string]interface{}{"score": "rate.value"},
+    })
+    if err != nil {
+        t.Fatalf("NewSubWorkflowExecutor failed: %v", err)
+    }
+    if executor.WorkflowID != "score" || executor.Version != "1.x" || executor.Outputs["score"] != "rate.value" {
+        t.Errorf("Unexpected executor: %+v", executor)
+    }
+    if _, ok := executor.Inputs.(map[string]interface{}); !ok {
+        t.Errorf("Expected the inputs template to be a map, got %T", executor.Inputs)
+    }
+
+    tests := []struct {
+        name   string
+        config map[string]interface{}
+    }{
+        {"missing workflow_id", map[string]interface{}{"version": "1.x"}},
+        {"bad version", map[string]interface{}{"workflow_id": "score", "version": "^1.2"}},
+        {"empty output path", map[string]interface{}{"workflow_id": "score", "outputs": map[string]interface{}{"score": ""}}},
+        {"bad outputs", map[string]interface{}{"workflow_id": "score", "outputs": []interface{}{"score"}}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if _, err := NewSubWorkflowExecutor(tt.config); err == nil {
+                t.Error("Expected an error")
+            }
+        })
+    }
+}
@@ -0,0 +1,114 @@
+package dagengine
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+// placeholderPattern matches template placeholders.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// inputTemplate expands the placeholders of executor templates: "{{ path }}"
+// is replaced by the value at a dot-separated path in the node's inputs and
+// "{{ secret:name/key }}" by a key of a secret.
+type inputTemplate struct {
+    ctx    context.Context
+    inputs map[string]interface{}
+    secret func(ctx context.Context, name, key string) (string, error)
+}
+
+// resolve returns the value of a placeholder.
+func (t *inputTemplate) resolve(placeholder string) (interface{}, error) {
+    if ref, ok := strings.CutPrefix(placeholder, "secret:"); ok {
+        name, key, ok := strings.Cut(ref, "/")
+        if !ok {
+            return nil, fmt.Errorf("secret placeholder '%s' must be secret:name/key", placeholder)
+        }
+        if t.secret == nil {
+            return nil, fmt.Errorf("no secrets are available for '%s'", placeholder)
+        }
+        return t.secret(t.ctx, name, key)
+    }
+    value, ok := lookupPath(t.inputs, placeholder)
+    if !ok {
+        return nil, fmt.Errorf("'%s' not found in inputs", placeholder)
+    }
+    return value, nil
+}
+
+// expand replaces the placeholders of s by their values, formatted as
+// strings and passed through escape if set.
+func (t *inputTemplate) expand(s string, escape func(string) string) (string, error) {
+    var err error
+    expanded := placeholderPattern.ReplaceAllStringFunc(s, func(match string) string {
+        if err != nil {
+            return ""
+        }
+        var value interface{}
+        if value, err = t.resolve(placeholderPattern.FindStringSubmatch(match)[1]); err != nil {
+            return ""
+        }
+        formatted := formatTemplateValue(value)
+        if escape != nil {
+            formatted = escape(formatted)
+        }
+        return formatted
+    })
+    return expanded, err
+}
+
+// expandValue expands the strings in a structured value. A string that is a
+// single placeholder is replaced by the value itself.
+func (t *inputTemplate) expandValue(value interface{}) (interface{}, error) {
+    switch v := value.(type) {
+    case string:
+        if match := placeholderPattern.FindStringSubmatch(v); match != nil && match[0] == strings.TrimSpace(v) {
+            return t.resolve(match[1])
+        }
+        return t.expand(v, nil)
+    case map[string]interface{}:
+        expanded := make(map[string]interface{}, len(v))
+        for key, item := range v {
+            converted, err := t.expandValue(item)
+            if err != nil {
+                return nil, err
+            }
+            expanded[key] = converted
+        }
+        return expanded, nil
+    case []interface{}:
+        expanded := make([]interface{}, len(v))
+        for i, item := range v {
+            converted, err := t.expandValue(item)
+            if err != nil {
+                return nil, err
+            }
+            expanded[i] = converted
+        }
+        return expanded, nil
+    }
+    return value, nil
+}
+
+// formatTemplateValue formats a value for a string template: strings as is,
+// numbers without exponent and other values as JSON.
+func formatTemplateValue(value interface{}) string {
+    switch v := value.(type) {
+    case string:
+        return v
+    case nil:
+        return ""
+    }
+    if number, ok := toFloat(value); ok {
+        return strconv.FormatFloat(number, 'f', -1, 64)
+    }
+    data, err := json.Marshal(value)
+    if err != nil {
+        return fmt.Sprintf("%v", value)
+    }
+    return string(data)
+}
@@ -0,0 +1,88 @@
+package dagengine
+
+// TriggerRule decides, from the final statuses of a node's parents, whether
+// the node runs once all of its dependencies are resolved.
+//
+// Rules are only evaluated after every parent has reached a final status. Under
+// the FailFast policy the run's nodes are cancelled as soon as one fails, and
+// the nodes that have yet to run are skipped, except those whose rule reacts
+// to failures (AllDone, OneFailed): they still run once their parents are
+// done, unless the whole run was cancelled or timed out.
+type TriggerRule string
+
+const (
+    // AllSuccess runs the node only if every parent completed (default).
+    AllSuccess TriggerRule = "all_success"
+    // AllDone runs the node whatever the outcome of its parents.
+    AllDone TriggerRule = "all_done"
+    // OneFailed runs the node if at least one parent failed, e.g. for cleanup or alerting.
+    OneFailed TriggerRule = "one_failed"
+    // OneSuccess runs the node if at least one parent completed.
+    OneSuccess TriggerRule = "one_success"
+    // NoneFailed runs the node if no parent failed; skipped parents are allowed.
+    NoneFailed TriggerRule = "none_failed"
+)
+
+// Valid reports whether r is a known trigger rule. The empty rule means AllSuccess.
+func (r TriggerRule) Valid() bool {
+    switch r {
+    case "", AllSuccess, AllDone, OneFailed, OneSuccess, NoneFailed:
+        return true
+    }
+    return false
+}
+
+// reactsToFailure reports whether nodes with rule r run after upstream
+// failures, and so must survive a FailFast cancellation.
+func (r TriggerRule) reactsToFailure() bool {
+    return r == AllDone || r == OneFailed
+}
+
+// blockedStatus returns the status to assign to a node whose parents ended
+// with the given statuses, or "" if the node may run. The policy only affects
+// AllSuccess, where BestEffort lets nodes run after upstream failures.
+func (r TriggerRule) blockedStatus(parentStatuses []string, policy FailurePolicy) string {
+    var completed, failed, skipped int
+    for _, status := range parentStatuses {
+        switch status {
+        case StatusCompleted:
+            completed++
+        case StatusFailed, StatusTimedOut, StatusUpstreamFailed:
+            failed++
+        default: // StatusSkipped, StatusCancelled
+            skipped++
+        }
+    }
+
+    switch r {
+    case AllDone:
+        return ""
+    case OneFailed:
+        if failed > 0 {
+            return ""
+        }
+        return StatusSkipped
+    case OneSuccess:
+        if completed > 0 || len(parentStatuses) == 0 {
+            return ""
+        }
+        if failed > 0 {
+            return StatusUpstreamFailed
+        }
+        return StatusSkipped
+    case NoneFailed:
+        if failed > 0 {
+            return StatusUpstreamFailed
+        }
+        return ""
+    }
+
+    // AllSuccess
+    if failed > 0 && policy != BestEffort {
+        return StatusUpstreamFailed
+    }
+    if skipped > 0 {
+        return StatusSkipped
+    }
+    return ""
+}
@@ -0,0 +1,500 @@
+package dagengine
+
+import (
+    "bytes"
+    "context"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "os"
+    "regexp"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/tetratelabs/wazero"
+    "github.com/tetratelabs/wazero/api"
+    "github.com/tetratelabs/wazero/experimental"
+    "github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+    "github.com/tetratelabs/wazero/sys"
+)
+
+// DefaultWasmMaxMemory bounds the linear memory of WasmExecutors without a
+// MaxMemory.
+const DefaultWasmMaxMemory = 64 << 20
+
+// DefaultWasmFuelTimeout bounds the runs of WasmExecutors with a MaxFuel but
+// no Timeout: fuel only counts calls, so it cannot stop loops without calls.
+const DefaultWasmFuelTimeout = 30 * time.Second
+
+const (
+    // wasmPageSize is the size of a page of linear memory.
+    wasmPageSize = 64 << 10
+    // maxWasmMemory is the most linear memory a module can address.
+    maxWasmMemory = 1 << 32
+    // maxWasmModule is the size above which artifacts are rejected.
+    maxWasmModule = 64 << 20
+)
+
+// wasmMagic starts every WebAssembly binary module.
+var wasmMagic = []byte("\x00asm")
+
+// sha256Pattern matches hex SHA-256 digests.
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// WasmExecutor implements the Executor interface for WebAssembly modules,
+// such as Rust or TinyGo programs compiled for WASI (wasm32-wasip1). Modules
+// run in a pure-Go runtime, without access to the file system, the network
+// or processes: they only see their stdin, stdout, stderr, environment
+// variables, clocks and a random source.
+//
+// The module is loaded on the first run and compiled once per process for
+// each memory limit, whichever executors run it; it is then instantiated
+// afresh for every run, so runs share no state.
+type WasmExecutor struct {
+    Module    []byte            // Binary module; if nil, it is read from Artifact
+    Artifact  string            // Path or http(s) URL of the binary module
+    SHA256    string            // Expected hex digest of the module, checked when set
+    Env       map[string]string // Environment variables of the module
+    MaxMemory int64             // Bound on linear memory in bytes (default: DefaultWasmMaxMemory)
+    MaxFuel   int64             // Function calls allowed per run; 0 means no limit
+    Timeout   time.Duration     // Bound on a run (default: DefaultWasmFuelTimeout with a MaxFuel, otherwise none)
+    Client    *http.Client      // Fetches URL artifacts (default: http.DefaultClient)
+
+    mu       sync.Mutex
+    runtime  wazero.Runtime        // Shared with the executors of the same limits
+    compiled wazero.CompiledModule // Loaded on the first run
+}
+
+// wasmRuntimeKey identifies the runtimes that executors share: one per
+// memory limit, with and without fuel metering.
+type wasmRuntimeKey struct {
+    pages   uint32
+    metered bool
+}
+
+// wasmModuleKey identifies a module compiled in a runtime, by digest.
+type wasmModuleKey struct {
+    runtime wasmRuntimeKey
+    digest  string
+}
+
+// wasmCache holds the runtimes and compiled modules of the process. They
+// live as long as the process: executors are not closed, and a process runs
+// a bounded set of modules.
+var wasmCache = struct {
+    mu       sync.Mutex
+    runtimes map[wasmRuntimeKey]wazero.Runtime
+    modules  map[wasmModuleKey]wazero.CompiledModule
+}{
+    runtimes: make(map[wasmRuntimeKey]wazero.Runtime),
+    modules:  make(map[wasmModuleKey]wazero.CompiledModule),
+}
+
+// WasmExitError is returned when a module exits with a non-zero code.
+type WasmExitError struct {
+    ExitCode uint32
+    Stderr   string // End of the module's stderr
+}
+
+func (e *WasmExitError) Error() string {
+    if e.Stderr == "" {
+        return fmt.Sprintf("wasm module exited with code %d", e.ExitCode)
+    }
+    return fmt.Sprintf("wasm module exited with code %d: %s", e.ExitCode, e.Stderr)
+}
+
+// NewWasmExecutor creates a WasmExecutor from a node's code, the base64 of
+// a binary module, or from the artifact its executor configuration
+// references:
+//
+//	config:
+//	  artifact: https://artifacts.internal/enrich-1.4.0.wasm  # or a path
+//	  sha256: 5f2b...e1
+//	  env:
+//	    LOG_LEVEL: debug
+//	  max_memory_mb: 32
+//	  max_fuel: 10000000
+//	  timeout_seconds: 5
+func NewWasmExecutor(code string, config map[string]interface{}) (*WasmExecutor, error) {
+    executor := &WasmExecutor{}
+
+    if code != "" {
+        // Long base64 strings are often wrapped in YAML
+        module, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(code), ""))
+        if err != nil {
+            return nil, fmt.Errorf("wasm code must be a base64 module: %w", err)
+        }
+        executor.Module = module
+    }
+
+    var err error
+    if executor.Artifact, err = configString("wasm", config, "artifact"); err != nil {
+        return nil, err
+    }
+    if executor.SHA256, err = configString("wasm", config, "sha256"); err != nil {
+        return nil, err
+    }
+    executor.SHA256 = strings.ToLower(executor.SHA256)
+    if executor.Env, err = configStringMap("wasm", config, "env"); err != nil {
+        return nil, err
+    }
+    for key, limit := range map[string]*int64{"max_memory_mb": &executor.MaxMemory, "max_fuel": &executor.MaxFuel} {
+        value, ok := config[key]
+        if !ok || value == nil {
+            continue
+        }
+        number, ok := toFloat(value)
+        if !ok || number < 0 || number != float64(int64(number)) {
+            return nil, fmt.Errorf("wasm %s must be a non-negative integer, got %v", key, value)
+        }
+        *limit = int64(number)
+    }
+    // Checked before scaling, which could overflow
+    if executor.MaxMemory > maxWasmMemory>>20 {
+        return nil, fmt.Errorf("wasm memory limit must be between 0 and 4096 MB")
+    }
+    executor.MaxMemory <<= 20
+    if executor.Timeout, err = configSeconds("wasm", config, "timeout_seconds"); err != nil {
+        return nil, err
+    }
+
+    if err := executor.Validate(); err != nil {
+        return nil, err
+    }
+    return executor, nil
+}
+
+// wasmExecutorType registers WasmExecutor as the "wasm" type. Its modules
+// see the workflow's variables, overridden by the node's, but not the
+// workflow's secrets.
+func wasmExecutorType() ExecutorType {
+    return ExecutorType{
+        Name: "wasm",
+        New: func(spec ExecutorSpec, host *ExecutorHost) (Executor, error) {
+            executor, err := NewWasmExecutor(spec.Code, spec.Config)
+            if err != nil {
+                return nil, err
+            }
+            env := make(map[string]string, len(host.Env)+len(executor.Env))
+            for name, value := range host.Env {
+                env[name] = value
+            }
+            for name, value := range executor.Env {
+                env[name] = value
+            }
+            executor.Env = env
+            return executor, nil
+        },
+        Schema: ExecutorSchema{
+            Code: CodeOptional,
+            Config: map[string]ConfigField{
+                "artifact":        {Kind: ConfigString, Description: "Path or http(s) URL of the module, instead of base64 code"},
+                "sha256":          {Kind: ConfigString, Description: "Expected hex digest of the module"},
+                "env":             {Kind: ConfigMap, Description: "Environment variables of the module"},
+                "max_memory_mb":   {Kind: ConfigNumber, Description: "Bound on linear memory (default: 64)"},
+                "max_fuel":        {Kind: ConfigNumber, Description: "Function calls allowed per run (default: no limit)"},
+                "timeout_seconds": {Kind: ConfigNumber, Description: "Bound on a run (default: 30 with max_fuel, otherwise none)"},
+            },
+        },
+        Validate: func(spec ExecutorSpec) error {
+            _, err := NewWasmExecutor(spec.Code, spec.Config)
+            return err
+        },
+    }
+}
+
+// Validate checks the executor's configuration.
+func (w *WasmExecutor) Validate() error {
+    switch {
+    case w.Module == nil && w.Artifact == "":
+        return fmt.Errorf("wasm module is required, as base64 code or an artifact")
+    case w.Module != nil && w.Artifact != "":
+        return fmt.Errorf("wasm module must be given as base64 code or an artifact, not both")
+    case w.Module != nil && !bytes.HasPrefix(w.Module, wasmMagic):
+        return fmt.Errorf("wasm code is not a binary WebAssembly module")
+    }
+    if w.SHA256 != "" && !sha256Pattern.MatchString(w.SHA256) {
+        return fmt.Errorf("wasm sha256 must be a hex SHA-256 digest, got '%s'", w.SHA256)
+    }
+    if w.MaxMemory < 0 || w.MaxMemory > maxWasmMemory {
+        return fmt.Errorf("wasm memory limit must be between 0 and 4096 MB")
+    }
+    if w.MaxFuel < 0 {
+        return fmt.Errorf("wasm fuel must not be negative")
+    }
+    if w.Timeout < 0 {
+        return fmt.Errorf("wasm timeout must not be negative")
+    }
+    return nil
+}
+
+// Describe identifies the module for result caching.
+func (w *WasmExecutor) Describe() ExecutorDescription {
+    digest := w.SHA256
+    if w.Module != nil {
+        sum := sha256.Sum256(w.Module)
+        digest = hex.EncodeToString(sum[:])
+    }
+    return ExecutorDescription{
+        Type: "wasm",
+        Config: map[string]interface{}{
+            "artifact":   w.Artifact,
+            "sha256":     digest,
+            "env":        w.Env,
+            "max_memory": w.MaxMemory,
+            "max_fuel":   w.MaxFuel,
+        },
+    }
+}
+
+// Execute runs the module's _start function, as for a WASI command.
+//
+// The node's inputs are written to the module's stdin as a JSON object. If
+// the module prints anything to stdout, it must be a JSON object, which
+// becomes the node's result; otherwise the result is empty. A module that
+// prints more than 10 MiB to stdout fails. The module fails with a
+// *WasmExitError if it exits with a non-zero code. The end of what it prints
+// to stderr is logged (see Log) when it succeeds.
+//
+// A module that grows its memory beyond MaxMemory gets an error from
+// memory.grow, and one that makes more than MaxFuel function calls is
+// stopped. Calls are the unit of fuel, so loops without calls are bounded by
+// Timeout instead, which defaults to DefaultWasmFuelTimeout when MaxFuel is
+// set. A module that exceeds Timeout fails without retries. When ctx is
+// done, the module is stopped at its next function call or loop iteration.
+func (w *WasmExecutor) Execute(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+    runtime, compiled, err := w.load(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    if inputs == nil {
+        inputs = map[string]interface{}{}
+    }
+    stdin, err := json.Marshal(inputs)
+    if err != nil {
+        return nil, fmt.Errorf("wasm inputs: %w", err)
+    }
+    stdout := &cappedBuffer{max: maxHTTPBody}
+    stderr := &tailBuffer{max: maxShellStderr}
+    config := wazero.NewModuleConfig().
+        WithName(""). // Anonymous, so that runs can overlap
+        WithArgs("wasm").
+        WithStdin(bytes.NewReader(stdin)).
+        WithStdout(stdout).
+        WithStderr(stderr).
+        WithRandSource(rand.Reader).
+        WithSysWalltime().
+        WithSysNanotime()
+    names := make([]string, 0, len(w.Env))
+    for name := range w.Env {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        config = config.WithEnv(name, w.Env[name])
+    }
+
+    runCtx, cancel := context.WithCancel(ctx)
+    defer cancel()
+    timeout := w.Timeout
+    if timeout == 0 && w.MaxFuel > 0 {
+        timeout = DefaultWasmFuelTimeout
+    }
+    if timeout > 0 {
+        var cancelTimeout context.CancelFunc
+        runCtx, cancelTimeout = context.WithTimeout(runCtx, timeout)
+        defer cancelTimeout()
+    }
+    fuel := &wasmFuel{remaining: w.MaxFuel, cancel: cancel}
+    if w.MaxFuel > 0 {
+        runCtx = context.WithValue(runCtx, wasmFuelKey{}, fuel)
+    }
+
+    module, err := runtime.InstantiateModule(runCtx, compiled, config)
+    if module != nil {
+        module.Close(context.Background())
+    }
+    if err != nil {
+        if fuel.exhausted {
+            return nil, Permanent(fmt.Errorf("wasm module ran out of fuel after %d calls", w.MaxFuel))
+        }
+        if stdout.exceeded {
+            return nil, Permanent(fmt.Errorf("wasm stdout exceeds %d bytes", maxHTTPBody))
+        }
+        if ctx.Err() != nil {
+            return nil, fmt.Errorf("wasm module interrupted: %w", ctx.Err())
+        }
+        if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+            return nil, Permanent(fmt.Errorf("wasm module exceeded its time limit of %v", timeout))
+        }
+        var exitErr *sys.ExitError
+        if errors.As(err, &exitErr) {
+            return nil, &WasmExitError{ExitCode: exitErr.ExitCode(), Stderr: lastBytes(stderr.String(), maxShellStderr)}
+        }
+        if message := lastBytes(stderr.String(), maxShellStderr); message != "" {
+            return nil, fmt.Errorf("wasm module failed: %w: %s", err, message)
+        }
+        return nil, fmt.Errorf("wasm module failed: %w", err)
+    }
+
+    if stdout.exceeded {
+        return nil, Permanent(fmt.Errorf("wasm stdout exceeds %d bytes", maxHTTPBody))
+    }
+    if message := lastBytes(stderr.String(), maxShellStderr); message != "" {
+        Log(ctx, LogInfo, message)
+    }
+    return outputResult("wasm", stdout.Bytes())
+}
+
+// load returns the runtime and the compiled module, reading the module and
+// looking it up in the process's cache on the first call.
+func (w *WasmExecutor) load(ctx context.Context) (wazero.Runtime, wazero.CompiledModule, error) {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    if w.compiled != nil {
+        return w.runtime, w.compiled, nil
+    }
+
+    module := w.Module
+    if module == nil {
+        var err error
+        if module, err = w.readArtifact(ctx); err != nil {
+            return nil, nil, err
+        }
+    }
+    sum := sha256.Sum256(module)
+    digest := hex.EncodeToString(sum[:])
+    if w.SHA256 != "" && digest != w.SHA256 {
+        return nil, nil, Permanent(fmt.Errorf("wasm module digest is %s, expected %s", digest, w.SHA256))
+    }
+
+    maxMemory := w.MaxMemory
+    if maxMemory == 0 {
+        maxMemory = DefaultWasmMaxMemory
+    }
+    pages := maxMemory / wasmPageSize
+    if pages == 0 {
+        pages = 1
+    }
+    runtime, compiled, err := compileWasm(wasmModuleKey{
+        runtime: wasmRuntimeKey{pages: uint32(pages), metered: w.MaxFuel > 0},
+        digest:  digest,
+    }, module)
+    if err != nil {
+        return nil, nil, err
+    }
+    w.runtime, w.compiled = runtime, compiled
+    return runtime, compiled, nil
+}
+
+// compileWasm returns the runtime of key and module compiled in it, creating
+// and compiling them if no executor did yet.
+func compileWasm(key wasmModuleKey, module []byte) (wazero.Runtime, wazero.CompiledModule, error) {
+    wasmCache.mu.Lock()
+    defer wasmCache.mu.Unlock()
+
+    runtime, ok := wasmCache.runtimes[key.runtime]
+    if compiled, ok := wasmCache.modules[key]; ok {
+        return runtime, compiled, nil
+    }
+
+    // The runtime outlives the run that creates it
+    background := context.Background()
+    if !ok {
+        runtime = wazero.NewRuntimeWithConfig(background, wazero.NewRuntimeConfig().
+            WithCloseOnContextDone(true).
+            WithMemoryLimitPages(key.runtime.pages))
+        if _, err := wasi_snapshot_preview1.Instantiate(background, runtime); err != nil {
+            runtime.Close(background)
+            return nil, nil, fmt.Errorf("wasm runtime: %w", err)
+        }
+        wasmCache.runtimes[key.runtime] = runtime
+    }
+
+    compileCtx := background
+    if key.runtime.metered {
+        compileCtx = experimental.WithFunctionListenerFactory(compileCtx, wasmFuelListener{})
+    }
+    compiled, err := runtime.CompileModule(compileCtx, module)
+    if err != nil {
+        return nil, nil, Permanent(fmt.Errorf("wasm module: %w", err))
+    }
+    wasmCache.modules[key] = compiled
+    return runtime, compiled, nil
+}
+
+// readArtifact reads the module from the executor's artifact.
+func (w *WasmExecutor) readArtifact(ctx context.Context) ([]byte, error) {
+    if !strings.HasPrefix(w.Artifact, "http://") && !strings.HasPrefix(w.Artifact, "https://") {
+        module, err := os.ReadFile(w.Artifact)
+        if err != nil {
+            return nil, Permanent(fmt.Errorf("wasm artifact: %w", err))
+        }
+        return module, nil
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.Artifact, nil)
+    if err != nil {
+        return nil, Permanent(fmt.Errorf("wasm artifact: %w", err))
+    }
+    client := w.Client
+    if client == nil {
+        client = http.DefaultClient
+    }
+    resp, err := client.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("wasm artifact: %w", err)
+    }
+    defer resp.Body.Close()
+    data, err := io.ReadAll(io.LimitReader(resp.Body, maxWasmModule+1))
+    if err != nil {
+        return nil, fmt.Errorf("wasm artifact: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        statusErr := &HTTPStatusError{StatusCode: resp.StatusCode, Body: firstBytes(string(data), 512)}
+        if statusErr.retryable() {
+            return nil, fmt.Errorf("wasm artifact: %w", statusErr)
+        }
+        return nil, Permanent(fmt.Errorf("wasm artifact: %w", statusErr))
+    }
+    if len(data) > maxWasmModule {
+        return nil, Permanent(fmt.Errorf("wasm artifact is larger than %d MB", maxWasmModule>>20))
+    }
+    return data, nil
+}
+
+// wasmFuelKey is the context key of the fuel of a run.
+type wasmFuelKey struct{}
+
+// wasmFuel counts the function calls a run may still make. A module runs on
+// a single goroutine, so it needs no locking.
+type wasmFuel struct {
+    remaining int64
+    exhausted bool
+    cancel    context.CancelFunc // Stops the run
+}
+
+// wasmFuelListener consumes a unit of the run's fuel on every function call.
+type wasmFuelListener struct{}
+
+func (wasmFuelListener) NewFunctionListener(api.FunctionDefinition) experimental.FunctionListener {
+    return experimental.FunctionListenerFunc(func(ctx context.Context, _ api.Module, _ api.FunctionDefinition, _ []uint64, _ experimental.StackIterator) {
+        fuel, ok := ctx.Value(wasmFuelKey{}).(*wasmFuel)
+        if !ok || fuel.exhausted {
+            return
+        }
+        if fuel.remaining--; fuel.remaining < 0 {
+            fuel.exhausted = true
+            fuel.cancel()
+        }
+    })
+}
@@ -0,0 +1,327 @@
+package dagengine
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+)
+
+// Functions imported by the modules of wasmModule, by index.
+const (
+    wasmFdRead   = 0
+    wasmFdWrite  = 1
+    wasmProcExit = 2
+    wasmNoop     = 3 // Defined by the module; does nothing
+)
+
+// wasmModule assembles a WASI command whose _start function runs start (a
+// sequence of instructions). Its memory has pages pages and data at offset 0.
+func wasmModule(pages int, data []byte, start ...[]byte) []byte {
+    section := func(id byte, items ...[]byte) []byte {
+        var body []byte
+        body = append(body, uleb(len(items))...)
+        for _, item := range items {
+            body = append(body, item...)
+        }
+        return append(append([]byte{id}, uleb(len(body))...), body...)
+    }
+    name := func(s string) []byte { return append(uleb(len(s)), s...) }
+    wasi := func(function string, typ byte) []byte {
+        return append(append(name("wasi_snapshot_preview1"), name(function)...), 0x00, typ)
+    }
+    code := func(instructions ...[]byte) []byte {
+        body := []byte{0x00} // No locals
+        for _, instruction := range instructions {
+            body = append(body, instruction...)
+        }
+        body = append(body, 0x0b)
+        return append(uleb(len(body)), body...)
+    }
+
+    module := []byte("\x00asm\x01\x00\x00\x00")
+    module = append(module, section(1,
+        []byte{0x60, 4, 0x7f, 0x7f, 0x7f, 0x7f, 1, 0x7f}, // (i32, i32, i32, i32) -> i32
+        []byte{0x60, 1, 0x7f, 0},                        // (i32) -> ()
+        []byte{0x60, 0, 0},                              // () -> ()
+    )...)
+    module = append(module, section(2, wasi("fd_read", 0), wasi("fd_write", 0), wasi("proc_exit", 1))...)
+    module = append(module, section(3, []byte{2}, []byte{2})...)
+    module = append(module, section(5, append([]byte{0x00}, uleb(pages)...))...)
+    module = append(module, section(7,
+        append(name("_start"), 0x00, 4),
+        append(name("memory"), 0x02, 0),
+    )...)
+    module = append(module, section(10, code(), code(start...))...)
+    segment := append([]byte{0x00, 0x41, 0x00, 0x0b}, uleb(len(data))...)
+    return append(module, section(11, append(segment, data...))...)
+}
+
+// uleb encodes n as an unsigned LEB128.
+func uleb(n int) []byte {
+    var out []byte
+    for {
+        b := byte(n & 0x7f)
+        n >>= 7
+        if n == 0 {
+            return append(out, b)
+        }
+        out = append(out, b|0x80)
+    }
+}
+
+// Instructions used by the test modules.
+func i32(n int32) []byte {
+    out := []byte{0x41}
+    for {
+        b := byte(n & 0x7f)
+        n >>= 7
+        if (n == 0 && b&0x40 == 0) || (n == -1 && b&0x40 != 0) {
+            return append(out, b)
+        }
+        out = append(out, b|0x80)
+    }
+}
+
+func call(function int) []byte { return append([]byte{0x10}, uleb(function)...) }
+
+var (
+    drop     = []byte{0x1a}
+    i32Load  = []byte{0x28, 2, 0}
+    i32Store = []byte{0x36, 2, 0}
+    i32Eq    = []byte{0x46}
+    grow     = []byte{0x40, 0}
+)
+
+// ioVec is the data of modules that write text: an iovec at 0 pointing to
+// the text, stored at 8.
+func ioVec(text string) []byte {
+    data := []byte{8, 0, 0, 0, byte(len(text)), 0, 0, 0}
+    return append(data, text...)
+}
+
+var (
+    // echoModule copies its stdin to its stdout, using a buffer at 1024.
+    echoModule = wasmModule(1, []byte{0x00, 0x04, 0, 0, 0x00, 0xf0, 0, 0},
+        i32(0), i32(0), i32(1), i32(16), call(wasmFdRead), drop,
+        i32(4), i32(16), i32Load, i32Store,
+        i32(1), i32(0), i32(1), i32(16), call(wasmFdWrite), drop)
+    // loopModule calls a function forever.
+    loopModule = wasmModule(1, nil, []byte{0x03, 0x40}, call(wasmNoop), []byte{0x0c, 0}, []byte{0x0b})
+    // spinModule loops forever without calls.
+    spinModule = wasmModule(1, nil, []byte{0x03, 0x40, 0x0c, 0, 0x0b})
+    // floodModule writes its memory page to stdout until fd_write fails.
+    floodModule = wasmModule(1, []byte{0, 0, 0, 0, 0, 0, 1, 0},
+        []byte{0x02, 0x40, 0x03, 0x40},
+        i32(1), i32(0), i32(1), i32(16), call(wasmFdWrite),
+        []byte{0x0d, 1, 0x0c, 0, 0x0b, 0x0b})
+)
+
+// printModule writes text to fd and exits with code.
+func printModule(fd int32, text string, code int32) []byte {
+    return wasmModule(1, ioVec(text),
+        i32(fd), i32(0), i32(1), i32(64), call(wasmFdWrite), drop,
+        i32(code), call(wasmProcExit))
+}
+
+// growModule grows its memory by pages and exits with 4 if it cannot.
+func growModule(pages int32) []byte {
+    return wasmModule(1, nil,
+        i32(pages), grow, i32(-1), i32Eq, []byte{0x04, 0x40}, i32(4), call(wasmProcExit), []byte{0x0b})
+}
+
+func TestWasmExecutor(t *testing.T) {
+    inputs := map[string]interface{}{"extract": map[string]interface{}{"count": float64(3)}}
+
+    tests := []struct {
+        name     string
+        executor *WasmExecutor
+        want     map[string]interface{}
+    }{
+        {"stdin to stdout", &WasmExecutor{Module: echoModule}, inputs},
+        {"no output", &WasmExecutor{Module: printModule(2, "done", 0)}, map[string]interface{}{}},
+        {"fuel suffices", &WasmExecutor{Module: echoModule, MaxFuel: 10}, inputs},
+        {"memory within limit", &WasmExecutor{Module: growModule(16), MaxMemory: 2 << 20}, map[string]interface{}{}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            // Twice, the second time with the compiled module
+            for i := 0; i < 2; i++ {
+                got, err := tt.executor.Execute(context.Background(), inputs)
+                if err != nil {
+                    t.Fatalf("Execute failed: %v", err)
+                }
+                if !reflect.DeepEqual(got, tt.want) {
+                    t.Errorf("Expected %v, got %v", tt.want, got)
+                }
+            }
+        })
+    }
+
+    // Executors of the same module and limits share its compilation
+    a, b := &WasmExecutor{Module: echoModule}, &WasmExecutor{Module: append([]byte(nil), echoModule...)}
+    for _, executor := range []*WasmExecutor{a, b} {
+        if _, err := executor.Execute(context.Background(), inputs); err != nil {
+            t.Fatalf("Execute failed: %v", err)
+        }
+    }
+    if a.compiled != b.compiled || a.runtime != b.runtime {
+        t.Error("Expected the executors to share the compiled module")
+    }
+    metered := &WasmExecutor{Module: echoModule, MaxFuel: 10}
+    if _, err := metered.Execute(context.Background(), inputs); err != nil {
+        t.Fatalf("Execute failed: %v", err)
+    }
+    if metered.compiled == a.compiled {
+        t.Error("Expected a metered executor to compile the module with fuel listeners")
+    }
+}
+
+func TestWasmExecutorErrors(t *testing.T) {
+    _, err := (&WasmExecutor{Module: printModule(2, "disk full", 3)}).Execute(context.Background(), nil)
+    var exitErr *WasmExitError
+    if !errors.As(err, &exitErr) {
+        t.Fatalf("Expected a WasmExitError, got %v", err)
+    }
+    if exitErr.ExitCode != 3 || exitErr.Stderr != "disk full" {
+        t.Errorf("Expected code 3 and stderr 'disk full', got %d and %q", exitErr.ExitCode, exitErr.Stderr)
+    }
+
+    _, err = (&WasmExecutor{Module: growModule(64), MaxMemory: 2 << 20}).Execute(context.Background(), nil)
+    if !errors.As(err, &exitErr) || exitErr.ExitCode != 4 {
+        t.Errorf("Expected memory.grow to fail beyond the limit, got %v", err)
+    }
+
+    tests := []struct {
+        name      string
+        executor  *WasmExecutor
+        want      string
+        permanent bool
+    }{
+        {"non-object output", &WasmExecutor{Module: printModule(1, "[1, 2]", 0)}, "must be a JSON object", false},
+        {"out of fuel", &WasmExecutor{Module: loopModule, MaxFuel: 1000}, "ran out of fuel after 1000 calls", true},
+        {"output too large", &WasmExecutor{Module: floodModule}, "stdout exceeds", true},
+        {"over time", &WasmExecutor{Module: spinModule, Timeout: 50 * time.Millisecond}, "time limit of 50ms", true},
+        {"memory over limit", &WasmExecutor{Module: wasmModule(64, nil), MaxMemory: 1 << 20}, "wasm module", true},
+        {"invalid module", &WasmExecutor{Module: []byte("\x00asm\x02")}, "wasm module", true},
+        {"digest mismatch", &WasmExecutor{Module: echoModule, SHA256: strings.Repeat("0", 64)}, "digest", true},
+        {"missing artifact", &WasmExecutor{Artifact: "/nonexistent.wasm"}, "wasm artifact", true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := tt.executor.Execute(context.Background(), nil)
+            if err == nil || !strings.Contains(err.Error(), tt.want) {
+                t.Fatalf("Expected an error containing %q, got %v", tt.want, err)
+            }
+            if IsPermanent(err) != tt.permanent {
+                t.Errorf("Expected permanent to be %v, got %v", tt.permanent, IsPermanent(err))
+            }
+        })
+    }
+}
+
+func TestWasmExecutorCancellation(t *testing.T) {
+    for name, module := range map[string][]byte{"calls": loopModule, "loop": spinModule} {
+        t.Run(name, func(t *testing.T) {
+            ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+            defer cancel()
+
+            start := time.Now()
+            _, err := (&WasmExecutor{Module: module}).Execute(ctx, nil)
+            if !errors.Is(err, context.DeadlineExceeded) {
+                t.Errorf("Expected the error to wrap context.DeadlineExceeded, got %v", err)
+            }
+            if elapsed := time.Since(start); elapsed > 2*time.Second {
+                t.Errorf("Expected the module to be stopped at the deadline, ran for %v", elapsed)
+            }
+        })
+    }
+}
+
+func TestWasmExecutorArtifacts(t *testing.T) {
+    sum := sha256.Sum256(echoModule)
+    digest := hex.EncodeToString(sum[:])
+    path := filepath.Join(t.TempDir(), "echo.wasm")
+    if err := os.WriteFile(path, echoModule, 0644); err != nil {
+        t.Fatalf("WriteFile failed: %v", err)
+    }
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/echo.wasm" {
+            http.NotFound(w, r)
+            return
+        }
+        w.Write(echoModule)
+    }))
+    defer server.Close()
+
+    inputs := map[string]interface{}{"id": "42"}
+    for _, artifact := range []string{path, server.URL + "/echo.wasm"} {
+        executor, err := NewWasmExecutor("", map[string]interface{}{"artifact": artifact, "sha256": strings.ToUpper(digest)})
+        if err != nil {
+            t.Fatalf("NewWasmExecutor failed: %v", err)
+        }
+        got, err := executor.Execute(context.Background(), inputs)
+        if err != nil {
+            t.Fatalf("Execute of %s failed: %v", artifact, err)
+        }
+        if !reflect.DeepEqual(got, inputs) {
+            t.Errorf("Expected %v, got %v", inputs, got)
+        }
+    }
+
+    _, err := (&WasmExecutor{Artifact: server.URL + "/missing.wasm"}).Execute(context.Background(), nil)
+    var statusErr *HTTPStatusError
+    if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound || !IsPermanent(err) {
+        t.Errorf("Expected a permanent 404 HTTPStatusError, got %v", err)
+    }
+}
+
+func TestNewWasmExecutor(t *testing.T) {
+    code := base64.StdEncoding.EncodeToString(echoModule)
+    wrapped := code[:10] + "\n  " + code[10:]
+    executor, err := NewWasmExecutor(wrapped, map[string]interface{}{
+        "env":             map[string]interface{}{"LOG_LEVEL": "debug"},
+        "max_memory_mb":   32,
+        "max_fuel":        1e6,
+        "timeout_seconds": 2,
+    })
+    if err != nil {
+        t.Fatalf("NewWasmExecutor failed: %v", err)
+    }
+    if !reflect.DeepEqual(executor.Module, echoModule) || executor.MaxMemory != 32<<20 || executor.MaxFuel != 1000000 || executor.Timeout != 2*time.Second || executor.Env["LOG_LEVEL"] != "debug" {
+        t.Errorf("Unexpected executor: %+v", executor)
+    }
+
+    tests := []struct {
+        name   string
+        code   string
+        config map[string]interface{}
+    }{
+        {"no module", "", nil},
+        {"code and artifact", code, map[string]interface{}{"artifact": "/m.wasm"}},
+        {"not base64", "not base64!", nil},
+        {"not wasm", base64.StdEncoding.EncodeToString([]byte("#!/bin/sh")), nil},
+        {"bad digest", code, map[string]interface{}{"sha256": "abc"}},
+        {"bad memory", code, map[string]interface{}{"max_memory_mb": 8192}},
+        {"overflowing memory", code, map[string]interface{}{"max_memory_mb": 1 << 50}},
+        {"bad fuel", code, map[string]interface{}{"max_fuel": 1.5}},
+        {"bad timeout", code, map[string]interface{}{"timeout_seconds": -1}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if _, err := NewWasmExecutor(tt.code, tt.config); err == nil {
+                t.Error("Expected an error")
+            }
+        })
+    }
+}
@@ -17,6 +17,7 @@ type CronTrigger struct {
 	cronID        cron.EntryID
 	mu            sync.Mutex
 	inputsBuilder func() map[string]interface{} // Optional function to build inputs dynamically
+	timeout       time.Duration                 // Optional override of the workflow's deadline
 }
 
 // CronTriggerConfig configures a cron trigger.
@@ -25,6 +26,7 @@ type CronTriggerConfig struct {
 	Schedule      string                    // Cron expression (e.g., "0 */5 * * * *" for every 5 minutes)
 	WorkflowID    string
 	InputsBuilder func() map[string]interface{} // Optional: dynamic inputs based on trigger time
+	Timeout       time.Duration                 // Optional: overrides the deadline declared by the workflow
 }
 
 // NewCronTrigger creates a new cron trigger.
@@ -42,6 +44,7 @@ func NewCronTrigger(config CronTriggerConfig) (*CronTrigger, error) {
 		workflowID:    config.WorkflowID,
 		cron:          cron.New(cron.WithSeconds()),
 		inputsBuilder: config.InputsBuilder,
+		timeout:       config.Timeout,
 	}, nil
 }
 
@@ -67,8 +70,9 @@ func (ct *CronTrigger) Start(ctx context.Context, executor WorkflowExecutor) err
 		inputs["_trigger_id"] = ct.id
 		inputs["_trigger_time"] = time.Now().Unix()
 		
-		// Execute workflow
-		workflowCtx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		// Execute workflow within its deadline
+		timeout := resolveWorkflowTimeout(executor, ct.workflowID, ct.timeout)
+		workflowCtx, cancel := context.WithTimeout(context.Background(), timeout)
 		defer cancel()
 		
 		if _, err := executor.ExecuteWorkflow(workflowCtx, ct.workflowID, inputs); err != nil {
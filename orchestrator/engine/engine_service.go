@@ -14,6 +14,8 @@ type EngineService struct {
 	ID            string
 	capacity      int
 	activeWorkflows map[string]*WorkflowExecution
+	stateStore    dagengine.StateStore // Checkpoints of the executions, if set
+	resultCache   dagengine.ResultCache // Results of cached nodes, if set
 	mu            sync.RWMutex
 }
 
@@ -23,6 +25,7 @@ type WorkflowExecution struct {
 	WorkflowID    string
 	Version       string
 	Engine        *dagengine.DAGEngine
+	Result        *dagengine.RunResult // Final per-node statuses, set once the run finishes
 	StartTime     time.Time
 	Status        string
 	Context       context.Context
@@ -38,8 +41,53 @@ func NewEngineService(id string, capacity int) *EngineService {
 	}
 }
 
-// ExecuteWorkflow executes a workflow on this engine
-func (es *EngineService) ExecuteWorkflow(ctx context.Context, workflowID, version, executionID string, engine *dagengine.DAGEngine) error {
+// SetStateStore makes executions save checkpoints to store, so that they can
+// be resumed with ResumeWorkflow if the engine dies mid-run.
+func (es *EngineService) SetStateStore(store dagengine.StateStore) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.stateStore = store
+}
+
+// SetResultCache makes nodes with a cache configuration reuse results stored
+// in cache by earlier executions.
+func (es *EngineService) SetResultCache(cache dagengine.ResultCache) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.resultCache = cache
+}
+
+// ExecuteWorkflow executes a workflow on this engine with the given workflow-level inputs
+func (es *EngineService) ExecuteWorkflow(ctx context.Context, workflowID, version, executionID string, engine *dagengine.DAGEngine, inputs map[string]interface{}) error {
+	return es.startWorkflow(ctx, workflowID, version, executionID, engine, func(execCtx context.Context, plan *dagengine.Plan) (*dagengine.Run, error) {
+		return engine.StartExecution(execCtx, executionID, plan, inputs), nil
+	})
+}
+
+// ResumeWorkflow continues an execution from its last checkpoint: nodes that
+// had completed are not run again.
+func (es *EngineService) ResumeWorkflow(ctx context.Context, workflowID, version, executionID string, engine *dagengine.DAGEngine) error {
+	return es.startWorkflow(ctx, workflowID, version, executionID, engine, func(execCtx context.Context, plan *dagengine.Plan) (*dagengine.Run, error) {
+		return engine.ResumeExecution(execCtx, executionID, plan)
+	})
+}
+
+// RerunWorkflow executes again the node fromNode and its descendants in a
+// finished execution, reusing the checkpointed results of the other nodes.
+func (es *EngineService) RerunWorkflow(ctx context.Context, workflowID, version, executionID, fromNode string, engine *dagengine.DAGEngine) error {
+	return es.startWorkflow(ctx, workflowID, version, executionID, engine, func(execCtx context.Context, plan *dagengine.Plan) (*dagengine.Run, error) {
+		return engine.RerunExecution(execCtx, executionID, plan, fromNode)
+	})
+}
+
+// startWorkflow compiles the engine's workflow, starts it with start and
+// tracks the execution until it finishes.
+func (es *EngineService) startWorkflow(ctx context.Context, workflowID, version, executionID string, engine *dagengine.DAGEngine, start func(context.Context, *dagengine.Plan) (*dagengine.Run, error)) error {
+	plan, err := engine.Compile()
+	if err != nil {
+		return fmt.Errorf("failed to compile workflow %s: %w", workflowID, err)
+	}
+	
 	es.mu.Lock()
 	
 	// Check capacity
@@ -47,6 +95,16 @@ func (es *EngineService) ExecuteWorkflow(ctx context.Context, workflowID, versio
 		es.mu.Unlock()
 		return fmt.Errorf("engine at capacity (%d)", es.capacity)
 	}
+	if _, exists := es.activeWorkflows[executionID]; exists {
+		es.mu.Unlock()
+		return fmt.Errorf("workflow %s is already running", executionID)
+	}
+	if engine.StateStore == nil {
+		engine.StateStore = es.stateStore
+	}
+	if engine.Cache == nil {
+		engine.Cache = es.resultCache
+	}
 	
 	// Create execution context
 	execCtx, cancel := context.WithCancel(ctx)
@@ -65,7 +123,16 @@ func (es *EngineService) ExecuteWorkflow(ctx context.Context, workflowID, versio
 	es.activeWorkflows[executionID] = exec
 	es.mu.Unlock()
 	
-	// Execute in goroutine
+	run, err := start(execCtx, plan)
+	if err != nil {
+		cancel()
+		es.mu.Lock()
+		delete(es.activeWorkflows, executionID)
+		es.mu.Unlock()
+		return err
+	}
+	
+	// Wait in goroutine
 	go func() {
 		defer func() {
 			es.mu.Lock()
@@ -73,14 +140,11 @@ func (es *EngineService) ExecuteWorkflow(ctx context.Context, workflowID, versio
 			es.mu.Unlock()
 		}()
 		
-		err := engine.Run(execCtx)
+		result, _ := run.Wait()
 		
 		es.mu.Lock()
-		if err != nil {
-			exec.Status = "FAILED"
-		} else {
-			exec.Status = "COMPLETED"
-		}
+		exec.Result = result
+		exec.Status = result.Status
 		es.mu.Unlock()
 	}()
 	
@@ -3,6 +3,7 @@ package orchestrator
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 	"github.com/gbasilveira/dag-engine/dagengine"
@@ -19,18 +20,31 @@ type EngineWrapper struct {
 	currentCtx    context.Context
 	currentCancel context.CancelFunc
 	currentWorkflow string
+	currentRequest string // RequestID of the running workflow
+	monitor       *Monitor // Receives the engine's lifecycle events, if set
 	wg            sync.WaitGroup
 }
 
 // NewEngineWrapper creates a new engine wrapper with communication channels.
 func NewEngineWrapper(id string, engine *dagengine.DAGEngine) *EngineWrapper {
-	return &EngineWrapper{
+	ew := &EngineWrapper{
 		ID:       id,
 		Engine:   engine,
 		Inbound:  make(chan *EngineMessage, 100),
 		Outbound: make(chan *EngineMessage, 100),
 		Status:   StatusIdle,
 	}
+	if engine != nil {
+		engine.AddObserver(&engineObserver{wrapper: ew})
+	}
+	return ew
+}
+
+// SetMonitor forwards the engine's run and node lifecycle events to m.
+func (ew *EngineWrapper) SetMonitor(m *Monitor) {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+	ew.monitor = m
 }
 
 // Start begins the engine's message processing loop.
@@ -144,6 +158,12 @@ func (ew *EngineWrapper) handleMessage(ctx context.Context, msg *EngineMessage)
 			}
 		}
 		ew.mu.Unlock()
+	case MsgTypeCancel:
+		ew.mu.Lock()
+		if ew.currentRequest == msg.RequestID && ew.currentCancel != nil {
+			ew.currentCancel()
+		}
+		ew.mu.Unlock()
 	case MsgTypeResume:
 		ew.mu.Lock()
 		if ew.Status == StatusPaused {
@@ -157,17 +177,26 @@ func (ew *EngineWrapper) handleMessage(ctx context.Context, msg *EngineMessage)
 
 // handleWorkflowRequest executes a workflow in the engine.
 func (ew *EngineWrapper) handleWorkflowRequest(ctx context.Context, msg *EngineMessage) {
-	// Extract workflow ID from message payload
+	// Extract workflow ID and inputs from message payload
 	workflowID := ""
+	var inputs map[string]interface{}
+	var plan *dagengine.Plan
 	if payload, ok := msg.Payload.(map[string]interface{}); ok {
 		if id, exists := payload["workflow_id"].(string); exists {
 			workflowID = id
 		}
+		if in, exists := payload["inputs"].(map[string]interface{}); exists {
+			inputs = in
+		}
+		if p, exists := payload["plan"].(*dagengine.Plan); exists {
+			plan = p
+		}
 	}
 	
 	ew.mu.Lock()
 	ew.Status = StatusRunning
 	ew.currentWorkflow = workflowID
+	ew.currentRequest = msg.RequestID
 	workflowCtx, cancel := context.WithCancel(ctx)
 	ew.currentCtx = workflowCtx
 	ew.currentCancel = cancel
@@ -179,26 +208,40 @@ func (ew *EngineWrapper) handleWorkflowRequest(ctx context.Context, msg *EngineM
 			ew.mu.Lock()
 			ew.Status = StatusIdle
 			ew.currentWorkflow = ""
+			ew.currentRequest = ""
 			ew.currentCtx = nil
 			ew.currentCancel = nil
 			ew.mu.Unlock()
 		}()
 		
-		// Execute the workflow
-		err := ew.Engine.Run(workflowCtx)
+		// Execute the compiled plan, or the wrapper's own engine graph if none was sent
+		var result *dagengine.RunResult
+		var err error
+		if plan != nil {
+			result, err = ew.Engine.Execute(workflowCtx, plan, inputs)
+		} else {
+			result, err = ew.Engine.Run(workflowCtx, inputs)
+		}
 		duration := time.Since(startTime)
 		
 		// Send response
+		payload := map[string]interface{}{
+			"success":  err == nil,
+			"error":    err,
+			"duration": duration.Nanoseconds(),
+		}
+		if result != nil {
+			payload["status"] = result.Status
+			payload["node_statuses"] = result.NodeStatuses
+			payload["node_attempts"] = result.NodeAttempts
+			payload["outputs"] = result.Outputs
+		}
 		response := &EngineMessage{
 			Type:      MsgTypeWorkflowResponse,
 			EngineID:  ew.ID,
 			Timestamp: time.Now(),
 			RequestID: msg.RequestID,
-			Payload: map[string]interface{}{
-				"success":  err == nil,
-				"error":    err,
-				"duration": duration.Nanoseconds(),
-			},
+			Payload:   payload,
 		}
 		
 		select {
@@ -241,3 +284,111 @@ func (ew *EngineWrapper) handleHealthCheck(msg *EngineMessage) {
 	}
 }
 
+// recordEvent forwards an engine event to the monitor, if one is attached,
+// as an event of the running workflow and request.
+func (ew *EngineWrapper) recordEvent(event *WorkflowEvent) {
+	ew.mu.RLock()
+	monitor := ew.monitor
+	event.WorkflowID = ew.currentWorkflow
+	event.ExecutionID = ew.currentRequest
+	ew.mu.RUnlock()
+	
+	if monitor != nil {
+		monitor.recordWorkflowEvent(ew.ID, event)
+	}
+}
+
+// engineObserver turns the lifecycle callbacks of a wrapper's engine into
+// WorkflowEvents. A wrapper runs one workflow at a time, so events are
+// attributed to the wrapper's current workflow and request; the engine's run
+// ID is kept in their Data as "run_id".
+type engineObserver struct {
+	wrapper *EngineWrapper
+}
+
+func (o *engineObserver) OnRunStart(event dagengine.RunEvent) {
+	o.wrapper.recordEvent(&WorkflowEvent{
+		EventType: "workflow_started",
+		Status:    dagengine.StatusRunning,
+		Data:      map[string]string{"run_id": event.RunID},
+		Timestamp:   event.StartTime.Unix(),
+	})
+}
+
+func (o *engineObserver) OnNodeStart(event dagengine.NodeEvent) {
+	o.wrapper.recordEvent(nodeWorkflowEvent("node_started", event))
+}
+
+func (o *engineObserver) OnNodeRetry(event dagengine.NodeEvent) {
+	workflowEvent := nodeWorkflowEvent("node_retrying", event)
+	workflowEvent.Data["retry_delay"] = event.RetryDelay.String()
+	o.wrapper.recordEvent(workflowEvent)
+}
+
+func (o *engineObserver) OnNodeComplete(event dagengine.NodeEvent) {
+	eventType := "node_completed"
+	if event.Status == dagengine.StatusCached {
+		eventType = "node_cached"
+	}
+	o.wrapper.recordEvent(nodeWorkflowEvent(eventType, event))
+}
+
+func (o *engineObserver) OnNodeFail(event dagengine.NodeEvent) {
+	o.wrapper.recordEvent(nodeWorkflowEvent("node_failed", event))
+}
+
+// OnNodeLog records the messages logged by node tasks, e.g. with the log
+// module of Lua scripts.
+func (o *engineObserver) OnNodeLog(event dagengine.LogEvent) {
+	data := map[string]string{
+		"level":   event.Level,
+		"message": event.Message,
+		"run_id":  event.RunID,
+	}
+	if event.MappedFrom != "" {
+		data["mapped_from"] = event.MappedFrom
+	}
+	o.wrapper.recordEvent(&WorkflowEvent{
+		EventType: "node_log",
+		NodeID:    event.NodeID,
+		Data:      data,
+		Timestamp: event.Time.Unix(),
+	})
+}
+
+func (o *engineObserver) OnRunComplete(event dagengine.RunEvent) {
+	eventType := "workflow_completed"
+	data := map[string]string{"duration": event.Duration.String(), "run_id": event.RunID}
+	if event.Err != nil {
+		eventType = "workflow_failed"
+		data["error"] = event.Err.Error()
+	}
+	o.wrapper.recordEvent(&WorkflowEvent{
+		EventType: eventType,
+		Status:    event.Result.Status,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// nodeWorkflowEvent converts a node lifecycle event.
+func nodeWorkflowEvent(eventType string, event dagengine.NodeEvent) *WorkflowEvent {
+	data := map[string]string{
+		"attempt":  strconv.Itoa(event.Attempt),
+		"duration": event.Duration.String(),
+		"run_id":   event.RunID,
+	}
+	if event.MappedFrom != "" {
+		data["mapped_from"] = event.MappedFrom
+	}
+	if event.Err != nil {
+		data["error"] = event.Err.Error()
+	}
+	return &WorkflowEvent{
+		EventType: eventType,
+		NodeID:    event.NodeID,
+		Status:    event.Status,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	}
+}
@@ -18,6 +18,7 @@ type HTTPTrigger struct {
 	server       *http.Server
 	mu           sync.Mutex
 	mux          *http.ServeMux
+	timeout      time.Duration // Optional override of the workflow's deadline
 }
 
 // HTTPTriggerConfig configures an HTTP trigger.
@@ -26,6 +27,7 @@ type HTTPTriggerConfig struct {
 	Port       string // e.g., ":8080"
 	Path       string // e.g., "/trigger/workflow"
 	WorkflowID string
+	Timeout    time.Duration // Optional: overrides the deadline declared by the workflow
 }
 
 // NewHTTPTrigger creates a new HTTP trigger.
@@ -38,6 +40,7 @@ func NewHTTPTrigger(config HTTPTriggerConfig) *HTTPTrigger {
 		path:        config.Path,
 		workflowID:  config.WorkflowID,
 		mux:         mux,
+		timeout:     config.Timeout,
 	}
 }
 
@@ -129,8 +132,9 @@ func (ht *HTTPTrigger) handleRequest(w http.ResponseWriter, r *http.Request, exe
 		inputs["_http_remote_addr"] = r.RemoteAddr
 	}
 	
-	// Execute workflow with timeout
-	workflowCtx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	// Execute workflow within its deadline
+	timeout := resolveWorkflowTimeout(executor, ht.workflowID, ht.timeout)
+	workflowCtx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 	
 	response, err := executor.ExecuteWorkflow(workflowCtx, ht.workflowID, inputs)
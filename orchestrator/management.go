@@ -80,6 +80,35 @@ type GetWorkflowResponse struct {
 	Workflow *WorkflowDefinition
 }
 
+type RerunExecutionRequest struct {
+	ExecutionID string
+	FromNode    string
+}
+
+type RerunExecutionResponse struct {
+	Success     bool
+	Message     string
+	ExecutionID string
+}
+
+type GetExecutionRequest struct {
+	ExecutionID string
+}
+
+// ExecutionInfo describes an execution and the status of its nodes
+type ExecutionInfo struct {
+	ExecutionID  string
+	WorkflowID   string
+	Version      string
+	EngineID     string
+	NodeStatuses map[string]string // Node ID -> dagengine status, empty until the engine reports
+}
+
+type GetExecutionResponse struct {
+	Found     bool
+	Execution *ExecutionInfo
+}
+
 // NewManagementService creates a new management service
 func NewManagementService(orch *OrchestratorV2) *ManagementService {
 	return &ManagementService{
@@ -223,29 +252,88 @@ func (ms *ManagementService) GetWorkflow(ctx context.Context, req *GetWorkflowRe
 	}, nil
 }
 
+// RerunExecution re-executes a node and its descendants in a finished execution
+func (ms *ManagementService) RerunExecution(ctx context.Context, req *RerunExecutionRequest) (*RerunExecutionResponse, error) {
+	if req.ExecutionID == "" || req.FromNode == "" {
+		return nil, fmt.Errorf("execution ID and node are required")
+	}
+
+	resp, err := ms.orchestrator.RerunExecution(ctx, req.ExecutionID, req.FromNode)
+	if err != nil {
+		return &RerunExecutionResponse{
+			Success:     false,
+			Message:     fmt.Sprintf("failed to rerun execution: %v", err),
+			ExecutionID: req.ExecutionID,
+		}, nil
+	}
+
+	message := fmt.Sprintf("Rerun from %s completed", req.FromNode)
+	if !resp.Success {
+		message = fmt.Sprintf("Rerun from %s failed", req.FromNode)
+	}
+	return &RerunExecutionResponse{
+		Success:     resp.Success,
+		Message:     message,
+		ExecutionID: req.ExecutionID,
+	}, nil
+}
+
+// GetExecution retrieves an execution and the status of its nodes
+func (ms *ManagementService) GetExecution(ctx context.Context, req *GetExecutionRequest) (*GetExecutionResponse, error) {
+	execution, err := ms.orchestrator.GetExecution(req.ExecutionID)
+	if err != nil {
+		return &GetExecutionResponse{
+			Found: false,
+		}, nil
+	}
+
+	return &GetExecutionResponse{
+		Found:     true,
+		Execution: execution,
+	}, nil
+}
+
 // protoToWorkflowDefinition and workflowDefinitionToProto will be implemented
 // once proto files are generated. For now, we work directly with WorkflowDefinition.
 
-// buildDAGEngineFromDefinition builds a DAGEngine from a WorkflowDefinition
-func buildDAGEngineFromDefinition(def *WorkflowDefinition) (*dagengine.DAGEngine, error) {
+// buildDAGEngineFromDefinition builds a DAGEngine from a WorkflowDefinition.
+// Its nodes can read the secrets the workflow declares if secrets is set, and
+// run sub-workflows through subWorkflows if it is set.
+func buildDAGEngineFromDefinition(def *WorkflowDefinition, secrets *SecretsManager, subWorkflows *SubWorkflowCoordinator) (*dagengine.DAGEngine, error) {
 	engine := dagengine.NewDAGEngine()
+	if def.FailurePolicy != "" {
+		policy := dagengine.FailurePolicy(def.FailurePolicy)
+		if !policy.Valid() {
+			return nil, fmt.Errorf("unsupported failure policy: %s", def.FailurePolicy)
+		}
+		engine.Policy = policy
+	}
+	engine.Timeout = def.Timeout
+	engine.MaxConcurrency = def.MaxConcurrency
+	engine.ResourcePools = def.Pools
 
+	host := executorHost(def, secrets, subWorkflows)
 	for _, nodeDef := range def.Nodes {
-		var executor dagengine.Executor
-
-		switch nodeDef.ExecutorType {
-		case "lua":
-			executor = &dagengine.LuaExecutor{
-				Code: nodeDef.ExecutorCode,
-			}
-		case "shell":
-			// TODO: Implement shell executor
-			return nil, fmt.Errorf("shell executor not yet implemented")
-		default:
-			return nil, fmt.Errorf("unsupported executor type: %s", nodeDef.ExecutorType)
+		spec := dagengine.ExecutorSpec{
+			Type:   nodeDef.ExecutorType,
+			Code:   nodeDef.ExecutorCode,
+			Config: nodeDef.ExecutorConfig,
 		}
-
-		node := dagengine.NewNode(nodeDef.NodeID, nodeDef.Dependencies, executor)
+		node, err := engine.NodeFromSpec(nodeDef.NodeID, nodeDef.Dependencies, spec, host)
+		if err != nil {
+			return nil, err
+		}
+		if nodeDef.Retry != nil {
+			node.Retry = nodeDef.Retry
+		}
+		node.Timeout = nodeDef.Timeout
+		node.Condition = nodeDef.Condition
+		node.TriggerRule = dagengine.TriggerRule(nodeDef.TriggerRule)
+		node.Map = nodeDef.Map
+		node.Priority = nodeDef.Priority
+		node.Pool = nodeDef.Pool
+		node.PoolSlots = nodeDef.PoolSlots
+		node.Cache = nodeDef.Cache
 		engine.AddNode(node)
 	}
 
@@ -256,3 +344,42 @@ func buildDAGEngineFromDefinition(def *WorkflowDefinition) (*dagengine.DAGEngine
 	return engine, nil
 }
 
+
+// executorHost returns the services the executors of def's nodes use: the
+// workflow's environment variables, the secrets it declares if secrets is
+// set, and the sub-workflows of subWorkflows if it is set.
+func executorHost(def *WorkflowDefinition, secrets *SecretsManager, subWorkflows *SubWorkflowCoordinator) *dagengine.ExecutorHost {
+	host := &dagengine.ExecutorHost{Env: workflowEnv(def)}
+	if subWorkflows != nil {
+		host.SubWorkflows = subWorkflows.Runner(def.WorkflowID)
+	}
+	secretRefs := ExtractSecretsFromConfig(def.Metadata)
+	if secrets != nil {
+		host.Secret = secrets.Lookup(secretRefs)
+		if len(secretRefs) > 0 {
+			host.SecretEnv = func(ctx context.Context) (map[string]string, error) {
+				return secrets.InjectSecretsIntoEnv(ctx, secretRefs)
+			}
+		}
+	}
+	return host
+}
+
+// workflowEnv returns a copy of the environment variables of def's
+// configuration.
+func workflowEnv(def *WorkflowDefinition) map[string]string {
+	vars := make(map[string]string)
+	if configMeta, ok := def.Metadata["configuration"].(map[string]interface{}); ok {
+		switch env := configMeta["env"].(type) {
+		case map[string]string:
+			for name, value := range env {
+				vars[name] = value
+			}
+		case map[string]interface{}:
+			for name, value := range env {
+				vars[name] = fmt.Sprintf("%v", value)
+			}
+		}
+	}
+	return vars
+}
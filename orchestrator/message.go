@@ -14,6 +14,7 @@ const (
 	MsgTypeStop             MessageType = "stop"
 	MsgTypePause            MessageType = "pause"
 	MsgTypeResume           MessageType = "resume"
+	MsgTypeCancel           MessageType = "cancel" // Cancels the workflow started by the request with the same RequestID
 )
 
 // EngineMessage represents a message sent to or received from an engine.
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/gbasilveira/dag-engine/dagengine"
 )
 
 // MonitorEvent represents an event captured by the monitoring system.
@@ -108,6 +110,10 @@ func (m *Monitor) GetEventChannel() chan *MonitorEvent {
 
 // RecordEvent records a new monitoring event.
 func (m *Monitor) RecordEvent(event *MonitorEvent) {
+	if m.ctx.Err() != nil {
+		return // Stopped
+	}
+	
 	select {
 	case m.events <- event:
 	default:
@@ -141,23 +147,7 @@ func (m *Monitor) AttachToEngineGRPC(engineID string, eventStream <-chan *Workfl
 					return
 				}
 				
-				// Convert workflow event to monitor event
-				severity := SeverityInfo
-				if event.Status == "FAILED" || event.Status == "ERROR" {
-					severity = SeverityError
-				}
-				
-				monitorEvent := &MonitorEvent{
-					EventType:   event.EventType,
-					Timestamp:   time.Unix(event.Timestamp, 0),
-					EngineID:    engineID,
-					WorkflowID:  event.WorkflowID,
-					ExecutionID: event.ExecutionID,
-					Data:        convertMap(event.Data),
-					Severity:    severity,
-				}
-				
-				m.RecordEvent(monitorEvent)
+				m.recordWorkflowEvent(engineID, event)
 			}
 		}
 	}()
@@ -165,6 +155,57 @@ func (m *Monitor) AttachToEngineGRPC(engineID string, eventStream <-chan *Workfl
 	return nil
 }
 
+// AttachToEngine records the run and node lifecycle events of an in-process
+// engine registered with the orchestrator.
+func (m *Monitor) AttachToEngine(engineID string) error {
+	engines, ok := m.orchestrator.(interface {
+		GetEngineWrapper(engineID string) (*EngineWrapper, error)
+	})
+	if !ok {
+		return fmt.Errorf("orchestrator does not manage in-process engines")
+	}
+	
+	wrapper, err := engines.GetEngineWrapper(engineID)
+	if err != nil {
+		return err
+	}
+	wrapper.SetMonitor(m)
+	return nil
+}
+
+// recordWorkflowEvent converts a workflow event to a monitor event and records it.
+func (m *Monitor) recordWorkflowEvent(engineID string, event *WorkflowEvent) {
+	severity := SeverityInfo
+	switch {
+	case event.Status == "FAILED" || event.Status == "ERROR" || event.Status == "TIMED_OUT":
+		severity = SeverityError
+	case event.EventType == "node_retrying":
+		severity = SeverityWarning
+	case event.EventType == "node_log" && event.Data["level"] == dagengine.LogWarn:
+		severity = SeverityWarning
+	case event.EventType == "node_log" && event.Data["level"] == dagengine.LogError:
+		severity = SeverityError
+	}
+	
+	data := convertMap(event.Data)
+	if event.NodeID != "" {
+		data["node_id"] = event.NodeID
+	}
+	if event.Status != "" {
+		data["status"] = event.Status
+	}
+	
+	m.RecordEvent(&MonitorEvent{
+		EventType:   event.EventType,
+		Timestamp:   time.Unix(event.Timestamp, 0),
+		EngineID:    engineID,
+		WorkflowID:  event.WorkflowID,
+		ExecutionID: event.ExecutionID,
+		Data:        data,
+		Severity:    severity,
+	})
+}
+
 // processEvents processes events and distributes them to subscribers.
 func (m *Monitor) processEvents() {
 	defer m.wg.Done()
@@ -201,7 +242,7 @@ func convertMap(data map[string]string) map[string]interface{} {
 	return result
 }
 
-// WorkflowEvent represents a workflow execution event (from gRPC stream)
+// WorkflowEvent represents a workflow execution event (from a gRPC stream or an in-process engine)
 type WorkflowEvent struct {
 	EventType   string
 	ExecutionID string
@@ -12,6 +12,7 @@ import (
 type Orchestrator struct {
 	engines     map[string]*EngineWrapper
 	workflows   map[string]*Workflow
+	plans       map[string]*dagengine.Plan // Compiled plans, built once per workflow
 	mu          sync.RWMutex
 	ctx         context.Context
 	cancel      context.CancelFunc
@@ -26,6 +27,7 @@ func NewOrchestrator(ctx context.Context) *Orchestrator {
 	return &Orchestrator{
 		engines:   make(map[string]*EngineWrapper),
 		workflows: make(map[string]*Workflow),
+		plans:     make(map[string]*dagengine.Plan),
 		ctx:       orchestratorCtx,
 		cancel:    cancel,
 	}
@@ -109,21 +111,12 @@ func (o *Orchestrator) ExecuteWorkflow(ctx context.Context, workflowID string, i
 		return nil, fmt.Errorf("no available engine for workflow %s", workflowID)
 	}
 	
-	// Build the workflow engine
-	engine, err := workflow.Builder()
+	// Compile the workflow once; every execution reuses the same plan
+	plan, err := o.workflowPlan(workflow)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build workflow engine: %w", err)
-	}
-	
-	// Replace the engine in the wrapper
-	selectedEngine.mu.Lock()
-	selectedEngine.Engine = engine
-	selectedEngine.mu.Unlock()
-	
-	// Preprocess the DAG
-	if err := engine.PreprocessDAG(); err != nil {
-		return nil, fmt.Errorf("failed to preprocess DAG: %w", err)
+		return nil, err
 	}
+	timeout := o.WorkflowTimeout(workflowID)
 	
 	// Generate request ID
 	requestID := o.nextRequestID()
@@ -137,6 +130,7 @@ func (o *Orchestrator) ExecuteWorkflow(ctx context.Context, workflowID string, i
 		Payload: map[string]interface{}{
 			"workflow_id": workflowID,
 			"inputs":      inputs,
+			"plan":        plan,
 		},
 	}
 	
@@ -145,11 +139,9 @@ func (o *Orchestrator) ExecuteWorkflow(ctx context.Context, workflowID string, i
 		return nil, err
 	}
 	
-	// Wait for response (with timeout)
-	timeout := 5 * time.Minute // Default timeout
-	if deadline, ok := ctx.Deadline(); ok {
-		timeout = time.Until(deadline)
-	}
+	// Wait for response within the workflow deadline (or the caller's, if earlier)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 	
 	select {
 	case responseMsg := <-selectedEngine.Outbound:
@@ -176,22 +168,87 @@ func (o *Orchestrator) ExecuteWorkflow(ctx context.Context, workflowID string, i
 				err = fmt.Errorf("workflow execution failed")
 			}
 			
+			outputs := make(map[string]interface{})
+			if nodeOutputs, ok := payload["outputs"].(map[string]map[string]interface{}); ok {
+				for nodeID, result := range nodeOutputs {
+					outputs[nodeID] = result
+				}
+			}
+			
 			return &WorkflowResponse{
 				WorkflowID: workflowID,
 				Success:    success && err == nil,
+				Outputs:    outputs,
 				Duration:   duration,
 				Metadata:   payload,
 			}, err
 		}
-	case <-time.After(timeout):
-		return nil, fmt.Errorf("workflow execution timeout")
 	case <-ctx.Done():
+		// Stop the run, which the engine would otherwise finish
+		select {
+		case selectedEngine.Inbound <- &EngineMessage{
+			Type:      MsgTypeCancel,
+			EngineID:  selectedEngine.ID,
+			Timestamp: time.Now(),
+			RequestID: requestID,
+		}:
+		default:
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("workflow execution timed out: %w", ctx.Err())
+		}
 		return nil, ctx.Err()
 	}
 	
 	return nil, fmt.Errorf("unexpected response")
 }
 
+// WorkflowTimeout returns the deadline for a run of the given workflow.
+func (o *Orchestrator) WorkflowTimeout(workflowID string) time.Duration {
+	o.mu.RLock()
+	workflow, exists := o.workflows[workflowID]
+	o.mu.RUnlock()
+	
+	if !exists || workflow.Timeout <= 0 {
+		return DefaultWorkflowTimeout
+	}
+	return workflow.Timeout
+}
+
+// workflowPlan returns the compiled plan of a workflow, building and
+// compiling it on first use.
+func (o *Orchestrator) workflowPlan(workflow *Workflow) (*dagengine.Plan, error) {
+	o.mu.RLock()
+	plan, exists := o.plans[workflow.ID]
+	o.mu.RUnlock()
+	if exists {
+		return plan, nil
+	}
+	
+	engine, err := workflow.Builder()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build workflow engine: %w", err)
+	}
+	
+	// The engine enforces the workflow deadline so that running nodes are marked TIMED_OUT
+	if engine.Timeout == 0 {
+		engine.Timeout = o.WorkflowTimeout(workflow.ID)
+	}
+	
+	plan, err = engine.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile workflow %s: %w", workflow.ID, err)
+	}
+	
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if existing, exists := o.plans[workflow.ID]; exists {
+		return existing, nil
+	}
+	o.plans[workflow.ID] = plan
+	return plan, nil
+}
+
 // GetEngineState returns the state of a specific engine.
 func (o *Orchestrator) GetEngineState(engineID string) (*EngineState, error) {
 	o.mu.RLock()
@@ -231,6 +288,19 @@ func (o *Orchestrator) GetEngineOutboundChannel(engineID string) (chan *EngineMe
 	return wrapper.Outbound, nil
 }
 
+// GetEngineWrapper returns the wrapper of a registered engine (for monitoring).
+func (o *Orchestrator) GetEngineWrapper(engineID string) (*EngineWrapper, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	
+	wrapper, exists := o.engines[engineID]
+	if !exists {
+		return nil, fmt.Errorf("engine %s not found", engineID)
+	}
+	
+	return wrapper, nil
+}
+
 // Stop gracefully stops the orchestrator and all engines.
 func (o *Orchestrator) Stop() {
 	o.mu.Lock()
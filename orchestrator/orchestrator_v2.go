@@ -2,6 +2,7 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -24,8 +25,33 @@ type OrchestratorV2 struct {
 	subWorkflowCoord *SubWorkflowCoordinator
 	executionCounter int64
 	executionCounterMu sync.Mutex
+	executions      map[string]*executionRecord // executionID -> where it ran, for reruns
+	executionOrder  []string                    // executionIDs, oldest first
 }
 
+// executionRecord remembers which workflow version ran on which engine, and
+// the node statuses last reported by the engine.
+type executionRecord struct {
+	WorkflowID   string
+	Version      string
+	EngineID     string
+	NodeStatuses map[string]string
+}
+
+// Errors returned for executions and nodes the orchestrator does not know.
+var (
+	ErrExecutionNotFound = errors.New("execution not found")
+	ErrNodeNotFound      = errors.New("node not found")
+)
+
+// maxExecutionRecords bounds the executions the orchestrator remembers; the
+// oldest are forgotten first, and can no longer be rerun.
+const maxExecutionRecords = 10000
+
+// stopWorkflowTimeout bounds the request that stops an execution whose
+// caller gave up on it.
+const stopWorkflowTimeout = 5 * time.Second
+
 // NewOrchestratorV2 creates a new distributed orchestrator
 func NewOrchestratorV2(ctx context.Context, cfg *Config) (*OrchestratorV2, error) {
 	if err := cfg.Validate(); err != nil {
@@ -78,6 +104,7 @@ func NewOrchestratorV2(ctx context.Context, cfg *Config) (*OrchestratorV2, error
 		transport:        trans,
 		discovery:        disc,
 		engines:          make(map[string]*transport.EngineInfo),
+		executions:       make(map[string]*executionRecord),
 		ctx:              orchCtx,
 		cancel:           cancel,
 		subWorkflowCoord: nil, // Will be set after orchestrator is created
@@ -103,15 +130,22 @@ func (o *OrchestratorV2) RegisterWorkflow(workflowID, version string, builder Wo
 	return o.workflowManager.RegisterWorkflow(workflowID, version, builder, metadata)
 }
 
-// ExecuteWorkflow executes a workflow on a selected engine
+// ExecuteWorkflow executes the latest version of a workflow on a selected engine
 func (o *OrchestratorV2) ExecuteWorkflow(ctx context.Context, workflowID string, inputs map[string]interface{}) (*WorkflowResponse, error) {
+	return o.ExecuteWorkflowVersion(ctx, workflowID, "latest", inputs)
+}
+
+// ExecuteWorkflowVersion executes the newest version of a workflow that
+// matches a constraint, such as "1.4.2" or "1.x" (see
+// dagengine.VersionMatches), on a selected engine
+func (o *OrchestratorV2) ExecuteWorkflowVersion(ctx context.Context, workflowID, constraint string, inputs map[string]interface{}) (*WorkflowResponse, error) {
 	// Check if workflow exists
 	if !o.workflowManager.HasWorkflow(workflowID) {
 		return nil, fmt.Errorf("workflow %s not found", workflowID)
 	}
 	
-	// Get latest version
-	version, err := o.workflowManager.GetLatestVersion(workflowID)
+	// Resolve the version to run
+	version, err := o.workflowManager.ResolveVersion(workflowID, constraint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get workflow version: %w", err)
 	}
@@ -125,25 +159,99 @@ func (o *OrchestratorV2) ExecuteWorkflow(ctx context.Context, workflowID string,
 		return nil, fmt.Errorf("failed to select engine: %w", err)
 	}
 	
-	// Get engine info
+	// Get the version's definition
+	def, err := o.workflowManager.GetWorkflowDefinition(workflowID, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow definition: %w", err)
+	}
+	
+	// Convert inputs to map[string]string for transport
+	inputMap := make(map[string]string)
+	for k, v := range inputs {
+		inputMap[k] = fmt.Sprintf("%v", v)
+	}
+	
+	// Create workflow request
+	req := &transport.WorkflowRequest{
+		WorkflowID:      workflowID,
+		WorkflowVersion: version,
+		ExecutionID:     executionID,
+		Inputs:          inputMap,
+		TimeoutSeconds:  workflowTimeoutSeconds(def),
+	}
+	
+	o.mu.Lock()
+	o.recordExecutionLocked(executionID, &executionRecord{WorkflowID: workflowID, Version: version, EngineID: engineID})
+	o.mu.Unlock()
+	
+	return o.executeOnEngine(ctx, engineID, req)
+}
+
+// recordExecutionLocked remembers a new execution, forgetting the oldest
+// ones beyond maxExecutionRecords. o.mu must be held.
+func (o *OrchestratorV2) recordExecutionLocked(executionID string, record *executionRecord) {
+	o.executions[executionID] = record
+	o.executionOrder = append(o.executionOrder, executionID)
+	for len(o.executionOrder) > maxExecutionRecords {
+		delete(o.executions, o.executionOrder[0])
+		o.executionOrder[0] = ""
+		o.executionOrder = o.executionOrder[1:]
+	}
+}
+
+// RerunExecution executes again a node and all its descendants in a finished
+// execution. The request goes to the engine that ran the execution, which
+// reuses the results it checkpointed for the other nodes. It fails with
+// ErrExecutionNotFound or ErrNodeNotFound when either is unknown.
+func (o *OrchestratorV2) RerunExecution(ctx context.Context, executionID, fromNode string) (*WorkflowResponse, error) {
 	o.mu.RLock()
-	engineInfo, exists := o.engines[engineID]
+	record, exists := o.executions[executionID]
 	o.mu.RUnlock()
 	
 	if !exists {
-		return nil, fmt.Errorf("engine %s not found", engineID)
+		return nil, fmt.Errorf("%w: %s", ErrExecutionNotFound, executionID)
 	}
 	
-	// Build workflow definition
-	def, err := o.workflowManager.BuildWorkflow(workflowID)
+	def, err := o.workflowManager.GetWorkflowDefinition(record.WorkflowID, record.Version)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build workflow: %w", err)
+		return nil, fmt.Errorf("failed to get workflow version: %w", err)
+	}
+	if !hasNode(def, fromNode) {
+		return nil, fmt.Errorf("%w: workflow %s has no node %s", ErrNodeNotFound, record.WorkflowID, fromNode)
 	}
 	
-	// Convert inputs to map[string]string for transport
-	inputMap := make(map[string]string)
-	for k, v := range inputs {
-		inputMap[k] = fmt.Sprintf("%v", v)
+	req := &transport.WorkflowRequest{
+		WorkflowID:      record.WorkflowID,
+		WorkflowVersion: record.Version,
+		ExecutionID:     executionID,
+		TimeoutSeconds:  workflowTimeoutSeconds(def),
+		RerunFrom:       fromNode,
+	}
+	
+	return o.executeOnEngine(ctx, record.EngineID, req)
+}
+
+// hasNode reports whether def declares a node with the given ID.
+func hasNode(def *WorkflowDefinition, nodeID string) bool {
+	for _, node := range def.Nodes {
+		if node.NodeID == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
+// executeOnEngine sends a workflow request to an engine and waits for its response.
+func (o *OrchestratorV2) executeOnEngine(ctx context.Context, engineID string, req *transport.WorkflowRequest) (*WorkflowResponse, error) {
+	workflowID := req.WorkflowID
+	
+	// Get engine info
+	o.mu.RLock()
+	engineInfo, exists := o.engines[engineID]
+	o.mu.RUnlock()
+	
+	if !exists {
+		return nil, fmt.Errorf("engine %s not found", engineID)
 	}
 	
 	// Create transport connection
@@ -153,15 +261,6 @@ func (o *OrchestratorV2) ExecuteWorkflow(ctx context.Context, workflowID string,
 	}
 	defer conn.Close()
 	
-	// Create workflow request
-	req := &transport.WorkflowRequest{
-		WorkflowID:      workflowID,
-		WorkflowVersion: version,
-		ExecutionID:     executionID,
-		Inputs:          inputMap,
-		TimeoutSeconds:  600, // 10 minutes default
-	}
-	
 	// Update load balancer active workflows
 	o.loadBalancer.(*ConsistentHashLoadBalancer).IncrementActiveWorkflows(engineID)
 	defer func() {
@@ -173,6 +272,13 @@ func (o *OrchestratorV2) ExecuteWorkflow(ctx context.Context, workflowID string,
 	resp, err := conn.ExecuteWorkflow(ctx, req)
 	duration := time.Since(startTime)
 	
+	if ctx.Err() != nil {
+		// The engine keeps running the workflow unless told to stop
+		stopCtx, cancel := context.WithTimeout(context.Background(), stopWorkflowTimeout)
+		conn.StopWorkflow(stopCtx, req.ExecutionID)
+		cancel()
+	}
+	
 	if err != nil {
 		return &WorkflowResponse{
 			WorkflowID: workflowID,
@@ -181,6 +287,8 @@ func (o *OrchestratorV2) ExecuteWorkflow(ctx context.Context, workflowID string,
 		}, err
 	}
 	
+	o.recordNodeStatuses(req.ExecutionID, resp.NodeResults)
+	
 	// Convert outputs back
 	outputs := make(map[string]interface{})
 	for k, v := range resp.Outputs {
@@ -194,12 +302,77 @@ func (o *OrchestratorV2) ExecuteWorkflow(ctx context.Context, workflowID string,
 		Duration:   duration.Nanoseconds(),
 		Metadata: map[string]interface{}{
 			"engine_id":     engineID,
-			"execution_id":  executionID,
-			"version":       version,
+			"execution_id":  req.ExecutionID,
+			"version":       req.WorkflowVersion,
 		},
 	}, nil
 }
 
+// recordNodeStatuses keeps the node statuses reported for an execution.
+func (o *OrchestratorV2) recordNodeStatuses(executionID string, results []*transport.NodeResult) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	
+	record, exists := o.executions[executionID]
+	if !exists {
+		return
+	}
+	statuses := make(map[string]string, len(results))
+	for _, result := range results {
+		statuses[result.NodeID] = result.Status
+	}
+	record.NodeStatuses = statuses
+}
+
+// GetExecution returns what is known about an execution: the workflow
+// version it ran and the last reported status of each node.
+func (o *OrchestratorV2) GetExecution(executionID string) (*ExecutionInfo, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	
+	record, exists := o.executions[executionID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrExecutionNotFound, executionID)
+	}
+	
+	statuses := make(map[string]string, len(record.NodeStatuses))
+	for nodeID, status := range record.NodeStatuses {
+		statuses[nodeID] = status
+	}
+	return &ExecutionInfo{
+		ExecutionID:  executionID,
+		WorkflowID:   record.WorkflowID,
+		Version:      record.Version,
+		EngineID:     record.EngineID,
+		NodeStatuses: statuses,
+	}, nil
+}
+
+// WorkflowTimeout returns the deadline declared by the workflow's definition.
+func (o *OrchestratorV2) WorkflowTimeout(workflowID string) time.Duration {
+	def, err := o.workflowManager.BuildWorkflow(workflowID)
+	if err != nil {
+		return DefaultWorkflowTimeout
+	}
+	return workflowDefinitionTimeout(def)
+}
+
+// workflowDefinitionTimeout returns the deadline for a run of def.
+func workflowDefinitionTimeout(def *WorkflowDefinition) time.Duration {
+	if def == nil || def.Timeout <= 0 {
+		return DefaultWorkflowTimeout
+	}
+	return def.Timeout
+}
+
+// workflowTimeoutSeconds returns the deadline for a run of def in whole
+// seconds, as engines take it, rounded up so that a sub-second timeout does
+// not become 0.
+func workflowTimeoutSeconds(def *WorkflowDefinition) int64 {
+	timeout := workflowDefinitionTimeout(def)
+	return int64((timeout + time.Second - 1) / time.Second)
+}
+
 // startDiscovery starts the engine discovery process
 func (o *OrchestratorV2) startDiscovery() error {
 	// Use Watch for real-time updates
@@ -115,8 +115,15 @@ func ExtractSecretsFromConfig(config map[string]interface{}) []SecretRef {
 		return nil
 	}
 
-	secretsMeta, ok := configMeta["secrets"].([]interface{})
-	if !ok {
+	var secretsMeta []interface{}
+	switch meta := configMeta["secrets"].(type) {
+	case []interface{}:
+		secretsMeta = meta
+	case []map[string]interface{}:
+		for _, sec := range meta {
+			secretsMeta = append(secretsMeta, sec)
+		}
+	default:
 		return nil
 	}
 
@@ -144,6 +151,8 @@ func ExtractSecretsFromConfig(config map[string]interface{}) []SecretRef {
 					ref.Keys[k] = vStr
 				}
 			}
+		} else if keys, ok := secMap["keys"].(map[string]string); ok {
+			ref.Keys = keys
 		}
 
 		secrets = append(secrets, ref)
@@ -152,6 +161,30 @@ func ExtractSecretsFromConfig(config map[string]interface{}) []SecretRef {
 	return secrets
 }
 
+// Lookup returns a function that reads a key of one of the secrets in refs,
+// for executors that resolve secrets while they run. Secrets not in refs,
+// and keys not listed by a ref that lists keys, cannot be read.
+func (sm *SecretsManager) Lookup(refs []SecretRef) func(ctx context.Context, name, key string) (string, error) {
+	return func(ctx context.Context, name, key string) (string, error) {
+		for _, ref := range refs {
+			if ref.Name != name {
+				continue
+			}
+			if len(ref.Keys) > 0 {
+				if _, ok := ref.Keys[key]; !ok {
+					return "", fmt.Errorf("key %s of secret %s is not declared by the workflow", key, name)
+				}
+			}
+			value, err := sm.GetSecretValue(ctx, ref.Name, ref.Namespace, key)
+			if err != nil {
+				return "", err
+			}
+			return string(value), nil
+		}
+		return "", fmt.Errorf("secret %s is not declared by the workflow", name)
+	}
+}
+
 // SecretRef represents a reference to a Kubernetes secret
 type SecretRef struct {
 	Name      string
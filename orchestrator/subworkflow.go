@@ -2,9 +2,12 @@ package orchestrator
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/gbasilveira/dag-engine/dagengine"
 )
 
 // SubWorkflowExecution tracks a sub-workflow execution
@@ -19,8 +22,19 @@ type SubWorkflowExecution struct {
 	Error             error
 	StartTime         time.Time
 	EndTime           *time.Time
+	cancel            context.CancelFunc // Stops the execution, for executions run by sub-workflow nodes
+}
+
+// versionedOrchestrator is implemented by orchestrators that can run a
+// version of a workflow other than the latest
+type versionedOrchestrator interface {
+	ExecuteWorkflowVersion(ctx context.Context, workflowID, constraint string, inputs map[string]interface{}) (*WorkflowResponse, error)
 }
 
+// finishedSubWorkflowRetention is how long a finished execution started with
+// ExecuteSubWorkflow stays available to GetSubWorkflowStatus
+const finishedSubWorkflowRetention = time.Hour
+
 // SubWorkflowCoordinator coordinates sub-workflow executions
 type SubWorkflowCoordinator struct {
 	activeSubWorkflows map[string]*SubWorkflowExecution // executionID -> execution
@@ -42,15 +56,18 @@ func NewSubWorkflowCoordinator(orch interface {
 	}
 }
 
-// ExecuteSubWorkflow executes a sub-workflow through the orchestrator
+// ExecuteSubWorkflow executes a sub-workflow through the orchestrator. Its
+// status stays available for finishedSubWorkflowRetention after it finishes
 func (swc *SubWorkflowCoordinator) ExecuteSubWorkflow(ctx context.Context, 
 	subWorkflowID, subWorkflowVersion, parentWorkflowID, parentExecutionID string,
 	inputs map[string]interface{}) (string, error) {
 	
 	// Generate execution ID for sub-workflow
 	executionID := fmt.Sprintf("%s-sub-%d", parentExecutionID, time.Now().UnixNano())
+	ctx, cancel := context.WithCancel(ctx)
 	
 	swc.mu.Lock()
+	swc.pruneFinishedLocked(time.Now())
 	
 	// Create sub-workflow execution record
 	exec := &SubWorkflowExecution{
@@ -61,6 +78,7 @@ func (swc *SubWorkflowCoordinator) ExecuteSubWorkflow(ctx context.Context,
 		ParentExecutionID: parentExecutionID,
 		Status:            "PENDING",
 		StartTime:         time.Now(),
+		cancel:            cancel,
 	}
 	
 	swc.activeSubWorkflows[executionID] = exec
@@ -87,6 +105,7 @@ func (swc *SubWorkflowCoordinator) ExecuteSubWorkflow(ctx context.Context,
 			now := time.Now()
 			exec.EndTime = &now
 			swc.mu.Unlock()
+			cancel()
 		}()
 		
 		swc.mu.Lock()
@@ -96,7 +115,9 @@ func (swc *SubWorkflowCoordinator) ExecuteSubWorkflow(ctx context.Context,
 		response, err := swc.orchestrator.ExecuteWorkflow(ctx, subWorkflowID, inputs)
 		
 		swc.mu.Lock()
-		if err != nil {
+		if exec.Status == "CANCELLED" {
+			exec.Error = err
+		} else if err != nil {
 			exec.Status = "FAILED"
 			exec.Error = err
 		} else {
@@ -109,6 +130,143 @@ func (swc *SubWorkflowCoordinator) ExecuteSubWorkflow(ctx context.Context,
 	return executionID, nil
 }
 
+// Runner returns the runner that the sub-workflow nodes of a parent
+// workflow use (see dagengine.ExecutorHost). Unlike ExecuteSubWorkflow, it
+// waits for the child to finish, and stops it when the node's context is
+// done or the parent's children are cancelled.
+func (swc *SubWorkflowCoordinator) Runner(parentWorkflowID string) dagengine.SubWorkflowRunner {
+	return dagengine.SubWorkflowRunnerFunc(func(ctx context.Context, req *dagengine.SubWorkflowRequest) (*dagengine.SubWorkflowResult, error) {
+		return swc.runSubWorkflow(ctx, parentWorkflowID, req)
+	})
+}
+
+// runSubWorkflow runs a sub-workflow for a node of a parent run and waits
+// for it to finish
+func (swc *SubWorkflowCoordinator) runSubWorkflow(ctx context.Context, parentWorkflowID string, req *dagengine.SubWorkflowRequest) (*dagengine.SubWorkflowResult, error) {
+	versioned, canPin := swc.orchestrator.(versionedOrchestrator)
+	if !canPin && req.Version != "" && req.Version != "latest" {
+		return nil, dagengine.Permanent(fmt.Errorf("orchestrator cannot run version %s of workflow %s", req.Version, req.WorkflowID))
+	}
+	
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	
+	executionID := fmt.Sprintf("%s-sub-%d", req.ParentRunID, time.Now().UnixNano())
+	exec := &SubWorkflowExecution{
+		ExecutionID:       executionID,
+		SubWorkflowID:     req.WorkflowID,
+		SubWorkflowVersion: req.Version,
+		ParentWorkflowID:  parentWorkflowID,
+		ParentExecutionID: req.ParentRunID,
+		Status:            "RUNNING",
+		StartTime:         time.Now(),
+		cancel:            cancel,
+	}
+	
+	swc.mu.Lock()
+	swc.activeSubWorkflows[executionID] = exec
+	swc.parentToChildren[req.ParentRunID] = append(swc.parentToChildren[req.ParentRunID], executionID)
+	swc.mu.Unlock()
+	
+	// The node gets the outcome, so nothing reads the record once it is done
+	defer func() {
+		swc.mu.Lock()
+		swc.forgetLocked(exec)
+		swc.mu.Unlock()
+	}()
+	
+	// Add parent context information to a copy of the inputs
+	inputs := make(map[string]interface{}, len(req.Inputs)+2)
+	for k, v := range req.Inputs {
+		inputs[k] = v
+	}
+	inputs["_parent_workflow_id"] = parentWorkflowID
+	inputs["_parent_execution_id"] = req.ParentRunID
+	
+	var response *WorkflowResponse
+	var err error
+	if canPin {
+		response, err = versioned.ExecuteWorkflowVersion(ctx, req.WorkflowID, req.Version, inputs)
+	} else {
+		response, err = swc.orchestrator.ExecuteWorkflow(ctx, req.WorkflowID, inputs)
+	}
+	if err == nil && !response.Success {
+		err = fmt.Errorf("workflow %s did not succeed", req.WorkflowID)
+	}
+	
+	swc.mu.Lock()
+	defer swc.mu.Unlock()
+	now := time.Now()
+	exec.EndTime = &now
+	switch {
+	case err != nil && (ctx.Err() != nil || exec.Status == "CANCELLED"):
+		exec.Status = "CANCELLED"
+		exec.Error = err
+		return nil, err
+	case err != nil:
+		exec.Status = "FAILED"
+		exec.Error = err
+		return nil, err
+	}
+	exec.Status = "COMPLETED"
+	exec.Outputs = response.Outputs
+	
+	result := &dagengine.SubWorkflowResult{ExecutionID: executionID, Outputs: childOutputs(response.Outputs)}
+	if id, ok := response.Metadata["execution_id"].(string); ok {
+		result.ExecutionID = id
+	}
+	if version, ok := response.Metadata["version"].(string); ok {
+		result.Version = version
+		exec.SubWorkflowVersion = version
+	}
+	return result, nil
+}
+
+// pruneFinishedLocked forgets the executions that finished longer than
+// finishedSubWorkflowRetention before now. swc.mu must be held
+func (swc *SubWorkflowCoordinator) pruneFinishedLocked(now time.Time) {
+	for _, exec := range swc.activeSubWorkflows {
+		if exec.EndTime != nil && now.Sub(*exec.EndTime) > finishedSubWorkflowRetention {
+			swc.forgetLocked(exec)
+		}
+	}
+}
+
+// forgetLocked removes an execution, and its parent's entry once it has no
+// children left. swc.mu must be held
+func (swc *SubWorkflowCoordinator) forgetLocked(exec *SubWorkflowExecution) {
+	delete(swc.activeSubWorkflows, exec.ExecutionID)
+	children := swc.parentToChildren[exec.ParentExecutionID]
+	for i, childID := range children {
+		if childID == exec.ExecutionID {
+			children = append(children[:i:i], children[i+1:]...)
+			break
+		}
+	}
+	if len(children) == 0 {
+		delete(swc.parentToChildren, exec.ParentExecutionID)
+	} else {
+		swc.parentToChildren[exec.ParentExecutionID] = children
+	}
+}
+
+// childOutputs returns the outputs of a child workflow with the node results
+// that engines send as JSON decoded, so that sub-workflow nodes can map
+// their fields
+func childOutputs(outputs map[string]interface{}) map[string]interface{} {
+	decoded := make(map[string]interface{}, len(outputs))
+	for nodeID, output := range outputs {
+		decoded[nodeID] = output
+		if s, ok := output.(string); ok {
+			var result map[string]interface{}
+			if err := json.Unmarshal([]byte(s), &result); err == nil {
+				decoded[nodeID] = result
+			}
+		}
+	}
+	return decoded
+}
+
 // GetSubWorkflowStatus returns the status of a sub-workflow
 func (swc *SubWorkflowCoordinator) GetSubWorkflowStatus(executionID string) (*SubWorkflowExecution, error) {
 	swc.mu.RLock()
@@ -160,12 +318,14 @@ func (swc *SubWorkflowCoordinator) CancelChildren(parentExecutionID string) erro
 		exec, exists := swc.activeSubWorkflows[childID]
 		swc.mu.RUnlock()
 		
+		swc.mu.Lock()
 		if exists && exec.Status == "RUNNING" {
-			// TODO: Cancel through orchestrator
-			swc.mu.Lock()
 			exec.Status = "CANCELLED"
-			swc.mu.Unlock()
+			if exec.cancel != nil {
+				exec.cancel()
+			}
 		}
+		swc.mu.Unlock()
 	}
 	
 	return nil
@@ -92,6 +92,7 @@ func (gc *grpcConnection) ExecuteWorkflow(ctx context.Context, req *WorkflowRequ
 		ParentWorkflowId: req.ParentWorkflowID,
 		ParentExecutionId: req.ParentExecutionID,
 		TimeoutSeconds:  req.TimeoutSeconds,
+		RerunFrom:       req.RerunFrom,
 	}
 	
 	resp, err := gc.client.ExecuteWorkflow(ctx, protoReq)
@@ -69,6 +69,7 @@ type WorkflowRequest struct {
 	ParentWorkflowID string
 	ParentExecutionID string
 	TimeoutSeconds   int64
+	RerunFrom        string // If set, rerun this node and its descendants in the finished execution ExecutionID
 }
 
 // WorkflowResponse represents a workflow execution response
@@ -87,6 +88,7 @@ type NodeResult struct {
 	Status      string
 	Outputs     map[string]string
 	ErrorMessage string
+	Attempts    int
 }
 
 // SubWorkflowRequest represents a sub-workflow execution request
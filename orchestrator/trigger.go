@@ -1,12 +1,38 @@
 package orchestrator
 
-import "context"
+import (
+	"context"
+	"time"
+)
+
+// DefaultWorkflowTimeout is the deadline for a workflow run whose definition does not declare one.
+const DefaultWorkflowTimeout = 10 * time.Minute
 
 // WorkflowExecutor defines the interface for executing workflows
 type WorkflowExecutor interface {
 	ExecuteWorkflow(ctx context.Context, workflowID string, inputs map[string]interface{}) (*WorkflowResponse, error)
 }
 
+// WorkflowTimeoutProvider is implemented by executors that know the deadline
+// declared in a workflow's definition.
+type WorkflowTimeoutProvider interface {
+	WorkflowTimeout(workflowID string) time.Duration
+}
+
+// resolveWorkflowTimeout returns the deadline for one execution triggered by a trigger:
+// the trigger's own override, then the workflow definition, then DefaultWorkflowTimeout.
+func resolveWorkflowTimeout(executor WorkflowExecutor, workflowID string, override time.Duration) time.Duration {
+	if override > 0 {
+		return override
+	}
+	if provider, ok := executor.(WorkflowTimeoutProvider); ok {
+		if timeout := provider.WorkflowTimeout(workflowID); timeout > 0 {
+			return timeout
+		}
+	}
+	return DefaultWorkflowTimeout
+}
+
 // Trigger represents an event source that can initiate workflow execution.
 type Trigger interface {
 	// ID returns the unique identifier for this trigger.
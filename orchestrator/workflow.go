@@ -2,6 +2,7 @@ package orchestrator
 
 import (
 	"context"
+	"time"
 	"github.com/gbasilveira/dag-engine/dagengine"
 )
 
@@ -12,6 +13,8 @@ type Workflow struct {
 	Description string
 	// Builder function that creates a configured DAG engine for this workflow
 	Builder func() (*dagengine.DAGEngine, error)
+	// Deadline for a whole run (0: DefaultWorkflowTimeout)
+	Timeout time.Duration
 	// Metadata for workflows
 	Metadata map[string]interface{}
 }
@@ -108,6 +108,20 @@ func (wm *WorkflowManager) GetLatestVersion(workflowID string) (string, error) {
 	return version.Version, nil
 }
 
+// ResolveVersion returns the newest version of a workflow that matches a
+// constraint
+func (wm *WorkflowManager) ResolveVersion(workflowID, constraint string) (string, error) {
+	wm.mu.RLock()
+	defer wm.mu.RUnlock()
+	
+	version, err := wm.versions.ResolveVersion(workflowID, constraint)
+	if err != nil {
+		return "", err
+	}
+	
+	return version.Version, nil
+}
+
 // HasWorkflow checks if a workflow exists
 func (wm *WorkflowManager) HasWorkflow(workflowID string) bool {
 	wm.mu.RLock()
@@ -147,6 +161,11 @@ func extractDependencies(def *WorkflowDefinition) []string {
 				depMap[wfID] = true
 			}
 		}
+		if node.ExecutorType == "subworkflow" {
+			if wfID, ok := node.ExecutorConfig["workflow_id"].(string); ok {
+				depMap[wfID] = true
+			}
+		}
 	}
 	
 	deps := make([]string, 0, len(depMap))
@@ -3,6 +3,9 @@ package orchestrator
 import (
 	"fmt"
 	"sync"
+	"time"
+
+	"github.com/gbasilveira/dag-engine/dagengine"
 )
 
 // WorkflowVersion represents a versioned workflow
@@ -18,11 +21,15 @@ type WorkflowVersion struct {
 
 // WorkflowDefinition holds the actual workflow structure
 type WorkflowDefinition struct {
-	WorkflowID string
-	Version    string
-	Name       string
-	Nodes      []NodeDefinition
-	Metadata   map[string]interface{}
+	WorkflowID    string
+	Version       string
+	Name          string
+	Nodes         []NodeDefinition
+	FailurePolicy string // How node failures affect the run (see dagengine.FailurePolicy)
+	Timeout       time.Duration // Deadline for a whole run (0: DefaultWorkflowTimeout, 10 minutes)
+	MaxConcurrency int           // Maximum nodes running at once (0: no limit)
+	Pools         map[string]int // Named resource pools and their slots
+	Metadata      map[string]interface{}
 }
 
 // NodeDefinition represents a node in the workflow
@@ -32,9 +39,40 @@ type NodeDefinition struct {
 	ExecutorType string
 	ExecutorCode string
 	ExecutorConfig map[string]interface{}
+	Retry       *dagengine.RetryPolicy // Optional retry policy (default: the executor type's)
+	Timeout     time.Duration          // Deadline for each attempt (0: no limit)
+	Condition   string                 // Optional expression deciding whether the node runs
+	TriggerRule string                 // See dagengine.TriggerRule (default: all_success)
+	Map         *dagengine.MapConfig   // Optional fan-out over an upstream list
+	Priority    int                    // Higher priority nodes start first when slots are scarce
+	Pool        string                 // Optional resource pool the node claims slots of
+	PoolSlots   int                    // Slots claimed in Pool (default: 1)
+	Cache       *dagengine.CacheConfig // Optional reuse of results for identical code and inputs
+	EstimatedDuration time.Duration    // Expected run time, used by plan analysis (0: unknown)
 	Metadata    map[string]interface{}
 }
 
+// Graph returns the graph of the workflow's nodes in definition order, for
+// export with dagengine.Graph.Export.
+func (def *WorkflowDefinition) Graph() *dagengine.Graph {
+	nodes := make([]dagengine.GraphNode, 0, len(def.Nodes))
+	deps := make(map[string][]string, len(def.Nodes))
+	for _, nodeDef := range def.Nodes {
+		node := dagengine.GraphNode{ID: nodeDef.NodeID, Executor: nodeDef.ExecutorType}
+		if nodeDef.Map != nil {
+			node.MapOver = nodeDef.Map.Over
+		}
+		nodes = append(nodes, node)
+		deps[nodeDef.NodeID] = nodeDef.Dependencies
+	}
+
+	name := def.Name
+	if name == "" {
+		name = def.WorkflowID
+	}
+	return dagengine.NewGraph(name, nodes, deps)
+}
+
 // VersionManager manages workflow versions and their dependencies
 type VersionManager struct {
 	versions   map[string]map[string]*WorkflowVersion // workflowID -> version -> WorkflowVersion
@@ -105,6 +143,30 @@ func (vm *VersionManager) GetVersion(workflowID, version string) (*WorkflowVersi
 	return v, nil
 }
 
+// ResolveVersion retrieves the newest version of a workflow that matches a
+// constraint (see dagengine.VersionMatches)
+func (vm *VersionManager) ResolveVersion(workflowID, constraint string) (*WorkflowVersion, error) {
+	vm.mu.RLock()
+	defer vm.mu.RUnlock()
+	
+	versions, exists := vm.versions[workflowID]
+	if !exists {
+		return nil, fmt.Errorf("workflow %s not found", workflowID)
+	}
+	
+	var newest *WorkflowVersion
+	for version, v := range versions {
+		if dagengine.VersionMatches(constraint, version) && (newest == nil || isNewerVersion(version, newest.Version)) {
+			newest = v
+		}
+	}
+	if newest == nil {
+		return nil, fmt.Errorf("workflow %s has no version matching %s", workflowID, constraint)
+	}
+	
+	return newest, nil
+}
+
 // GetLatestVersion retrieves the latest version of a workflow
 func (vm *VersionManager) GetLatestVersion(workflowID string) (*WorkflowVersion, error) {
 	vm.mu.RLock()
@@ -208,10 +270,9 @@ func (vm *VersionManager) updateDependencies(version *WorkflowVersion) {
 	}
 }
 
-// isNewerVersion compares two semantic versions (simplified)
+// isNewerVersion reports whether v1 is newer than v2, comparing numeric
+// parts as numbers (see dagengine.CompareVersions)
 func isNewerVersion(v1, v2 string) bool {
-	// Simple comparison - in production, use a proper semver library
-	// For now, assume lexicographic comparison for simplicity
-	return v1 > v2
+	return dagengine.CompareVersions(v1, v2) > 0
 }
 
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/gbasilveira/dag-engine/dagengine"
 )
 
 // Validate validates a WorkflowSpec and returns any errors
@@ -86,15 +88,49 @@ func (wsd *WorkflowSpecDef) Validate() error {
 		nodeIDs[node.ID] = true
 	}
 
-	// Validate dependencies reference existing nodes
-	for i, node := range wsd.Nodes {
-		for _, dep := range node.Dependencies {
-			if !nodeIDs[dep] {
-				return fmt.Errorf("node[%d] depends on non-existent node: %s", i, dep)
-			}
+	// Validate the dependency graph with the same topological pass the engine uses:
+	// missing and duplicate dependencies, self-dependencies, cycles and unreachable nodes
+	ids := make([]string, 0, len(wsd.Nodes))
+	deps := make(map[string][]string, len(wsd.Nodes))
+	for _, node := range wsd.Nodes {
+		ids = append(ids, node.ID)
+		deps[node.ID] = node.Dependencies
+	}
+	if err := dagengine.ValidateGraph(ids, deps); err != nil {
+		return err
+	}
+
+	if wsd.MaxConcurrency < 0 {
+		return fmt.Errorf("max_concurrency must not be negative: %d", wsd.MaxConcurrency)
+	}
+
+	for name, size := range wsd.Pools {
+		if size < 1 {
+			return fmt.Errorf("pool %s must have at least 1 slot: %d", name, size)
 		}
 	}
 
+	for _, node := range wsd.Nodes {
+		if node.Pool == "" {
+			continue
+		}
+		size, exists := wsd.Pools[node.Pool]
+		if !exists {
+			return fmt.Errorf("node %s uses undefined pool: %s", node.ID, node.Pool)
+		}
+		if node.PoolSlots > size {
+			return fmt.Errorf("node %s claims %d slots of pool %s, which only has %d", node.ID, node.PoolSlots, node.Pool, size)
+		}
+	}
+
+	if wsd.TimeoutSec < 0 {
+		return fmt.Errorf("timeout_seconds must not be negative: %d", wsd.TimeoutSec)
+	}
+
+	if wsd.FailurePolicy != "" && !dagengine.FailurePolicy(wsd.FailurePolicy).Valid() {
+		return fmt.Errorf("unsupported failure_policy: %s (supported: fail_fast, continue, best_effort)", wsd.FailurePolicy)
+	}
+
 	// Validate triggers if present
 	if wsd.Triggers != nil {
 		if err := wsd.Triggers.Validate(); err != nil {
@@ -126,31 +162,92 @@ func (ns *NodeSpec) Validate() error {
 		return fmt.Errorf("executor: %v", err)
 	}
 
+	if ns.TimeoutSec < 0 {
+		return fmt.Errorf("timeout_seconds must not be negative: %d", ns.TimeoutSec)
+	}
+
+	if !dagengine.TriggerRule(ns.TriggerRule).Valid() {
+		return fmt.Errorf("unsupported trigger_rule: %s (supported: all_success, all_done, one_failed, one_success, none_failed)", ns.TriggerRule)
+	}
+
+	if ns.Condition != "" {
+		if _, err := dagengine.CompileCondition(ns.Condition); err != nil {
+			return fmt.Errorf("condition: %v", err)
+		}
+	}
+
+	if ns.PoolSlots < 0 {
+		return fmt.Errorf("pool_slots must not be negative: %d", ns.PoolSlots)
+	}
+
+	if ns.EstimatedDurationSec < 0 {
+		return fmt.Errorf("estimated_duration_seconds must not be negative: %v", ns.EstimatedDurationSec)
+	}
+
+	if ns.Cache != nil && ns.Cache.TTLSec < 0 {
+		return fmt.Errorf("cache: ttl_seconds must not be negative: %d", ns.Cache.TTLSec)
+	}
+
+	if ns.Map != nil {
+		if err := ns.Map.Validate(ns.Dependencies); err != nil {
+			return fmt.Errorf("map: %v", err)
+		}
+	}
+
+	if ns.Retry != nil {
+		if err := ns.Retry.Validate(); err != nil {
+			return fmt.Errorf("retry: %v", err)
+		}
+	}
+
 	return nil
 }
 
-// Validate validates ExecutorSpec
-func (es *ExecutorSpec) Validate() error {
-	if es.Type == "" {
-		return fmt.Errorf("type is required")
+// Validate validates MapSpec against the dependencies of its node
+func (ms *MapSpec) Validate(dependencies []string) error {
+	mapConfig := dagengine.MapConfig{Over: ms.Over, As: ms.As, Concurrency: ms.Concurrency}
+	return mapConfig.Validate(dependencies)
+}
+
+// Validate validates RetrySpec
+func (rs *RetrySpec) Validate() error {
+	if rs.MaxAttempts < 1 {
+		return fmt.Errorf("max_attempts must be at least 1: %d", rs.MaxAttempts)
 	}
 
-	supportedTypes := map[string]bool{
-		"lua":   true,
-		"shell": true,
+	if rs.Backoff != "" && rs.Backoff != "fixed" && rs.Backoff != "exponential" {
+		return fmt.Errorf("unsupported backoff: %s (supported: fixed, exponential)", rs.Backoff)
 	}
 
-	if !supportedTypes[es.Type] {
-		return fmt.Errorf("unsupported executor type: %s (supported: lua, shell)", es.Type)
+	if rs.InitialDelaySeconds < 0 || rs.MaxDelaySeconds < 0 {
+		return fmt.Errorf("delays must not be negative")
 	}
 
-	if es.Type == "lua" && es.Code == "" {
-		return fmt.Errorf("code is required for lua executor")
+	if rs.Multiplier < 0 {
+		return fmt.Errorf("multiplier must not be negative: %v", rs.Multiplier)
+	}
+
+	if rs.Jitter < 0 || rs.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1: %v", rs.Jitter)
 	}
 
 	return nil
 }
 
+// Validate validates ExecutorSpec against the executor types registered in
+// dagengine.DefaultExecutorRegistry
+func (es *ExecutorSpec) Validate() error {
+	if es.Type == "" {
+		return fmt.Errorf("type is required")
+	}
+
+	return dagengine.DefaultExecutorRegistry().Validate(dagengine.ExecutorSpec{
+		Type:   es.Type,
+		Code:   es.Code,
+		Config: es.Config,
+	})
+}
+
 // Validate validates TriggersSpec
 func (ts *TriggersSpec) Validate() error {
 	if ts.HTTP != nil {
@@ -21,6 +21,10 @@ type WorkflowMetadata struct {
 // WorkflowSpecDef contains the actual workflow specification
 type WorkflowSpecDef struct {
 	Nodes         []NodeSpec       `yaml:"nodes"`
+	FailurePolicy string           `yaml:"failure_policy,omitempty"` // "fail_fast" (default), "continue", "best_effort"
+	TimeoutSec    int              `yaml:"timeout_seconds,omitempty"` // Deadline for a whole run (0: the orchestrator's default, 10 minutes)
+	MaxConcurrency int             `yaml:"max_concurrency,omitempty"` // Maximum nodes running at once (0: no limit)
+	Pools         map[string]int   `yaml:"pools,omitempty"`           // Named resource pools and their slots, e.g. db: 2
 	Triggers      *TriggersSpec     `yaml:"triggers,omitempty"`
 	Configuration *ConfigSpec       `yaml:"configuration,omitempty"`
 }
@@ -30,12 +34,45 @@ type NodeSpec struct {
 	ID           string                 `yaml:"id"`
 	Dependencies []string               `yaml:"dependencies,omitempty"`
 	Executor     ExecutorSpec           `yaml:"executor"`
+	Retry        *RetrySpec             `yaml:"retry,omitempty"`
+	TimeoutSec   int                    `yaml:"timeout_seconds,omitempty"` // Deadline for each attempt (0: no limit)
+	Condition    string                 `yaml:"condition,omitempty"`       // Run only if this expression holds, e.g. "transform.rows > 0"
+	TriggerRule  string                 `yaml:"trigger_rule,omitempty"`    // all_success (default), all_done, one_failed, one_success, none_failed
+	Map          *MapSpec               `yaml:"map,omitempty"`             // Run the executor once per element of an upstream list
+	Priority     int                    `yaml:"priority,omitempty"`        // Higher priority nodes start first when slots are scarce
+	Pool         string                 `yaml:"pool,omitempty"`            // Resource pool the node claims slots of
+	PoolSlots    int                    `yaml:"pool_slots,omitempty"`      // Slots claimed in the pool (default: 1)
+	Cache        *CacheSpec             `yaml:"cache,omitempty"`           // Reuse results for identical code and inputs
+	EstimatedDurationSec float64        `yaml:"estimated_duration_seconds,omitempty"` // Expected run time, used by plan analysis
 	Metadata     map[string]interface{} `yaml:"metadata,omitempty"`
 }
 
+// MapSpec defines a dynamic fan-out over a list produced upstream
+type MapSpec struct {
+	Over        string `yaml:"over"`                  // Path to the list, e.g. "discover.files" or "workflow.items"
+	As          string `yaml:"as,omitempty"`          // Input name for each element (default: item)
+	Concurrency int    `yaml:"concurrency,omitempty"` // Maximum elements processed at once (0: no limit)
+}
+
+// CacheSpec enables reuse of a node's result when its executor and inputs are unchanged
+type CacheSpec struct {
+	TTLSec int `yaml:"ttl_seconds,omitempty"` // How long a result stays valid (0: forever)
+}
+
+// RetrySpec defines how a failed node is retried
+type RetrySpec struct {
+	MaxAttempts         int      `yaml:"max_attempts"`                    // Total attempts including the first
+	Backoff             string   `yaml:"backoff,omitempty"`               // "fixed" (default) or "exponential"
+	InitialDelaySeconds float64  `yaml:"initial_delay_seconds,omitempty"`
+	MaxDelaySeconds     float64  `yaml:"max_delay_seconds,omitempty"`
+	Multiplier          float64  `yaml:"multiplier,omitempty"`            // Exponential growth factor (default: 2)
+	Jitter              float64  `yaml:"jitter,omitempty"`                // Fraction of the delay to randomize (0-1)
+	RetryOn             []string `yaml:"retry_on,omitempty"`              // Only retry errors containing one of these substrings
+}
+
 // ExecutorSpec defines the executor for a node
 type ExecutorSpec struct {
-	Type   string                 `yaml:"type"` // "lua", "shell", etc.
+	Type   string                 `yaml:"type"` // "lua", "shell", "http", "grpc", "wasm", "subworkflow", etc.
 	Code   string                 `yaml:"code,omitempty"`
 	Config map[string]interface{} `yaml:"config,omitempty"`
 }